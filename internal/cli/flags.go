@@ -35,6 +35,34 @@ func (o *OptionalDuration) Value() (time.Duration, bool) {
 	return o.value, o.set
 }
 
+// OptionalInterval records an interval flag and whether it was set.
+type OptionalInterval struct {
+	value time.Duration
+	set   bool
+}
+
+// Set accepts a duration or, like the interval directive, a rate such as "30/min".
+func (o *OptionalInterval) Set(s string) error {
+	v, err := config.ParseInterval(s)
+	if err != nil {
+		return err
+	}
+	o.value = v
+	o.set = true
+	return nil
+}
+
+func (o *OptionalInterval) String() string {
+	if !o.set {
+		return ""
+	}
+	return o.value.String()
+}
+
+func (o *OptionalInterval) Value() (time.Duration, bool) {
+	return o.value, o.set
+}
+
 // OptionalInt records an int flag and whether it was set.
 type OptionalInt struct {
 	value int
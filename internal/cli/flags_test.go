@@ -36,6 +36,38 @@ func TestOptionalDurationInvalid(t *testing.T) {
 	}
 }
 
+func TestOptionalDurationRejectsRate(t *testing.T) {
+	var d OptionalDuration
+	if err := d.Set("5/s"); err == nil {
+		t.Fatalf("expected error for a rate given as a timeout")
+	}
+	if _, ok := d.Value(); ok {
+		t.Fatalf("expected rejected duration to remain unset")
+	}
+}
+
+func TestOptionalInterval(t *testing.T) {
+	var i OptionalInterval
+	if _, ok := i.Value(); ok {
+		t.Fatalf("expected unset interval to report false")
+	}
+	if err := i.Set("250ms"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, ok := i.Value(); !ok || v != 250*time.Millisecond {
+		t.Fatalf("expected interval value 250ms, got %v (ok=%v)", v, ok)
+	}
+	if err := i.Set("30/min"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, ok := i.Value(); !ok || v != 2*time.Second {
+		t.Fatalf("expected 30/min to set 2s, got %v (ok=%v)", v, ok)
+	}
+	if err := i.Set("bad"); err == nil {
+		t.Fatalf("expected error for invalid interval")
+	}
+}
+
 func TestOptionalInt(t *testing.T) {
 	var i OptionalInt
 	if i.String() != "" {
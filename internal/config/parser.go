@@ -2,45 +2,196 @@ package config
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"math"
+	"net"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+
+	"github.com/doridoridoriand/surveiller/internal/log"
+	"github.com/doridoridoriand/surveiller/internal/ping"
+)
+
+// StdinPath is the config path that makes LoadConfig read from standard input.
+const StdinPath = "-"
+
+// DefaultMetricsPath is the HTTP path metrics are served on unless metrics.path is set.
+const DefaultMetricsPath = "/metrics"
+
+// DefaultMetricsPrefix is the metric name prefix unless metrics.prefix is set.
+const DefaultMetricsPrefix = "surveiller"
+
+// DefaultGroupName names the group of targets defined before any "---" line
+// unless default_group is set.
+const DefaultGroupName = "default"
+
+// DefaultDownThresholdNG is the number of consecutive failures that turns a
+// target DOWN unless down_threshold_ng is set.
+const DefaultDownThresholdNG = 3
+
+// DefaultShutdownGrace is how long the scheduler waits for in-flight pings on
+// exit unless shutdown_grace is set; shutdown_grace=0 does not wait.
+const DefaultShutdownGrace = 2 * time.Second
+
+// DefaultFlapWindow is the window flap_threshold counts status changes in.
+const DefaultFlapWindow = time.Minute
+
+// Default target count limits. Above the soft limit a warning is logged; above
+// the hard limit surveiller refuses to start unless forced.
+const (
+	DefaultTargetSoftLimit = 1000
+	DefaultTargetHardLimit = 10000
 )
 
 // SurveillerParser implements the Parser interface.
-type SurveillerParser struct{}
+type SurveillerParser struct {
+	// Lenient skips invalid target lines, recording them in Config.Skipped,
+	// instead of failing the whole load. Directive errors still fail.
+	Lenient bool
+}
 
 // DefaultGlobalOptions returns baseline settings used before config overrides.
 func DefaultGlobalOptions() GlobalOptions {
 	return GlobalOptions{
-		Interval:       1 * time.Second,
-		Timeout:        1 * time.Second,
-		MaxConcurrency: 100,
-		MetricsMode:    MetricsModePerTarget,
-		MetricsListen:  "",
-		UIScale:        10,
-		UIDisable:      false,
+		Interval:        1 * time.Second,
+		Timeout:         1 * time.Second,
+		ResolveCacheTTL: 1 * time.Second, // set to the final interval unless resolve_cache_ttl is given
+		MaxConcurrency:  100,
+		MetricsMode:     MetricsModePerTarget,
+		MetricsListen:   "",
+		MetricsPath:     DefaultMetricsPath,
+		MetricsPrefix:   DefaultMetricsPrefix,
+		DefaultGroup:    DefaultGroupName,
+		UIScale:         10,
+		UIDisable:       false,
+		UITheme:         UIThemeUnicode,
+		UIRTTUnit:       RTTUnitAuto,
+		LogLevel:        "info",
+		ShutdownGrace:   DefaultShutdownGrace,
+		FlapWindow:      DefaultFlapWindow,
+		TargetSoftLimit: DefaultTargetSoftLimit,
+		TargetHardLimit: DefaultTargetHardLimit,
 	}
 }
 
 // LoadConfig parses a surveiller.conf file with CLI overrides applied.
+// A path of StdinPath reads the config from standard input.
 func (p SurveillerParser) LoadConfig(path string, overrides CLIOverrides) (*Config, error) {
+	if path == StdinPath {
+		return p.ParseConfig(os.Stdin, overrides)
+	}
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	cfg := &Config{Global: DefaultGlobalOptions()}
+	return p.ParseConfig(file, overrides)
+}
+
+// ParseConfig parses surveiller.conf content from r with CLI overrides applied.
+func (p SurveillerParser) ParseConfig(r io.Reader, overrides CLIOverrides) (*Config, error) {
+	st := newParseState()
+	if err := p.parseInto(st, r, ""); err != nil {
+		return nil, err
+	}
+	return st.finish(overrides)
+}
+
+// LoadConfigDir loads every *.conf file in dir in lexical order as if they were
+// one file: directives apply in order and targets accumulate, with target
+// names unique across all files. Each file starts outside any group. Hidden
+// files are skipped.
+func (p SurveillerParser) LoadConfigDir(dir string, overrides CLIOverrides) (*Config, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	st := newParseState()
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || filepath.Ext(entry.Name()) != ".conf" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if err := p.parseFile(st, path); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return st.finish(overrides)
+}
+
+func (p SurveillerParser) parseFile(st *parseState, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return p.parseInto(st, file, filepath.Base(path))
+}
+
+// parseState accumulates a Config across one or more config files.
+type parseState struct {
+	cfg        *Config
+	groupIndex int
+	seen       map[string]targetLocation
+	// resolveCacheTTLSet records an explicit resolve_cache_ttl; otherwise it
+	// follows the final interval.
+	resolveCacheTTLSet bool
+}
 
-	scanner := bufio.NewScanner(file)
-	groupIndex := 0
+// targetLocation records where a target was defined; file is empty for a
+// single config.
+type targetLocation struct {
+	file string
+	line int
+}
+
+func (l targetLocation) String() string {
+	if l.file == "" {
+		return fmt.Sprintf("line %d", l.line)
+	}
+	return fmt.Sprintf("%s line %d", l.file, l.line)
+}
+
+// applyDirective applies pairs to the global options of st.
+func (st *parseState) applyDirective(pairs map[string]string) error {
+	if _, ok := pairs["resolve_cache_ttl"]; ok {
+		st.resolveCacheTTLSet = true
+	}
+	return applyDirective(&st.cfg.Global, pairs)
+}
+
+func newParseState() *parseState {
+	return &parseState{
+		cfg:  &Config{Global: DefaultGlobalOptions()},
+		seen: make(map[string]targetLocation),
+	}
+}
+
+// parseInto parses one config file into st. source names the file in
+// duplicate-name errors spanning several files.
+func (p SurveillerParser) parseInto(st *parseState, r io.Reader, source string) error {
+	cfg := st.cfg
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanConfigLines)
 	currentGroup := ""
+	lineNo := 0
 
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		lineNo++
+		text := scanner.Text()
+		if lineNo == 1 {
+			// Windows editors may prefix UTF-8 files with a byte order mark.
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		line := strings.TrimSpace(text)
 		if line == "" {
 			continue
 		}
@@ -49,48 +200,175 @@ func (p SurveillerParser) LoadConfig(path string, overrides CLIOverrides) (*Conf
 			if strings.HasPrefix(line, "# surveiller:") {
 				pairs, err := p.ParseSurveillerDirective(line)
 				if err != nil {
-					return nil, err
+					return err
 				}
-				if err := applyDirective(&cfg.Global, pairs); err != nil {
-					return nil, err
+				if err := st.applyDirective(pairs); err != nil {
+					return err
 				}
 			}
 			continue
 		}
 
+		if isComment(line) {
+			continue
+		}
+
 		if strings.HasPrefix(line, "surveiller:") {
 			pairs, err := p.ParseSurveillerDirective(line)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			if err := applyDirective(&cfg.Global, pairs); err != nil {
-				return nil, err
+			if err := st.applyDirective(pairs); err != nil {
+				return err
 			}
 			continue
 		}
 
 		if strings.HasPrefix(line, "---") {
-			groupIndex++
+			st.groupIndex++
 			groupName := strings.TrimSpace(strings.TrimPrefix(line, "---"))
 			if groupName == "" {
-				groupName = fmt.Sprintf("group-%d", groupIndex)
+				groupName = fmt.Sprintf("group-%d", st.groupIndex)
 			}
 			currentGroup = groupName
 			continue
 		}
 
-		target, err := p.ParseTargetLine(line, currentGroup)
+		target, err := p.parseTarget(st, line, currentGroup, lineNo, source)
 		if err != nil {
-			return nil, err
+			if !p.Lenient {
+				return err
+			}
+			cfg.Skipped = append(cfg.Skipped, SkippedLine{File: source, Line: lineNo, Err: err})
+			continue
 		}
+		st.seen[target.Name] = targetLocation{file: source, line: lineNo}
 		cfg.Targets = append(cfg.Targets, target)
 	}
 
-	if err := scanner.Err(); err != nil {
+	return scanner.Err()
+}
+
+// scanConfigLines is a bufio.SplitFunc that ends lines at "\n", "\r\n" or a
+// lone "\r", so configs saved with Windows or classic Mac line endings parse
+// like Unix ones and no "\r" reaches the tokenizer.
+func scanConfigLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// "\r": wait for the next byte to tell "\r\n" from a lone "\r".
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// parseTarget parses and validates the target line at lineNo, including its
+// options and the uniqueness of its name.
+func (p SurveillerParser) parseTarget(st *parseState, line, group string, lineNo int, source string) (TargetConfig, error) {
+	target, err := p.ParseTargetLine(line, group)
+	if err != nil {
+		return TargetConfig{}, err
+	}
+	target.Probe = ProbeICMP
+	if val, ok := target.Options["probe"]; ok {
+		switch ProbeType(val) {
+		case ProbeICMP, ProbeSynthetic:
+			target.Probe = ProbeType(val)
+		default:
+			return TargetConfig{}, fmt.Errorf("invalid probe option on line %d: %q", lineNo, val)
+		}
+	}
+	if val, ok := target.Options["tls"]; ok {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return TargetConfig{}, fmt.Errorf("invalid tls option on line %d: %w", lineNo, err)
+		}
+		if target.Probe != ProbeSynthetic {
+			return TargetConfig{}, fmt.Errorf("invalid tls option on line %d: requires probe=synthetic", lineNo)
+		}
+		target.TLS = b
+	}
+	if val, ok := target.Options["dscp"]; ok {
+		dscp, err := ParseDSCP(val)
+		if err != nil {
+			return TargetConfig{}, fmt.Errorf("invalid dscp option on line %d: %w", lineNo, err)
+		}
+		if target.Probe != ProbeICMP {
+			return TargetConfig{}, fmt.Errorf("invalid dscp option on line %d: requires probe=icmp", lineNo)
+		}
+		target.DSCP = dscp
+	}
+	if err := validateTargetAddress(target); err != nil {
+		return TargetConfig{}, fmt.Errorf("invalid target address on line %d: %w: %q", lineNo, err, line)
+	}
+	if val, ok := target.Options["resolve_interval"]; ok {
+		d, err := parseNonNegativeDuration(val)
+		if err != nil {
+			return TargetConfig{}, fmt.Errorf("invalid resolve_interval on line %d: %w", lineNo, err)
+		}
+		target.ResolveInterval = d
+	}
+	if val, ok := target.Options["metrics"]; ok {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return TargetConfig{}, fmt.Errorf("invalid metrics option on line %d: %w", lineNo, err)
+		}
+		target.ExcludeMetrics = !b
+	}
+	if val, ok := target.Options["mute"]; ok {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return TargetConfig{}, fmt.Errorf("invalid mute option on line %d: %w", lineNo, err)
+		}
+		target.Muted = b
+	}
+	target.Label = target.Options["label"]
+	if first, ok := st.seen[target.Name]; ok {
+		if first.file == source {
+			first.file = ""
+		}
+		return TargetConfig{}, fmt.Errorf("duplicate target name %q on line %d (first defined on %s)", target.Name, lineNo, first)
+	}
+	return target, nil
+}
+
+// finish validates the accumulated config and applies CLI overrides.
+func (st *parseState) finish(overrides CLIOverrides) (*Config, error) {
+	cfg := st.cfg
+	if err := validateThresholds(cfg.Global); err != nil {
 		return nil, err
 	}
 
+	// Stamp ungrouped targets here rather than at parse time: the directive
+	// may appear after them.
+	if cfg.Global.DefaultGroup != DefaultGroupName {
+		for i := range cfg.Targets {
+			if cfg.Targets[i].Group == "" {
+				cfg.Targets[i].Group = cfg.Global.DefaultGroup
+			}
+		}
+	}
+
 	applyCLIOverrides(&cfg.Global, overrides)
+	if !st.resolveCacheTTLSet {
+		cfg.Global.ResolveCacheTTL = cfg.Global.Interval
+	}
 	return cfg, nil
 }
 
@@ -107,8 +385,13 @@ func (p SurveillerParser) ParseSurveillerDirective(line string) (map[string]stri
 		return map[string]string{}, nil
 	}
 
+	// Values may be quoted to contain spaces, e.g. external_ping_cmd="busybox ping".
+	tokens, err := splitFields(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid directive: %w: %q", err, line)
+	}
 	pairs := make(map[string]string)
-	for _, token := range strings.Fields(payload) {
+	for _, token := range tokens {
 		kv := strings.SplitN(token, "=", 2)
 		if len(kv) != 2 {
 			return nil, fmt.Errorf("invalid directive token: %q", token)
@@ -120,10 +403,16 @@ func (p SurveillerParser) ParseSurveillerDirective(line string) (map[string]stri
 
 // ParseTargetLine parses a single target definition.
 func (p SurveillerParser) ParseTargetLine(line string, group string) (TargetConfig, error) {
-	fields := strings.Fields(line)
+	fields, err := splitFields(line)
+	if err != nil {
+		return TargetConfig{}, fmt.Errorf("invalid target line: %w: %q", err, line)
+	}
 	if len(fields) < 2 {
 		return TargetConfig{}, fmt.Errorf("invalid target line: %q", line)
 	}
+	if fields[0] == "" || fields[1] == "" {
+		return TargetConfig{}, fmt.Errorf("invalid target line: empty name or address: %q", line)
+	}
 
 	target := TargetConfig{
 		Name:    fields[0],
@@ -141,15 +430,49 @@ func (p SurveillerParser) ParseTargetLine(line string, group string) (TargetConf
 			target.Options[kv[0]] = kv[1]
 		}
 	}
+	if name, ok := target.Options["group"]; ok {
+		if name == "" {
+			return TargetConfig{}, fmt.Errorf("invalid target option: empty group: %q", line)
+		}
+		target.Group = name
+	}
+	if list, ok := target.Options["groups"]; ok {
+		if _, both := target.Options["group"]; both {
+			return TargetConfig{}, fmt.Errorf("invalid target option: group and groups are exclusive: %q", line)
+		}
+		groups, err := parseGroupList(list)
+		if err != nil {
+			return TargetConfig{}, fmt.Errorf("invalid target option: %w: %q", err, line)
+		}
+		target.Group = groups[0]
+		target.Groups = groups
+	}
 
 	return target, nil
 }
 
+// parseGroupList splits a comma-separated groups= value, dropping repeats.
+func parseGroupList(list string) ([]string, error) {
+	var groups []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("empty group in groups=%s", list)
+		}
+		if !seen[name] {
+			seen[name] = true
+			groups = append(groups, name)
+		}
+	}
+	return groups, nil
+}
+
 func applyDirective(global *GlobalOptions, pairs map[string]string) error {
 	for key, val := range pairs {
 		switch key {
 		case "interval":
-			d, err := time.ParseDuration(val)
+			d, err := ParseInterval(val)
 			if err != nil {
 				return fmt.Errorf("invalid interval: %w", err)
 			}
@@ -160,12 +483,175 @@ func applyDirective(global *GlobalOptions, pairs map[string]string) error {
 				return fmt.Errorf("invalid timeout: %w", err)
 			}
 			global.Timeout = d
+		case "ok_threshold":
+			d, err := parseNonNegativeDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid ok_threshold: %w", err)
+			}
+			global.OKThreshold = d
+		case "warn_threshold":
+			d, err := parseNonNegativeDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid warn_threshold: %w", err)
+			}
+			global.WarnThreshold = d
+		case "rtt_down_threshold":
+			d, err := parseNonNegativeDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid rtt_down_threshold: %w", err)
+			}
+			global.RTTDownThreshold = d
+		case "loss_down_threshold":
+			f, err := strconv.ParseFloat(strings.TrimSuffix(val, "%"), 64)
+			if err != nil {
+				return fmt.Errorf("invalid loss_down_threshold: %w", err)
+			}
+			if f < 0 || f > 100 {
+				return fmt.Errorf("invalid loss_down_threshold: must be between 0 and 100: %q", val)
+			}
+			global.LossDownThreshold = f
+		case "warn_threshold_ng":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("invalid warn_threshold_ng: %w", err)
+			}
+			if n < 0 {
+				return fmt.Errorf("invalid warn_threshold_ng: must not be negative: %d", n)
+			}
+			global.WarnThresholdNG = n
+		case "down_threshold_ng":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("invalid down_threshold_ng: %w", err)
+			}
+			if n < 0 {
+				return fmt.Errorf("invalid down_threshold_ng: must not be negative: %d", n)
+			}
+			global.DownThresholdNG = n
+		case "loss_window":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("invalid loss_window: %w", err)
+			}
+			if n < 0 {
+				return fmt.Errorf("invalid loss_window: must not be negative: %d", n)
+			}
+			global.LossWindow = n
+		case "flap_threshold":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("invalid flap_threshold: %w", err)
+			}
+			if n < 0 {
+				return fmt.Errorf("invalid flap_threshold: must not be negative: %d", n)
+			}
+			global.FlapThreshold = n
+		case "history_window":
+			d, err := parseNonNegativeDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid history_window: %w", err)
+			}
+			global.HistoryWindow = d
+		case "default_group":
+			if val == "" {
+				return fmt.Errorf("invalid default_group: must not be empty")
+			}
+			global.DefaultGroup = val
+		case "flap_window":
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid flap_window: %w", err)
+			}
+			if d <= 0 {
+				return fmt.Errorf("invalid flap_window: must be positive: %s", val)
+			}
+			global.FlapWindow = d
 		case "max_concurrency":
 			n, err := strconv.Atoi(val)
 			if err != nil {
 				return fmt.Errorf("invalid max_concurrency: %w", err)
 			}
 			global.MaxConcurrency = n
+		case "max_concurrency_external":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("invalid max_concurrency_external: %w", err)
+			}
+			if n < 0 {
+				return fmt.Errorf("invalid max_concurrency_external: must not be negative: %d", n)
+			}
+			global.MaxConcurrencyExternal = n
+		case "external_ping_cmd":
+			if strings.TrimSpace(val) == "" {
+				return fmt.Errorf("invalid external_ping_cmd: must not be empty")
+			}
+			global.ExternalPingCommand = val
+		case "max_pps":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("invalid max_pps: %w", err)
+			}
+			if n < 0 {
+				return fmt.Errorf("invalid max_pps: must not be negative: %d", n)
+			}
+			global.MaxPPS = n
+		case "payload_size":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("invalid payload_size: %w", err)
+			}
+			if n < 0 || n > ping.MaxPayloadSize {
+				return fmt.Errorf("invalid payload_size: must be between 0 and %d: %d", ping.MaxPayloadSize, n)
+			}
+			global.PayloadSize = n
+		case "dedupe_by_address":
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("invalid dedupe_by_address: %w", err)
+			}
+			global.DedupeByAddress = b
+		case "target_soft_limit":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("invalid target_soft_limit: %w", err)
+			}
+			if n < 0 {
+				return fmt.Errorf("invalid target_soft_limit: must not be negative: %d", n)
+			}
+			global.TargetSoftLimit = n
+		case "target_hard_limit":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("invalid target_hard_limit: %w", err)
+			}
+			if n < 0 {
+				return fmt.Errorf("invalid target_hard_limit: must not be negative: %d", n)
+			}
+			global.TargetHardLimit = n
+		case "shutdown_grace":
+			d, err := parseNonNegativeDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid shutdown_grace: %w", err)
+			}
+			global.ShutdownGrace = d
+		case "result_batch_interval":
+			d, err := parseNonNegativeDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid result_batch_interval: %w", err)
+			}
+			global.ResultBatchInterval = d
+		case "resolve_interval":
+			d, err := parseNonNegativeDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid resolve_interval: %w", err)
+			}
+			global.ResolveInterval = d
+		case "resolve_cache_ttl":
+			d, err := parseNonNegativeDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid resolve_cache_ttl: %w", err)
+			}
+			global.ResolveCacheTTL = d
 		case "metrics.mode":
 			switch val {
 			case string(MetricsModePerTarget):
@@ -178,17 +664,80 @@ func applyDirective(global *GlobalOptions, pairs map[string]string) error {
 				return fmt.Errorf("invalid metrics.mode: %q", val)
 			}
 		case "metrics.listen":
-			if isDigits(val) {
-				global.MetricsListen = ":" + val
-			} else {
-				global.MetricsListen = val
+			global.MetricsListen = NormalizeListenAddress(val)
+		case "metrics.path":
+			if !strings.HasPrefix(val, "/") {
+				return fmt.Errorf("invalid metrics.path: must start with '/': %q", val)
+			}
+			global.MetricsPath = val
+		case "metrics.prefix":
+			if !isMetricNamePrefix(val) {
+				return fmt.Errorf("invalid metrics.prefix: %q", val)
+			}
+			global.MetricsPrefix = val
+		case "metrics.max_staleness":
+			d, err := parseNonNegativeDuration(val)
+			if err != nil {
+				return fmt.Errorf("invalid metrics.max_staleness: %w", err)
 			}
+			global.MetricsMaxStaleness = d
+		case "metrics.runtime":
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("invalid metrics.runtime: %w", err)
+			}
+			global.MetricsRuntime = b
+		case "metrics.enable_reload":
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("invalid metrics.enable_reload: %w", err)
+			}
+			global.MetricsEnableReload = b
 		case "ui.scale":
 			n, err := strconv.Atoi(val)
 			if err != nil {
 				return fmt.Errorf("invalid ui.scale: %w", err)
 			}
 			global.UIScale = n
+		case "ui.theme":
+			switch val {
+			case string(UIThemeUnicode):
+				global.UITheme = UIThemeUnicode
+			case string(UIThemeASCII):
+				global.UITheme = UIThemeASCII
+			default:
+				return fmt.Errorf("invalid ui.theme: %q", val)
+			}
+		case "ui.rtt_unit":
+			switch unit := RTTUnit(val); unit {
+			case RTTUnitAuto, RTTUnitMicroseconds, RTTUnitMilliseconds, RTTUnitSeconds:
+				global.UIRTTUnit = unit
+			default:
+				return fmt.Errorf("invalid ui.rtt_unit: %q", val)
+			}
+		case "ui.show_address":
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("invalid ui.show_address: %w", err)
+			}
+			global.UIHideAddress = !b
+		case "ui.show_bar":
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("invalid ui.show_bar: %w", err)
+			}
+			global.UIHideBar = !b
+		case "ui.set_title":
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("invalid ui.set_title: %w", err)
+			}
+			global.UISetTitle = b
+		case "log.level":
+			if _, ok := log.LookupLevel(val); !ok {
+				return fmt.Errorf("invalid log.level: %q", val)
+			}
+			global.LogLevel = val
 		case "ui.disable":
 			b, err := strconv.ParseBool(val)
 			if err != nil {
@@ -202,6 +751,68 @@ func applyDirective(global *GlobalOptions, pairs map[string]string) error {
 	return nil
 }
 
+// ParseInterval parses a probe interval given either as a duration ("2s") or
+// as a rate of probes per second or minute ("5/s", "30/min").
+func ParseInterval(val string) (time.Duration, error) {
+	count, unit, ok := strings.Cut(val, "/")
+	if !ok {
+		return time.ParseDuration(val)
+	}
+	var per time.Duration
+	switch unit {
+	case "s", "sec":
+		per = time.Second
+	case "m", "min":
+		per = time.Minute
+	default:
+		return 0, fmt.Errorf("unknown rate unit %q in %q (use /s or /min)", unit, val)
+	}
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("invalid rate %q: probe count must be a positive number", val)
+	}
+	d := time.Duration(float64(per) / n)
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid rate %q: interval is too short", val)
+	}
+	return d, nil
+}
+
+func parseNonNegativeDuration(val string) (time.Duration, error) {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("must not be negative: %q", val)
+	}
+	return d, nil
+}
+
+func validateThresholds(global GlobalOptions) error {
+	if global.OKThreshold > 0 && global.WarnThreshold > 0 && global.OKThreshold > global.WarnThreshold {
+		return fmt.Errorf("ok_threshold (%s) must not exceed warn_threshold (%s)", global.OKThreshold, global.WarnThreshold)
+	}
+	// An unset down_threshold_ng still caps warn_threshold_ng at its default,
+	// or WARN could never be reached.
+	downNG := global.DownThresholdNG
+	if downNG == 0 {
+		downNG = DefaultDownThresholdNG
+	}
+	if global.WarnThresholdNG > downNG {
+		return fmt.Errorf("warn_threshold_ng (%d) must not exceed down_threshold_ng (%d)", global.WarnThresholdNG, downNG)
+	}
+	if global.RTTDownThreshold > 0 {
+		if global.WarnThreshold > 0 && global.RTTDownThreshold <= global.WarnThreshold {
+			return fmt.Errorf("rtt_down_threshold (%s) must exceed warn_threshold (%s)", global.RTTDownThreshold, global.WarnThreshold)
+		}
+		if global.OKThreshold > 0 && global.RTTDownThreshold <= global.OKThreshold {
+			return fmt.Errorf("rtt_down_threshold (%s) must exceed ok_threshold (%s)", global.RTTDownThreshold, global.OKThreshold)
+		}
+	}
+	return nil
+}
+
 func applyCLIOverrides(global *GlobalOptions, overrides CLIOverrides) {
 	if overrides.Interval != nil {
 		global.Interval = *overrides.Interval
@@ -216,15 +827,147 @@ func applyCLIOverrides(global *GlobalOptions, overrides CLIOverrides) {
 		global.MetricsMode = *overrides.MetricsMode
 	}
 	if overrides.MetricsListen != nil {
-		val := *overrides.MetricsListen
-		if isDigits(val) {
-			val = ":" + val
-		}
-		global.MetricsListen = val
+		global.MetricsListen = NormalizeListenAddress(*overrides.MetricsListen)
 	}
 	if overrides.UIDisable != nil {
 		global.UIDisable = *overrides.UIDisable
 	}
+	if overrides.UINoColor != nil {
+		global.UINoColor = *overrides.UINoColor
+	}
+	if overrides.LogLevel != nil {
+		global.LogLevel = *overrides.LogLevel
+	}
+}
+
+// dscpNames maps the class selector, assured forwarding and expedited
+// forwarding names accepted by the dscp option to their codepoints.
+var dscpNames = map[string]int{
+	"cs0": 0, "cs1": 8, "cs2": 16, "cs3": 24, "cs4": 32, "cs5": 40, "cs6": 48, "cs7": 56,
+	"af11": 10, "af12": 12, "af13": 14,
+	"af21": 18, "af22": 20, "af23": 22,
+	"af31": 26, "af32": 28, "af33": 30,
+	"af41": 34, "af42": 36, "af43": 38,
+	"ef": 46,
+}
+
+// ParseDSCP parses a DSCP codepoint given as a number from 0 to 63 or as a
+// name such as "ef" or "af41".
+func ParseDSCP(val string) (int, error) {
+	if dscp, ok := dscpNames[strings.ToLower(val)]; ok {
+		return dscp, nil
+	}
+	dscp, err := strconv.Atoi(val)
+	if err != nil || dscp < 0 || dscp > 63 {
+		return 0, fmt.Errorf("must be 0-63 or a name such as ef or af41: %q", val)
+	}
+	return dscp, nil
+}
+
+// validateTargetAddress validates the address of target. Synthetic probes
+// connect to a port, so their address may also be "host:port" or "[v6]:port".
+func validateTargetAddress(target TargetConfig) error {
+	if target.Probe != ProbeSynthetic {
+		return validateAddress(target.Address)
+	}
+	host, port, err := net.SplitHostPort(target.Address)
+	if err != nil {
+		return validateAddress(target.Address)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return validateAddress(host)
+}
+
+// validateAddress checks that an address is syntactically an IP literal or a
+// hostname. Resolution is deliberately not attempted since DNS may be transient.
+func validateAddress(addr string) error {
+	host := addr
+	if i := strings.IndexByte(host, '%'); i > 0 {
+		// IPv6 zone identifiers (fe80::1%eth0) are accepted by the resolver.
+		host = host[:i]
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if strings.Contains(addr, ":") {
+		return fmt.Errorf("malformed IP address %q", addr)
+	}
+
+	name := strings.TrimSuffix(addr, ".")
+	if name == "" || len(name) > 253 {
+		return fmt.Errorf("invalid hostname %q", addr)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("invalid hostname %q", addr)
+		}
+		for _, r := range label {
+			if !isHostnameRune(r) {
+				return fmt.Errorf("invalid hostname %q", addr)
+			}
+		}
+	}
+	return nil
+}
+
+func isHostnameRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_'
+}
+
+// splitFields splits line on whitespace like strings.Fields, except that text
+// inside double quotes is kept together and the quotes are removed. A quote may
+// start mid-token (label="East Coast"), and \" or \\ escape inside quotes.
+func splitFields(line string) ([]string, error) {
+	var fields []string
+	var current strings.Builder
+	inToken, inQuotes, escaped := false, false, false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case inQuotes && r == '\\':
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+			inToken = true
+		case !inQuotes && unicode.IsSpace(r):
+			if inToken {
+				fields = append(fields, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inQuotes || escaped {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inToken {
+		fields = append(fields, current.String())
+	}
+	return fields, nil
+}
+
+// isComment reports whether line uses one of the alternative comment styles.
+// "#" comments are handled separately because they may carry directives.
+func isComment(line string) bool {
+	return strings.HasPrefix(line, ";") || strings.HasPrefix(line, "//")
+}
+
+// NormalizeListenAddress turns a bare port such as "9100" into ":9100" and
+// returns any other address unchanged.
+func NormalizeListenAddress(value string) string {
+	value = strings.TrimSpace(value)
+	if isDigits(value) {
+		return ":" + value
+	}
+	return value
 }
 
 func isDigits(value string) bool {
@@ -238,3 +981,18 @@ func isDigits(value string) bool {
 	}
 	return true
 }
+
+// isMetricNamePrefix reports whether value can start a Prometheus metric name:
+// [a-zA-Z_:][a-zA-Z0-9_:]*.
+func isMetricNamePrefix(value string) bool {
+	if value == "" {
+		return false
+	}
+	for i, r := range value {
+		letter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' || r == ':'
+		if !letter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
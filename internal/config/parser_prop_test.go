@@ -222,12 +222,19 @@ func genConfigSpec() gopter.Gen {
 	return gopter.Gen(func(genParams *gopter.GenParameters) *gopter.GenResult {
 		groupCount := genParams.Rng.Intn(3) + 1
 		groups := make([][]targetSpec, groupCount)
+		// Target names must be unique across the whole config.
+		seen := make(map[string]bool)
 		for i := 0; i < groupCount; i++ {
 			targetCount := genParams.Rng.Intn(3) + 1
 			group := make([]targetSpec, targetCount)
 			for j := 0; j < targetCount; j++ {
+				name := randomToken(genParams.Rng)
+				for seen[name] {
+					name = randomToken(genParams.Rng)
+				}
+				seen[name] = true
 				group[j] = targetSpec{
-					Name:    randomToken(genParams.Rng),
+					Name:    name,
 					Address: randomToken(genParams.Rng),
 				}
 			}
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -132,16 +134,1129 @@ func TestLoadConfigRejectsInvalidTargetLine(t *testing.T) {
 	}
 }
 
+func TestLoadConfigRejectsDuplicateTargetNames(t *testing.T) {
+	configText := "" +
+		"web 192.0.2.1\n" +
+		"--- DNS\n" +
+		"resolver 192.0.2.53\n" +
+		"web 192.0.2.2\n"
+
+	path := writeTempConfig(t, configText)
+	parser := SurveillerParser{}
+
+	_, err := parser.LoadConfig(path, CLIOverrides{})
+	if err == nil {
+		t.Fatalf("expected error for duplicate target name")
+	}
+	for _, want := range []string{`"web"`, "line 4", "line 1"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error to mention %s, got %q", want, err.Error())
+		}
+	}
+}
+
+func TestLoadConfigRejectsInvalidAddress(t *testing.T) {
+	cases := []string{
+		"bad 192.0.2.1.5:80",
+		"bad exa$mple.com",
+		"bad -leading.example.com",
+		"bad 2001:db8::zz",
+		"bad host..example.com",
+	}
+	parser := SurveillerParser{}
+	for _, line := range cases {
+		path := writeTempConfig(t, "ok 192.0.2.1\n"+line+"\n")
+		_, err := parser.LoadConfig(path, CLIOverrides{})
+		if err == nil {
+			t.Fatalf("expected error for %q", line)
+		}
+		if !strings.Contains(err.Error(), "line 2") || !strings.Contains(err.Error(), line) {
+			t.Fatalf("expected error to mention line 2 and %q, got %q", line, err.Error())
+		}
+	}
+}
+
+func TestValidateAddressAcceptsValidAddresses(t *testing.T) {
+	valid := []string{
+		"192.0.2.1",
+		"2001:4860:4860::8888",
+		"fe80::1%eth0",
+		"example.com",
+		"example.com.",
+		"db-1.internal",
+		"not-resolvable-yet.invalid",
+		"localhost",
+	}
+	for _, addr := range valid {
+		if err := validateAddress(addr); err != nil {
+			t.Errorf("validateAddress(%q) unexpected error: %v", addr, err)
+		}
+	}
+}
+
 func TestLoadConfigRejectsInvalidDirective(t *testing.T) {
 	configText := "" +
 		"# surveiller: interval=notaduration\n" +
 		"example 192.0.2.1\n"
 
-	path := writeTempConfig(t, configText)
+	path := writeTempConfig(t, configText)
+	parser := SurveillerParser{}
+
+	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for invalid directive")
+	}
+}
+
+func TestLoadConfigParsesRTTThresholds(t *testing.T) {
+	configText := "" +
+		"# surveiller: ok_threshold=100ms warn_threshold=300ms rtt_down_threshold=1s\n" +
+		"example 192.0.2.1\n"
+
+	path := writeTempConfig(t, configText)
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.OKThreshold != 100*time.Millisecond {
+		t.Fatalf("expected ok_threshold 100ms, got %v", cfg.Global.OKThreshold)
+	}
+	if cfg.Global.WarnThreshold != 300*time.Millisecond {
+		t.Fatalf("expected warn_threshold 300ms, got %v", cfg.Global.WarnThreshold)
+	}
+	if cfg.Global.RTTDownThreshold != time.Second {
+		t.Fatalf("expected rtt_down_threshold 1s, got %v", cfg.Global.RTTDownThreshold)
+	}
+}
+
+func TestLoadConfigRejectsInvalidRTTThresholds(t *testing.T) {
+	cases := []string{
+		"# surveiller: ok_threshold=fast\n",
+		"# surveiller: warn_threshold=-1ms\n",
+		"# surveiller: ok_threshold=300ms warn_threshold=100ms\n",
+		"# surveiller: rtt_down_threshold=slow\n",
+		"# surveiller: warn_threshold=300ms rtt_down_threshold=300ms\n",
+		"# surveiller: ok_threshold=300ms rtt_down_threshold=200ms\n",
+	}
+	parser := SurveillerParser{}
+	for _, directive := range cases {
+		path := writeTempConfig(t, directive+"example 192.0.2.1\n")
+		if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+			t.Fatalf("expected error for %q", directive)
+		}
+	}
+}
+
+func TestParseInterval(t *testing.T) {
+	valid := map[string]time.Duration{
+		"2s":     2 * time.Second,
+		"500ms":  500 * time.Millisecond,
+		"5/s":    200 * time.Millisecond,
+		"1/sec":  time.Second,
+		"30/min": 2 * time.Second,
+		"120/m":  500 * time.Millisecond,
+		"0.5/s":  2 * time.Second,
+		"3/min":  20 * time.Second,
+	}
+	for input, want := range valid {
+		got, err := ParseInterval(input)
+		if err != nil || got != want {
+			t.Errorf("ParseInterval(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"fast", "5/h", "0/s", "-1/min", "x/s", "/s", "5/"} {
+		if _, err := ParseInterval(input); err == nil {
+			t.Errorf("ParseInterval(%q) expected error", input)
+		}
+	}
+}
+
+func TestLoadConfigParsesIntervalRate(t *testing.T) {
+	path := writeTempConfig(t, "# surveiller: interval=30/min\nexample 192.0.2.1\n")
+	cfg, err := SurveillerParser{}.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.Interval != 2*time.Second {
+		t.Fatalf("expected interval=30/min to give 2s, got %v", cfg.Global.Interval)
+	}
+}
+
+func TestLoadConfigParsesFailureThresholds(t *testing.T) {
+	path := writeTempConfig(t, "# surveiller: warn_threshold_ng=2 down_threshold_ng=5\nexample 192.0.2.1\n")
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.WarnThresholdNG != 2 || cfg.Global.DownThresholdNG != 5 {
+		t.Fatalf("expected warn_threshold_ng=2 down_threshold_ng=5, got %d %d", cfg.Global.WarnThresholdNG, cfg.Global.DownThresholdNG)
+	}
+	if _, err := parser.LoadConfig(writeTempConfig(t, "# surveiller: warn_threshold_ng=3\nexample 192.0.2.1\n"), CLIOverrides{}); err != nil {
+		t.Fatalf("expected warn_threshold_ng equal to the default down_threshold_ng to be accepted: %v", err)
+	}
+
+	for _, directive := range []string{
+		"# surveiller: warn_threshold_ng=two\n",
+		"# surveiller: down_threshold_ng=-1\n",
+		"# surveiller: warn_threshold_ng=5 down_threshold_ng=2\n",
+		"# surveiller: warn_threshold_ng=4\n", // above the default down_threshold_ng of 3
+	} {
+		path := writeTempConfig(t, directive+"example 192.0.2.1\n")
+		if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+			t.Fatalf("expected error for %q", directive)
+		}
+	}
+}
+
+func TestParseConfigFromReader(t *testing.T) {
+	parser := SurveillerParser{}
+	input := "# surveiller: interval=3s\n---\nweb 192.0.2.1\n"
+
+	cfg, err := parser.ParseConfig(strings.NewReader(input), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("ParseConfig error: %v", err)
+	}
+	if cfg.Global.Interval != 3*time.Second {
+		t.Fatalf("expected interval 3s, got %s", cfg.Global.Interval)
+	}
+	if len(cfg.Targets) != 1 || cfg.Targets[0].Name != "web" || cfg.Targets[0].Group != "group-1" {
+		t.Fatalf("unexpected targets: %+v", cfg.Targets)
+	}
+}
+
+func TestLoadConfigNormalizesLineEndings(t *testing.T) {
+	unix := "# surveiller: interval=2s\n--- web\nweb1 192.0.2.1 label=\"East Coast\" mute=true\nweb2 192.0.2.2 resolve_interval=5m\n"
+	want, err := SurveillerParser{}.ParseConfig(strings.NewReader(unix), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("ParseConfig error: %v", err)
+	}
+
+	variants := map[string]string{
+		"crlf":         strings.ReplaceAll(unix, "\n", "\r\n"),
+		"cr":           strings.ReplaceAll(unix, "\n", "\r"),
+		"bom and crlf": "\ufeff" + strings.ReplaceAll(unix, "\n", "\r\n"),
+		"no final eol": strings.TrimSuffix(strings.ReplaceAll(unix, "\n", "\r\n"), "\r\n"),
+	}
+	for name, content := range variants {
+		got, err := SurveillerParser{}.ParseConfig(strings.NewReader(content), CLIOverrides{})
+		if err != nil {
+			t.Fatalf("%s: ParseConfig error: %v", name, err)
+		}
+		if !reflect.DeepEqual(got.Targets, want.Targets) || got.Global != want.Global {
+			t.Fatalf("%s: expected the same config as LF endings, got %+v", name, got.Targets)
+		}
+		for _, target := range got.Targets {
+			for key, value := range target.Options {
+				if strings.ContainsRune(value, '\r') || strings.ContainsRune(key, '\r') {
+					t.Fatalf("%s: stray \\r in option %q=%q", name, key, value)
+				}
+			}
+			if strings.ContainsRune(target.Address, '\r') {
+				t.Fatalf("%s: stray \\r in address %q", name, target.Address)
+			}
+		}
+	}
+
+	// Line numbers in errors count CRLF as one line break.
+	_, err = SurveillerParser{}.ParseConfig(strings.NewReader("a 192.0.2.1\r\na 192.0.2.2\r\n"), CLIOverrides{})
+	if err == nil || !strings.Contains(err.Error(), "on line 2") {
+		t.Fatalf("expected a duplicate error on line 2, got %v", err)
+	}
+}
+
+func TestLoadConfigLenientSkipsInvalidTargets(t *testing.T) {
+	content := strings.Join([]string{
+		"# surveiller: interval=2s",
+		"good1 192.0.2.1",
+		"broken",
+		"bad-addr not_a_host!",
+		"good1 192.0.2.9",
+		"good2 192.0.2.2 mute=perhaps",
+		"good3 192.0.2.3",
+		"",
+	}, "\n")
+	path := writeTempConfig(t, content)
+
+	if _, err := (SurveillerParser{}).LoadConfig(path, CLIOverrides{}); err == nil {
+		t.Fatalf("expected strict mode to reject the config")
+	}
+
+	cfg, err := SurveillerParser{Lenient: true}.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if len(cfg.Targets) != 2 || cfg.Targets[0].Name != "good1" || cfg.Targets[1].Name != "good3" {
+		t.Fatalf("expected good1 and good3, got %+v", cfg.Targets)
+	}
+	var lines []int
+	for _, skipped := range cfg.Skipped {
+		if skipped.Err == nil {
+			t.Fatalf("expected an error for skipped line %d", skipped.Line)
+		}
+		lines = append(lines, skipped.Line)
+	}
+	if !reflect.DeepEqual(lines, []int{3, 4, 5, 6}) {
+		t.Fatalf("expected lines 3-6 skipped, got %v", lines)
+	}
+	if cfg.Global.Interval != 2*time.Second {
+		t.Fatalf("expected directives to still apply, got interval %s", cfg.Global.Interval)
+	}
+
+	path = writeTempConfig(t, "# surveiller: interval=soon\nexample 192.0.2.1\n")
+	if _, err := (SurveillerParser{Lenient: true}).LoadConfig(path, CLIOverrides{}); err == nil {
+		t.Fatalf("expected an invalid directive to fail even in lenient mode")
+	}
+}
+
+func TestLoadConfigDirMergesFilesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"10-base.conf":  "# surveiller: interval=2s timeout=500ms\ngoogle 8.8.8.8\n---\nweb1 192.0.2.1\n",
+		"20-extra.conf": "# surveiller: interval=5s\ndns 8.8.4.4\n---\nweb2 192.0.2.2\n",
+		"README":        "not a config\n",
+		".hidden.conf":  "hidden 192.0.2.9\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub.conf"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	cfg, err := SurveillerParser{}.LoadConfigDir(dir, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfigDir error: %v", err)
+	}
+	if cfg.Global.Interval != 5*time.Second || cfg.Global.Timeout != 500*time.Millisecond {
+		t.Fatalf("expected later files to override earlier directives, got interval=%s timeout=%s", cfg.Global.Interval, cfg.Global.Timeout)
+	}
+
+	want := []struct{ name, group string }{
+		{"google", ""}, {"web1", "group-1"}, {"dns", ""}, {"web2", "group-2"},
+	}
+	if len(cfg.Targets) != len(want) {
+		t.Fatalf("expected %d targets, got %+v", len(want), cfg.Targets)
+	}
+	for i, w := range want {
+		if cfg.Targets[i].Name != w.name || cfg.Targets[i].Group != w.group {
+			t.Fatalf("target %d = %s/%q, expected %s/%q", i, cfg.Targets[i].Name, cfg.Targets[i].Group, w.name, w.group)
+		}
+	}
+}
+
+func TestLoadConfigDirRejectsDuplicateNamesAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.conf"), []byte("web 192.0.2.1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.conf"), []byte("\nweb 192.0.2.2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := SurveillerParser{}.LoadConfigDir(dir, CLIOverrides{})
+	if err == nil {
+		t.Fatal("expected duplicate name error")
+	}
+	want := filepath.Join(dir, "b.conf") + `: duplicate target name "web" on line 2 (first defined on a.conf line 1)`
+	if err.Error() != want {
+		t.Fatalf("unexpected error:\n got %s\nwant %s", err, want)
+	}
+
+	if _, err := (SurveillerParser{}).LoadConfigDir(filepath.Join(dir, "missing"), CLIOverrides{}); err == nil {
+		t.Fatal("expected error for a missing directory")
+	}
+}
+
+func TestLoadConfigReadsStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	if _, err := w.WriteString("web 192.0.2.1\ndb 192.0.2.2\n"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	cfg, err := SurveillerParser{}.LoadConfig(StdinPath, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if len(cfg.Targets) != 2 || cfg.Targets[1].Name != "db" {
+		t.Fatalf("unexpected targets: %+v", cfg.Targets)
+	}
+}
+
+func TestLoadConfigParsesMaxConcurrencyExternal(t *testing.T) {
+	path := writeTempConfig(t, "# surveiller: max_concurrency_external=8\nexample 192.0.2.1\n")
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.MaxConcurrencyExternal != 8 {
+		t.Fatalf("expected max_concurrency_external 8, got %d", cfg.Global.MaxConcurrencyExternal)
+	}
+
+	for _, directive := range []string{"max_concurrency_external=lots", "max_concurrency_external=-2"} {
+		path := writeTempConfig(t, "# surveiller: "+directive+"\nexample 192.0.2.1\n")
+		if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+			t.Fatalf("expected error for %s", directive)
+		}
+	}
+}
+
+func TestLoadConfigParsesPayloadSize(t *testing.T) {
+	path := writeTempConfig(t, "# surveiller: payload_size=9000\nexample 192.0.2.1\n")
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.PayloadSize != 9000 {
+		t.Fatalf("expected payload_size 9000, got %d", cfg.Global.PayloadSize)
+	}
+
+	for _, directive := range []string{"payload_size=big", "payload_size=-1", "payload_size=70000"} {
+		path := writeTempConfig(t, "# surveiller: "+directive+"\nexample 192.0.2.1\n")
+		if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+			t.Fatalf("expected error for %s", directive)
+		}
+	}
+}
+
+func TestLoadConfigParsesDedupeByAddress(t *testing.T) {
+	parser := SurveillerParser{}
+	path := writeTempConfig(t, "# surveiller: dedupe_by_address=true\nexample 192.0.2.1\n")
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if !cfg.Global.DedupeByAddress {
+		t.Fatalf("expected dedupe_by_address to be enabled")
+	}
+
+	path = writeTempConfig(t, "# surveiller: dedupe_by_address=sometimes\nexample 192.0.2.1\n")
+	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for invalid dedupe_by_address")
+	}
+}
+
+func TestLoadConfigParsesLossWindow(t *testing.T) {
+	parser := SurveillerParser{}
+	path := writeTempConfig(t, "# surveiller: loss_window=50\nexample 192.0.2.1\n")
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.LossWindow != 50 {
+		t.Fatalf("expected loss_window 50, got %d", cfg.Global.LossWindow)
+	}
+
+	for _, directive := range []string{"loss_window=-5", "loss_window=many"} {
+		path := writeTempConfig(t, "# surveiller: "+directive+"\nexample 192.0.2.1\n")
+		if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+			t.Fatalf("expected error for %s", directive)
+		}
+	}
+}
+
+func TestLoadConfigParsesHistoryWindow(t *testing.T) {
+	parser := SurveillerParser{}
+	cfg, err := parser.LoadConfig(writeTempConfig(t, "# surveiller: history_window=1h\nexample 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.HistoryWindow != time.Hour {
+		t.Fatalf("expected history_window 1h, got %s", cfg.Global.HistoryWindow)
+	}
+
+	for _, directive := range []string{"history_window=-1m", "history_window=forever"} {
+		path := writeTempConfig(t, "# surveiller: "+directive+"\nexample 192.0.2.1\n")
+		if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+			t.Fatalf("expected error for %s", directive)
+		}
+	}
+}
+
+func TestLoadConfigParsesMetricsMaxStaleness(t *testing.T) {
+	parser := SurveillerParser{}
+	cfg, err := parser.LoadConfig(writeTempConfig(t, "# surveiller: metrics.max_staleness=5s\nexample 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.MetricsMaxStaleness != 5*time.Second {
+		t.Fatalf("expected metrics.max_staleness 5s, got %s", cfg.Global.MetricsMaxStaleness)
+	}
+
+	path := writeTempConfig(t, "# surveiller: metrics.max_staleness=-1s\nexample 192.0.2.1\n")
+	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for negative metrics.max_staleness")
+	}
+}
+
+func TestLoadConfigParsesFlapDetection(t *testing.T) {
+	parser := SurveillerParser{}
+	path := writeTempConfig(t, "# surveiller: flap_threshold=4 flap_window=2m\nexample 192.0.2.1\n")
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.FlapThreshold != 4 || cfg.Global.FlapWindow != 2*time.Minute {
+		t.Fatalf("expected flap_threshold=4 flap_window=2m, got %d %s", cfg.Global.FlapThreshold, cfg.Global.FlapWindow)
+	}
+
+	for _, directive := range []string{"flap_threshold=-1", "flap_threshold=x", "flap_window=0s", "flap_window=soon"} {
+		path := writeTempConfig(t, "# surveiller: "+directive+"\nexample 192.0.2.1\n")
+		if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+			t.Fatalf("expected error for %s", directive)
+		}
+	}
+}
+
+func TestLoadConfigParsesDefaultGroup(t *testing.T) {
+	parser := SurveillerParser{}
+	cfg, err := parser.LoadConfig(writeTempConfig(t, "a 192.0.2.1\n--- web\nb 192.0.2.2\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.DefaultGroup != DefaultGroupName || cfg.Targets[0].Group != "" {
+		t.Fatalf("expected ungrouped target to stay unstamped, got %q %q", cfg.Global.DefaultGroup, cfg.Targets[0].Group)
+	}
+
+	// The directive applies to ungrouped targets listed before it too.
+	path := writeTempConfig(t, "a 192.0.2.1\n# surveiller: default_group=prod\n--- web\nb 192.0.2.2\n")
+	cfg, err = parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.DefaultGroup != "prod" || cfg.Targets[0].Group != "prod" || cfg.Targets[1].Group != "web" {
+		t.Fatalf("expected groups prod and web, got %q and %q", cfg.Targets[0].Group, cfg.Targets[1].Group)
+	}
+
+	if _, err := parser.LoadConfig(writeTempConfig(t, "# surveiller: default_group=\n"), CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for empty default_group")
+	}
+}
+
+func TestLoadConfigParsesMaxPPS(t *testing.T) {
+	path := writeTempConfig(t, "# surveiller: max_pps=50\nexample 192.0.2.1\n")
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.MaxPPS != 50 {
+		t.Fatalf("expected max_pps 50, got %d", cfg.Global.MaxPPS)
+	}
+
+	for _, directive := range []string{"max_pps=fast", "max_pps=-1"} {
+		path := writeTempConfig(t, "# surveiller: "+directive+"\nexample 192.0.2.1\n")
+		if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+			t.Fatalf("expected error for %s", directive)
+		}
+	}
+}
+
+func TestLoadConfigParsesTargetLimits(t *testing.T) {
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(writeTempConfig(t, "example 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.TargetSoftLimit != DefaultTargetSoftLimit || cfg.Global.TargetHardLimit != DefaultTargetHardLimit {
+		t.Fatalf("expected default limits, got soft=%d hard=%d", cfg.Global.TargetSoftLimit, cfg.Global.TargetHardLimit)
+	}
+
+	path := writeTempConfig(t, "# surveiller: target_soft_limit=5\n# surveiller: target_hard_limit=0\nexample 192.0.2.1\n")
+	cfg, err = parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.TargetSoftLimit != 5 || cfg.Global.TargetHardLimit != 0 {
+		t.Fatalf("expected soft=5 hard=0, got soft=%d hard=%d", cfg.Global.TargetSoftLimit, cfg.Global.TargetHardLimit)
+	}
+
+	for _, directive := range []string{"target_soft_limit=many", "target_hard_limit=-1"} {
+		path := writeTempConfig(t, "# surveiller: "+directive+"\nexample 192.0.2.1\n")
+		if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+			t.Fatalf("expected error for %s", directive)
+		}
+	}
+}
+
+func TestLoadConfigParsesShutdownGrace(t *testing.T) {
+	path := writeTempConfig(t, "# surveiller: shutdown_grace=500ms\nexample 192.0.2.1\n")
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.ShutdownGrace != 500*time.Millisecond {
+		t.Fatalf("expected shutdown_grace 500ms, got %v", cfg.Global.ShutdownGrace)
+	}
+
+	// Unset keeps the default; an explicit 0 turns the grace period off.
+	cfg, err = parser.LoadConfig(writeTempConfig(t, "example 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.ShutdownGrace != DefaultShutdownGrace {
+		t.Fatalf("expected default shutdown_grace %v, got %v", DefaultShutdownGrace, cfg.Global.ShutdownGrace)
+	}
+	cfg, err = parser.LoadConfig(writeTempConfig(t, "# surveiller: shutdown_grace=0\nexample 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.ShutdownGrace != 0 {
+		t.Fatalf("expected shutdown_grace=0 to be kept, got %v", cfg.Global.ShutdownGrace)
+	}
+
+	for _, directive := range []string{"shutdown_grace=soon", "shutdown_grace=-1s"} {
+		path := writeTempConfig(t, "# surveiller: "+directive+"\nexample 192.0.2.1\n")
+		if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+			t.Fatalf("expected error for %s", directive)
+		}
+	}
+}
+
+func TestLoadConfigParsesMetricsEnableReload(t *testing.T) {
+	parser := SurveillerParser{}
+	cfg, err := parser.LoadConfig(writeTempConfig(t, "example 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.MetricsEnableReload {
+		t.Fatalf("expected metrics.enable_reload to default to false")
+	}
+
+	cfg, err = parser.LoadConfig(writeTempConfig(t, "# surveiller: metrics.enable_reload=true\nexample 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if !cfg.Global.MetricsEnableReload {
+		t.Fatalf("expected metrics.enable_reload=true")
+	}
+
+	if _, err := parser.LoadConfig(writeTempConfig(t, "# surveiller: metrics.enable_reload=maybe\nexample 192.0.2.1\n"), CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for invalid metrics.enable_reload")
+	}
+}
+
+func TestLoadConfigParsesMetricsRuntime(t *testing.T) {
+	parser := SurveillerParser{}
+	cfg, err := parser.LoadConfig(writeTempConfig(t, "example 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.MetricsRuntime {
+		t.Fatalf("expected metrics.runtime to default to false")
+	}
+
+	cfg, err = parser.LoadConfig(writeTempConfig(t, "# surveiller: metrics.runtime=true\nexample 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if !cfg.Global.MetricsRuntime {
+		t.Fatalf("expected metrics.runtime=true")
+	}
+
+	if _, err := parser.LoadConfig(writeTempConfig(t, "# surveiller: metrics.runtime=maybe\nexample 192.0.2.1\n"), CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for invalid metrics.runtime")
+	}
+}
+
+func TestLoadConfigParsesResultBatchInterval(t *testing.T) {
+	path := writeTempConfig(t, "# surveiller: result_batch_interval=100ms\nexample 192.0.2.1\n")
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.ResultBatchInterval != 100*time.Millisecond {
+		t.Fatalf("expected result_batch_interval 100ms, got %v", cfg.Global.ResultBatchInterval)
+	}
+
+	path = writeTempConfig(t, "# surveiller: result_batch_interval=-1s\nexample 192.0.2.1\n")
+	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil || !strings.Contains(err.Error(), "invalid result_batch_interval") {
+		t.Fatalf("expected result_batch_interval error, got %v", err)
+	}
+}
+
+func TestLoadConfigParsesResolveInterval(t *testing.T) {
+	path := writeTempConfig(t, "# surveiller: resolve_interval=5m\nweb example.com resolve_interval=30s\ndb db.example.com\n")
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.ResolveInterval != 5*time.Minute {
+		t.Fatalf("expected global resolve_interval 5m, got %v", cfg.Global.ResolveInterval)
+	}
+	if cfg.Targets[0].ResolveInterval != 30*time.Second {
+		t.Fatalf("expected target resolve_interval 30s, got %v", cfg.Targets[0].ResolveInterval)
+	}
+	if cfg.Targets[1].ResolveInterval != 0 {
+		t.Fatalf("expected no target override, got %v", cfg.Targets[1].ResolveInterval)
+	}
+
+	path = writeTempConfig(t, "web example.com resolve_interval=often\n")
+	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("expected resolve_interval error with line number, got %v", err)
+	}
+}
+
+func TestLoadConfigParsesMetricsPath(t *testing.T) {
+	parser := SurveillerParser{}
+
+	path := writeTempConfig(t, "example 192.0.2.1\n")
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.MetricsPath != "/metrics" {
+		t.Fatalf("expected default metrics path /metrics, got %q", cfg.Global.MetricsPath)
+	}
+
+	path = writeTempConfig(t, "# surveiller: metrics.path=/surveiller/metrics\nexample 192.0.2.1\n")
+	cfg, err = parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.MetricsPath != "/surveiller/metrics" {
+		t.Fatalf("expected metrics path /surveiller/metrics, got %q", cfg.Global.MetricsPath)
+	}
+
+	path = writeTempConfig(t, "# surveiller: metrics.path=metrics\nexample 192.0.2.1\n")
+	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for metrics.path without leading slash")
+	}
+}
+
+func TestLoadConfigResolveCacheTTLFollowsInterval(t *testing.T) {
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(writeTempConfig(t, "example example.com\n# surveiller: interval=5s\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.ResolveCacheTTL != 5*time.Second {
+		t.Fatalf("expected resolve_cache_ttl to follow interval, got %s", cfg.Global.ResolveCacheTTL)
+	}
+
+	interval := 2 * time.Second
+	cfg, err = parser.LoadConfig(writeTempConfig(t, "example example.com\n"), CLIOverrides{Interval: &interval})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.ResolveCacheTTL != interval {
+		t.Fatalf("expected resolve_cache_ttl to follow --interval, got %s", cfg.Global.ResolveCacheTTL)
+	}
+
+	cfg, err = parser.LoadConfig(writeTempConfig(t, "# surveiller: interval=5s resolve_cache_ttl=0\nexample example.com\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.ResolveCacheTTL != 0 {
+		t.Fatalf("expected resolve_cache_ttl=0 to disable the cache, got %s", cfg.Global.ResolveCacheTTL)
+	}
+
+	if _, err := parser.LoadConfig(writeTempConfig(t, "# surveiller: resolve_cache_ttl=-1s\nexample example.com\n"), CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for negative resolve_cache_ttl")
+	}
+}
+
+func TestLoadConfigParsesMetricsPrefix(t *testing.T) {
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(writeTempConfig(t, "example 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.MetricsPrefix != "surveiller" {
+		t.Fatalf("expected default metrics prefix surveiller, got %q", cfg.Global.MetricsPrefix)
+	}
+
+	cfg, err = parser.LoadConfig(writeTempConfig(t, "# surveiller: metrics.prefix=deadman\nexample 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.MetricsPrefix != "deadman" {
+		t.Fatalf("expected metrics prefix deadman, got %q", cfg.Global.MetricsPrefix)
+	}
+
+	for _, bad := range []string{"", "9lives", "dead-man", "dead man"} {
+		path := writeTempConfig(t, "# surveiller: metrics.prefix=\""+bad+"\"\nexample 192.0.2.1\n")
+		if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil || !strings.Contains(err.Error(), "invalid metrics.prefix") {
+			t.Errorf("expected metrics.prefix error for %q, got %v", bad, err)
+		}
+	}
+}
+
+func TestLoadConfigParsesMuteOption(t *testing.T) {
+	parser := SurveillerParser{}
+	cfg, err := parser.LoadConfig(writeTempConfig(t, "a 192.0.2.1 mute=true\nb 192.0.2.2\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if !cfg.Targets[0].Muted || cfg.Targets[1].Muted {
+		t.Fatalf("expected only the first target muted, got %v %v", cfg.Targets[0].Muted, cfg.Targets[1].Muted)
+	}
+
+	if _, err := parser.LoadConfig(writeTempConfig(t, "a 192.0.2.1 mute=maybe\n"), CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for invalid mute option")
+	}
+}
+
+func TestLoadConfigParsesProbeOption(t *testing.T) {
+	parser := SurveillerParser{}
+	cfg, err := parser.LoadConfig(writeTempConfig(t,
+		"a 192.0.2.1\nweb example.com:8443 probe=synthetic tls=true\nv6 [2001:db8::1]:80 probe=synthetic\nplain example.com probe=synthetic\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Targets[0].Probe != ProbeICMP {
+		t.Fatalf("expected default probe icmp, got %q", cfg.Targets[0].Probe)
+	}
+	if web := cfg.Targets[1]; web.Probe != ProbeSynthetic || !web.TLS {
+		t.Fatalf("expected synthetic TLS probe, got %q tls=%v", web.Probe, web.TLS)
+	}
+	if cfg.Targets[2].Probe != ProbeSynthetic || cfg.Targets[2].TLS || cfg.Targets[3].Probe != ProbeSynthetic {
+		t.Fatalf("expected synthetic probes without TLS, got %+v", cfg.Targets[2:])
+	}
+
+	for _, line := range []string{
+		"a 192.0.2.1 probe=http",
+		"a 192.0.2.1 tls=true",
+		"a example.com probe=synthetic tls=maybe",
+		"a example.com:http probe=synthetic",
+		"a example.com:443",
+	} {
+		if _, err := parser.LoadConfig(writeTempConfig(t, line+"\n"), CLIOverrides{}); err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+}
+
+func TestLoadConfigParsesDSCPOption(t *testing.T) {
 	parser := SurveillerParser{}
+	cfg, err := parser.LoadConfig(writeTempConfig(t, "voice 192.0.2.1 dscp=EF\nvideo 192.0.2.2 dscp=34\nplain 192.0.2.3\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	for i, want := range []int{46, 34, 0} {
+		if cfg.Targets[i].DSCP != want {
+			t.Errorf("%s: expected dscp %d, got %d", cfg.Targets[i].Name, want, cfg.Targets[i].DSCP)
+		}
+	}
+
+	for _, line := range []string{
+		"a 192.0.2.1 dscp=64",
+		"a 192.0.2.1 dscp=-1",
+		"a 192.0.2.1 dscp=voice",
+		"a example.com probe=synthetic dscp=ef",
+	} {
+		if _, err := parser.LoadConfig(writeTempConfig(t, line+"\n"), CLIOverrides{}); err == nil || !strings.Contains(err.Error(), "invalid dscp option") {
+			t.Errorf("expected dscp error for %q, got %v", line, err)
+		}
+	}
+}
+
+func TestLoadConfigParsesLabelOption(t *testing.T) {
+	path := writeTempConfig(t, "192.0.2.1 192.0.2.1 label=\"Core router\"\nplain 192.0.2.2\n")
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Targets[0].Label != "Core router" {
+		t.Fatalf("expected label %q, got %q", "Core router", cfg.Targets[0].Label)
+	}
+	if cfg.Targets[0].Name != "192.0.2.1" {
+		t.Fatalf("expected name to stay %q, got %q", "192.0.2.1", cfg.Targets[0].Name)
+	}
+	if cfg.Targets[1].Label != "" {
+		t.Fatalf("expected no label, got %q", cfg.Targets[1].Label)
+	}
+}
+
+func TestLoadConfigParsesMetricsOption(t *testing.T) {
+	path := writeTempConfig(t, "a 192.0.2.1 metrics=false\nb 192.0.2.2 metrics=true\nc 192.0.2.3\n")
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if !cfg.Targets[0].ExcludeMetrics || cfg.Targets[1].ExcludeMetrics || cfg.Targets[2].ExcludeMetrics {
+		t.Fatalf("unexpected ExcludeMetrics values: %v %v %v", cfg.Targets[0].ExcludeMetrics, cfg.Targets[1].ExcludeMetrics, cfg.Targets[2].ExcludeMetrics)
+	}
 
+	path = writeTempConfig(t, "a 192.0.2.1 metrics=maybe\n")
 	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
-		t.Fatalf("expected error for invalid directive")
+		t.Fatalf("expected error for invalid metrics option")
+	}
+}
+
+func TestNormalizeListenAddress(t *testing.T) {
+	cases := map[string]string{
+		"9100":           ":9100",
+		" 9100 ":         ":9100",
+		":9100":          ":9100",
+		"127.0.0.1:9100": "127.0.0.1:9100",
+		"":               "",
+	}
+	for in, want := range cases {
+		if got := NormalizeListenAddress(in); got != want {
+			t.Errorf("NormalizeListenAddress(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMetricsListenDirectiveAndOverrideAgree(t *testing.T) {
+	parser := SurveillerParser{}
+	for _, value := range []string{"9100", ":9100", "127.0.0.1:9100"} {
+		path := writeTempConfig(t, "# surveiller: metrics.listen="+value+"\nexample 192.0.2.1\n")
+		fromDirective, err := parser.LoadConfig(path, CLIOverrides{})
+		if err != nil {
+			t.Fatalf("LoadConfig error: %v", err)
+		}
+
+		path = writeTempConfig(t, "example 192.0.2.1\n")
+		override := value
+		fromOverride, err := parser.LoadConfig(path, CLIOverrides{MetricsListen: &override})
+		if err != nil {
+			t.Fatalf("LoadConfig error: %v", err)
+		}
+
+		if fromDirective.Global.MetricsListen != fromOverride.Global.MetricsListen {
+			t.Errorf("metrics listen %q: directive gave %q, override gave %q", value, fromDirective.Global.MetricsListen, fromOverride.Global.MetricsListen)
+		}
+	}
+}
+
+func TestLoadConfigSkipsAlternativeComments(t *testing.T) {
+	content := "; generated by tooling\n" +
+		"// hand-edited note\n" +
+		"# surveiller: interval=2s\n" +
+		"   ; indented comment\n" +
+		"example 192.0.2.1\n" +
+		"// disabled 192.0.2.2\n"
+	path := writeTempConfig(t, content)
+	parser := SurveillerParser{}
+
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if len(cfg.Targets) != 1 || cfg.Targets[0].Name != "example" {
+		t.Fatalf("expected only the example target, got %+v", cfg.Targets)
+	}
+	if cfg.Global.Interval != 2*time.Second {
+		t.Fatalf("expected directive to still apply, got interval %v", cfg.Global.Interval)
+	}
+}
+
+func TestLoadConfigParsesLossDownThreshold(t *testing.T) {
+	parser := SurveillerParser{}
+	for _, val := range []string{"60", "60%"} {
+		path := writeTempConfig(t, "# surveiller: loss_down_threshold="+val+"\nexample 192.0.2.1\n")
+		cfg, err := parser.LoadConfig(path, CLIOverrides{})
+		if err != nil {
+			t.Fatalf("LoadConfig error: %v", err)
+		}
+		if cfg.Global.LossDownThreshold != 60 {
+			t.Fatalf("expected loss_down_threshold 60 for %q, got %v", val, cfg.Global.LossDownThreshold)
+		}
+	}
+
+	for _, val := range []string{"lots", "-1", "101"} {
+		path := writeTempConfig(t, "# surveiller: loss_down_threshold="+val+"\nexample 192.0.2.1\n")
+		if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+			t.Fatalf("expected error for loss_down_threshold=%s", val)
+		}
+	}
+}
+
+func TestLoadConfigParsesUITheme(t *testing.T) {
+	parser := SurveillerParser{}
+
+	path := writeTempConfig(t, "example 192.0.2.1\n")
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.UITheme != UIThemeUnicode {
+		t.Fatalf("expected default theme unicode, got %q", cfg.Global.UITheme)
+	}
+
+	path = writeTempConfig(t, "# surveiller: ui.theme=ascii\nexample 192.0.2.1\n")
+	cfg, err = parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.UITheme != UIThemeASCII {
+		t.Fatalf("expected theme ascii, got %q", cfg.Global.UITheme)
+	}
+
+	path = writeTempConfig(t, "# surveiller: ui.theme=neon\nexample 192.0.2.1\n")
+	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for unknown theme")
+	}
+}
+
+func TestLoadConfigParsesUIRTTUnit(t *testing.T) {
+	parser := SurveillerParser{}
+
+	path := writeTempConfig(t, "example 192.0.2.1\n")
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.UIRTTUnit != RTTUnitAuto {
+		t.Fatalf("expected default rtt unit auto, got %q", cfg.Global.UIRTTUnit)
+	}
+
+	path = writeTempConfig(t, "# surveiller: ui.rtt_unit=ms\nexample 192.0.2.1\n")
+	cfg, err = parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.UIRTTUnit != RTTUnitMilliseconds {
+		t.Fatalf("expected rtt unit ms, got %q", cfg.Global.UIRTTUnit)
+	}
+
+	path = writeTempConfig(t, "# surveiller: ui.rtt_unit=ns\nexample 192.0.2.1\n")
+	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for unknown rtt unit")
+	}
+}
+
+func TestLoadConfigParsesLogLevel(t *testing.T) {
+	parser := SurveillerParser{}
+	path := writeTempConfig(t, "# surveiller: log.level=debug\nexample 192.0.2.1\n")
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.LogLevel != "debug" {
+		t.Fatalf("expected log level debug, got %q", cfg.Global.LogLevel)
+	}
+
+	override := "error"
+	cfg, err = parser.LoadConfig(path, CLIOverrides{LogLevel: &override})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.LogLevel != "error" {
+		t.Fatalf("expected --log-level to override log.level, got %q", cfg.Global.LogLevel)
+	}
+
+	path = writeTempConfig(t, "# surveiller: log.level=verbose\nexample 192.0.2.1\n")
+	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for unknown log.level")
+	}
+}
+
+func TestLoadConfigParsesExternalPingCommand(t *testing.T) {
+	parser := SurveillerParser{}
+	path := writeTempConfig(t, "# surveiller: external_ping_cmd=\"busybox ping\" interval=2s\nexample 192.0.2.1\n")
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.ExternalPingCommand != "busybox ping" {
+		t.Fatalf("expected quoted command to keep its space, got %q", cfg.Global.ExternalPingCommand)
+	}
+	if cfg.Global.Interval != 2*time.Second {
+		t.Fatalf("expected directives after the quoted value to parse, got interval %s", cfg.Global.Interval)
+	}
+
+	path = writeTempConfig(t, "# surveiller: external_ping_cmd=/bin/ping\nexample 192.0.2.1\n")
+	cfg, err = parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.ExternalPingCommand != "/bin/ping" {
+		t.Fatalf("expected /bin/ping, got %q", cfg.Global.ExternalPingCommand)
+	}
+
+	path = writeTempConfig(t, "# surveiller: external_ping_cmd=\"\"\nexample 192.0.2.1\n")
+	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for an empty external_ping_cmd")
+	}
+}
+
+func TestLoadConfigParsesUIShowBar(t *testing.T) {
+	parser := SurveillerParser{}
+	path := writeTempConfig(t, "# surveiller: ui.show_bar=false\nexample 192.0.2.1\n")
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if !cfg.Global.UIHideBar {
+		t.Fatalf("expected ui.show_bar=false to hide the bar")
+	}
+
+	path = writeTempConfig(t, "# surveiller: ui.show_bar=maybe\nexample 192.0.2.1\n")
+	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for invalid ui.show_bar")
+	}
+}
+
+func TestLoadConfigParsesUISetTitle(t *testing.T) {
+	parser := SurveillerParser{}
+	cfg, err := parser.LoadConfig(writeTempConfig(t, "example 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Global.UISetTitle {
+		t.Fatalf("expected ui.set_title to default to false")
+	}
+
+	cfg, err = parser.LoadConfig(writeTempConfig(t, "# surveiller: ui.set_title=true\nexample 192.0.2.1\n"), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if !cfg.Global.UISetTitle {
+		t.Fatalf("expected ui.set_title=true to be parsed")
+	}
+
+	if _, err := parser.LoadConfig(writeTempConfig(t, "# surveiller: ui.set_title=maybe\nexample 192.0.2.1\n"), CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for invalid ui.set_title")
+	}
+}
+
+func TestLoadConfigParsesUIShowAddress(t *testing.T) {
+	parser := SurveillerParser{}
+	path := writeTempConfig(t, "# surveiller: ui.show_address=false\nexample 192.0.2.1\n")
+	cfg, err := parser.LoadConfig(path, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if !cfg.Global.UIHideAddress {
+		t.Fatalf("expected ui.show_address=false to hide the address")
+	}
+
+	path = writeTempConfig(t, "# surveiller: ui.show_address=nope\nexample 192.0.2.1\n")
+	if _, err := parser.LoadConfig(path, CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for invalid ui.show_address")
 	}
 }
 
@@ -178,6 +1293,102 @@ func TestLoadConfigAppliesCLIOverrides(t *testing.T) {
 	}
 }
 
+func TestSplitFields(t *testing.T) {
+	cases := []struct {
+		line string
+		want []string
+	}{
+		{line: "web  192.0.2.1\tlabel=x", want: []string{"web", "192.0.2.1", "label=x"}},
+		{line: `"DB Primary" 10.0.0.1`, want: []string{"DB Primary", "10.0.0.1"}},
+		{line: `db 10.0.0.1 label="East Coast"`, want: []string{"db", "10.0.0.1", "label=East Coast"}},
+		{line: `"say \"hi\"" 10.0.0.1`, want: []string{`say "hi"`, "10.0.0.1"}},
+		{line: `"" 10.0.0.1`, want: []string{"", "10.0.0.1"}},
+	}
+	for _, tc := range cases {
+		got, err := splitFields(tc.line)
+		if err != nil {
+			t.Fatalf("splitFields(%q) error: %v", tc.line, err)
+		}
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") || len(got) != len(tc.want) {
+			t.Errorf("splitFields(%q) = %q, want %q", tc.line, got, tc.want)
+		}
+	}
+
+	if _, err := splitFields(`"DB Primary 10.0.0.1`); err == nil {
+		t.Fatalf("expected error for unterminated quote")
+	}
+}
+
+func TestParseTargetLineQuoted(t *testing.T) {
+	parser := SurveillerParser{}
+	target, err := parser.ParseTargetLine(`"DB Primary" 10.0.0.1 label="East Coast"`, "")
+	if err != nil {
+		t.Fatalf("ParseTargetLine error: %v", err)
+	}
+	if target.Name != "DB Primary" || target.Address != "10.0.0.1" {
+		t.Fatalf("unexpected target: %+v", target)
+	}
+	if target.Options["label"] != "East Coast" {
+		t.Fatalf("expected quoted option value, got %+v", target.Options)
+	}
+
+	if _, err := parser.ParseTargetLine(`"" 10.0.0.1`, ""); err == nil {
+		t.Fatalf("expected error for empty quoted name")
+	}
+}
+
+func TestParseConfigGroupOption(t *testing.T) {
+	input := "" +
+		"# surveiller: default_group=misc\n" +
+		"db1 10.0.0.1 group=database\n" +
+		"web1 10.0.0.2\n" +
+		"---\n" +
+		"db2 10.0.0.3 group=database\n" +
+		"web2 10.0.0.4\n" +
+		"\"db 3\" 10.0.0.5 group=\"data base\"\n"
+	cfg, err := SurveillerParser{}.ParseConfig(strings.NewReader(input), CLIOverrides{})
+	if err != nil {
+		t.Fatalf("ParseConfig error: %v", err)
+	}
+	want := map[string]string{
+		"db1":  "database",
+		"web1": "misc",
+		"db2":  "database",
+		"web2": "group-1",
+		"db 3": "data base",
+	}
+	for _, target := range cfg.Targets {
+		if target.Group != want[target.Name] {
+			t.Errorf("target %s: expected group %q, got %q", target.Name, want[target.Name], target.Group)
+		}
+	}
+
+	if _, err := (SurveillerParser{}).ParseConfig(strings.NewReader("db1 10.0.0.1 group=\n"), CLIOverrides{}); err == nil {
+		t.Fatalf("expected error for empty group option")
+	}
+}
+
+func TestParseTargetLineGroupsOption(t *testing.T) {
+	parser := SurveillerParser{}
+	target, err := parser.ParseTargetLine("web1 10.0.0.2 groups=web,us-east,web", "group-1")
+	if err != nil {
+		t.Fatalf("ParseTargetLine error: %v", err)
+	}
+	if target.Group != "web" || len(target.Groups) != 2 || target.Groups[1] != "us-east" {
+		t.Fatalf("expected groups [web us-east], got group=%q groups=%v", target.Group, target.Groups)
+	}
+
+	for _, line := range []string{
+		"web1 10.0.0.2 groups=web,,us-east",
+		"web1 10.0.0.2 groups=",
+		"web1 10.0.0.2 group=web groups=us-east",
+	} {
+		if _, err := parser.ParseTargetLine(line, ""); err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+}
+
 func TestParseTargetLineOptions(t *testing.T) {
 	parser := SurveillerParser{}
 	target, err := parser.ParseTargetLine("relay1 192.0.2.10 relay=jump1 user=foo", "group-1")
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// WriteStarter writes a minimal surveiller.conf with the default directives and
+// one target per host. Each host is also used as the target name, with a -2,
+// -3, ... suffix when a host is repeated. Hosts must be IP literals or
+// hostnames so that the output loads without errors.
+func WriteStarter(w io.Writer, hosts []string) error {
+	for _, host := range hosts {
+		if err := validateAddress(host); err != nil {
+			return err
+		}
+	}
+
+	defaults := DefaultGlobalOptions()
+	fmt.Fprintln(w, "# surveiller configuration generated by \"surveiller init\".")
+	fmt.Fprintln(w, "# See example/surveiller.sample.conf for all directives and target options.")
+	fmt.Fprintf(w, "# surveiller: interval=%s timeout=%s max_concurrency=%d\n",
+		defaults.Interval, defaults.Timeout, defaults.MaxConcurrency)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "# <name> <address> [option=value ...]")
+	if len(hosts) == 0 {
+		fmt.Fprintln(w, "# localhost 127.0.0.1")
+		return nil
+	}
+
+	used := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		name := host
+		for n := 2; used[name]; n++ {
+			name = host + "-" + strconv.Itoa(n)
+		}
+		used[name] = true
+		if _, err := fmt.Fprintf(w, "%s %s\n", name, host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
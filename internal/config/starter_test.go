@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteStarterRoundTrips(t *testing.T) {
+	var buf bytes.Buffer
+	hosts := []string{"example.com", "8.8.8.8", "2001:db8::1", "example.com"}
+	if err := WriteStarter(&buf, hosts); err != nil {
+		t.Fatalf("WriteStarter error: %v", err)
+	}
+
+	cfg, err := SurveillerParser{}.ParseConfig(&buf, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("generated config does not parse: %v\n%s", err, buf.String())
+	}
+	if cfg.Global != DefaultGlobalOptions() {
+		t.Fatalf("expected default global options, got %+v", cfg.Global)
+	}
+
+	wantNames := []string{"example.com", "8.8.8.8", "2001:db8::1", "example.com-2"}
+	if len(cfg.Targets) != len(wantNames) {
+		t.Fatalf("expected %d targets, got %d", len(wantNames), len(cfg.Targets))
+	}
+	for i, target := range cfg.Targets {
+		if target.Name != wantNames[i] || target.Address != hosts[i] {
+			t.Fatalf("target %d = %s %s, expected %s %s", i, target.Name, target.Address, wantNames[i], hosts[i])
+		}
+	}
+}
+
+func TestWriteStarterWithoutHosts(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteStarter(&buf, nil); err != nil {
+		t.Fatalf("WriteStarter error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "# surveiller: interval=1s timeout=1s max_concurrency=100\n") {
+		t.Fatalf("expected default directive line, got:\n%s", buf.String())
+	}
+
+	cfg, err := SurveillerParser{}.ParseConfig(&buf, CLIOverrides{})
+	if err != nil {
+		t.Fatalf("generated config does not parse: %v", err)
+	}
+	if len(cfg.Targets) != 0 {
+		t.Fatalf("expected no targets, got %v", cfg.Targets)
+	}
+}
+
+func TestWriteStarterRejectsInvalidHost(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteStarter(&buf, []string{"8.8.8.8", "bad host"})
+	if err == nil || !strings.Contains(err.Error(), "invalid hostname") {
+		t.Fatalf("expected invalid hostname error, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected nothing written on error, got:\n%s", buf.String())
+	}
+}
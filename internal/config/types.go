@@ -11,29 +11,109 @@ const (
 	MetricsModeBoth       MetricsMode = "both"
 )
 
+// UITheme selects the glyph set used by the TUI.
+type UITheme string
+
+const (
+	UIThemeUnicode UITheme = "unicode"
+	UIThemeASCII   UITheme = "ascii"
+)
+
+// RTTUnit pins the unit the TUI uses for RTT values.
+type RTTUnit string
+
+const (
+	RTTUnitAuto         RTTUnit = "auto"
+	RTTUnitMicroseconds RTTUnit = "us"
+	RTTUnitMilliseconds RTTUnit = "ms"
+	RTTUnitSeconds      RTTUnit = "s"
+)
+
+// ProbeType selects how a target is probed.
+type ProbeType string
+
+const (
+	ProbeICMP ProbeType = "icmp"
+	// ProbeSynthetic times DNS, TCP connect and optionally TLS like a client would.
+	ProbeSynthetic ProbeType = "synthetic"
+)
+
 // GlobalOptions holds global settings parsed from config and CLI overrides.
 type GlobalOptions struct {
-	Interval       time.Duration
-	Timeout        time.Duration
-	MaxConcurrency int
-	MetricsMode    MetricsMode
-	MetricsListen  string
-	UIScale        int
-	UIDisable      bool
+	Interval               time.Duration
+	Timeout                time.Duration
+	MaxConcurrency         int
+	MaxConcurrencyExternal int
+	ExternalPingCommand    string // space-separated argv prefix replacing "ping"; empty uses ping from PATH
+	MaxPPS                 int
+	PayloadSize            int
+	DedupeByAddress        bool
+	ShutdownGrace          time.Duration
+	ResultBatchInterval    time.Duration
+	ResolveInterval        time.Duration
+	ResolveCacheTTL        time.Duration // how long resolved addresses are reused; 0 disables
+	DefaultGroup           string
+	MetricsMode            MetricsMode
+	MetricsListen          string
+	MetricsPath            string
+	MetricsPrefix          string
+	MetricsMaxStaleness    time.Duration
+	MetricsRuntime         bool
+	MetricsEnableReload    bool
+	UIScale                int
+	UIDisable              bool
+	UITheme                UITheme
+	UIRTTUnit              RTTUnit
+	UIHideAddress          bool
+	UIHideBar              bool
+	UISetTitle             bool
+	UINoColor              bool
+	LogLevel               string
+	OKThreshold            time.Duration
+	WarnThreshold          time.Duration
+	RTTDownThreshold       time.Duration
+	WarnThresholdNG        int
+	DownThresholdNG        int
+	LossDownThreshold      float64
+	LossWindow             int
+	HistoryWindow          time.Duration
+	FlapThreshold          int
+	FlapWindow             time.Duration
+	TargetSoftLimit        int
+	TargetHardLimit        int
 }
 
 // TargetConfig represents a single target definition.
 type TargetConfig struct {
-	Name    string
-	Address string
-	Group   string
-	Options map[string]string
+	Name            string
+	Address         string
+	Group           string
+	Groups          []string // all groups from a groups= option; Group holds the first
+	Options         map[string]string
+	ResolveInterval time.Duration
+	ExcludeMetrics  bool
+	Label           string
+	Muted           bool
+	Probe           ProbeType // ProbeICMP unless probe= is set
+	TLS             bool      // synthetic probes only: add a TLS handshake
+	DSCP            int       // DSCP marking of ICMP probes (0-63); 0 leaves them unmarked
 }
 
 // Config is the parsed configuration file with global settings.
 type Config struct {
 	Targets []TargetConfig
 	Global  GlobalOptions
+	// Skipped lists the target lines dropped by a lenient parser.
+	Skipped []SkippedLine
+	// LoadedAt is when this config was applied; set by the caller, not the parser.
+	LoadedAt time.Time
+}
+
+// SkippedLine records an invalid target line skipped in lenient mode.
+type SkippedLine struct {
+	File string // empty for a single config
+	Line int
+	Err  error
 }
 
 // CLIOverrides holds optional CLI values that override config file values.
@@ -44,6 +124,8 @@ type CLIOverrides struct {
 	MetricsMode    *MetricsMode
 	MetricsListen  *string
 	UIDisable      *bool
+	UINoColor      *bool
+	LogLevel       *string
 }
 
 // Parser defines config parsing behavior.
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,10 +26,24 @@ var levelNames = map[Level]string{
 	LevelError: "ERROR",
 }
 
+// Time format values understood by SetTimeFormat in addition to Go layouts.
+const (
+	TimeFormatEpoch   = "epoch"
+	TimeFormatEpochMS = "epochms"
+)
+
 // Logger provides structured logging
 type Logger struct {
-	level  Level
-	output io.Writer
+	level      atomic.Int32 // Level; changed by SetLevel on config reload
+	output     io.Writer
+	errOutput  io.Writer // nil means ERROR entries go to output as well
+	timeFormat string
+}
+
+// LevelWriter is implemented by outputs that need the level of each entry,
+// such as syslog, which maps it to a severity.
+type LevelWriter interface {
+	WriteLevel(level Level, p []byte) (int, error)
 }
 
 // LogEntry represents a structured log entry
@@ -42,33 +58,66 @@ type LogEntry struct {
 // By default, logging is disabled (outputs to io.Discard).
 // Use SetOutput to enable logging to a file or other writer.
 func NewLogger(level Level) *Logger {
-	return &Logger{
-		level:  level,
-		output: io.Discard,
-	}
+	l := &Logger{output: io.Discard}
+	l.level.Store(int32(level))
+	return l
 }
 
 // SetOutput sets the output writer for the logger
 func (l *Logger) SetOutput(w io.Writer) {
 	l.output = w
+	l.errOutput = nil
+}
+
+// SetOutputs routes ERROR entries to errorOut and all other entries to normal.
+func (l *Logger) SetOutputs(normal, errorOut io.Writer) {
+	l.output = normal
+	l.errOutput = errorOut
+}
+
+func (l *Logger) writerFor(level Level) io.Writer {
+	if level >= LevelError && l.errOutput != nil {
+		return l.errOutput
+	}
+	return l.output
 }
 
 // SetLevel sets the log level
 func (l *Logger) SetLevel(level Level) {
-	l.level = level
+	l.level.Store(int32(level))
+}
+
+// SetTimeFormat sets the timestamp format: a Go time layout, "epoch" (seconds)
+// or "epochms" (milliseconds). An empty value restores RFC3339.
+func (l *Logger) SetTimeFormat(layout string) {
+	l.timeFormat = layout
+}
+
+func (l *Logger) formatTime(t time.Time) string {
+	switch l.timeFormat {
+	case "":
+		return t.Format(time.RFC3339)
+	case TimeFormatEpoch:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TimeFormatEpochMS:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	default:
+		return t.Format(l.timeFormat)
+	}
 }
 
 // log writes a structured log entry
 func (l *Logger) log(level Level, message string, fields map[string]interface{}) {
-	if level < l.level {
+	if level < Level(l.level.Load()) {
 		return
 	}
-	if l.output == nil || l.output == io.Discard {
+	out := l.writerFor(level)
+	if out == nil || out == io.Discard {
 		return
 	}
 
 	entry := LogEntry{
-		Timestamp: time.Now().Format(time.RFC3339),
+		Timestamp: l.formatTime(time.Now()),
 		Level:     levelNames[level],
 		Message:   message,
 		Fields:    fields,
@@ -77,11 +126,14 @@ func (l *Logger) log(level Level, message string, fields map[string]interface{})
 	data, err := json.Marshal(entry)
 	if err != nil {
 		// Fallback to plain text if JSON marshaling fails
-		fmt.Fprintf(l.output, "[%s] %s: %s\n", entry.Timestamp, entry.Level, message)
-		return
+		data = []byte(fmt.Sprintf("[%s] %s: %s", entry.Timestamp, entry.Level, message))
 	}
 
-	fmt.Fprintln(l.output, string(data))
+	if lw, ok := out.(LevelWriter); ok {
+		_, _ = lw.WriteLevel(level, append(data, '\n'))
+		return
+	}
+	fmt.Fprintln(out, string(data))
 }
 
 // Debug logs a debug message
@@ -150,18 +202,24 @@ func (l *Logger) LogError(component string, err error, fields map[string]interfa
 	l.Error("error occurred", fields)
 }
 
-// ParseLevel parses a log level string
+// ParseLevel parses a log level string, falling back to INFO for unknown values.
 func ParseLevel(levelStr string) Level {
+	level, _ := LookupLevel(levelStr)
+	return level
+}
+
+// LookupLevel parses a log level string and reports whether it was recognised.
+func LookupLevel(levelStr string) (Level, bool) {
 	switch levelStr {
 	case "DEBUG", "debug":
-		return LevelDebug
+		return LevelDebug, true
 	case "INFO", "info":
-		return LevelInfo
+		return LevelInfo, true
 	case "WARN", "warn", "WARNING", "warning":
-		return LevelWarn
+		return LevelWarn, true
 	case "ERROR", "error":
-		return LevelError
+		return LevelError, true
 	default:
-		return LevelInfo
+		return LevelInfo, false
 	}
 }
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type recordingLevelWriter struct {
+	levels []Level
+	buf    bytes.Buffer
+}
+
+func (w *recordingLevelWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *recordingLevelWriter) WriteLevel(level Level, p []byte) (int, error) {
+	w.levels = append(w.levels, level)
+	return w.buf.Write(p)
+}
+
+func TestLoggerPassesLevelToLevelWriter(t *testing.T) {
+	w := &recordingLevelWriter{}
+	logger := NewLogger(LevelDebug)
+	logger.SetOutput(w)
+
+	logger.Debug("d", nil)
+	logger.Warn("w", nil)
+	logger.Error("e", nil)
+
+	want := []Level{LevelDebug, LevelWarn, LevelError}
+	if len(w.levels) != len(want) {
+		t.Fatalf("expected %d entries, got %v", len(want), w.levels)
+	}
+	for i := range want {
+		if w.levels[i] != want[i] {
+			t.Fatalf("entry %d: expected level %v, got %v", i, want[i], w.levels[i])
+		}
+	}
+	if lines := strings.Count(w.buf.String(), "\n"); lines != 3 {
+		t.Fatalf("expected one line per entry, got %q", w.buf.String())
+	}
+}
+
+func TestLookupLevel(t *testing.T) {
+	if level, ok := LookupLevel("warning"); !ok || level != LevelWarn {
+		t.Fatalf("expected warning to map to WARN, got %v %v", level, ok)
+	}
+	if level, ok := LookupLevel("verbose"); ok || level != LevelInfo {
+		t.Fatalf("expected unknown level to fall back to INFO and report false, got %v %v", level, ok)
+	}
+}
+
+func TestLoggerSetLevelFiltersEntries(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(LevelInfo)
+	logger.SetOutput(&buf)
+
+	logger.Debug("hidden", nil)
+	logger.SetLevel(LevelDebug)
+	logger.Debug("shown", nil)
+
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Fatalf("expected SetLevel to change filtering, got %q", buf.String())
+	}
+}
+
+func TestLoggerSetOutputsSplitsErrors(t *testing.T) {
+	var normal, errs bytes.Buffer
+	logger := NewLogger(LevelInfo)
+	logger.SetOutputs(&normal, &errs)
+
+	logger.Info("info entry", nil)
+	logger.Warn("warn entry", nil)
+	logger.Error("error entry", nil)
+
+	if !strings.Contains(normal.String(), "info entry") || !strings.Contains(normal.String(), "warn entry") || strings.Contains(normal.String(), "error entry") {
+		t.Fatalf("unexpected normal output: %q", normal.String())
+	}
+	if !strings.Contains(errs.String(), "error entry") || strings.Contains(errs.String(), "info entry") {
+		t.Fatalf("unexpected error output: %q", errs.String())
+	}
+
+	// SetOutput goes back to a single writer.
+	var single bytes.Buffer
+	logger.SetOutput(&single)
+	logger.Error("again", nil)
+	if !strings.Contains(single.String(), "again") || strings.Contains(errs.String(), "again") {
+		t.Fatalf("expected SetOutput to route errors to the single writer")
+	}
+}
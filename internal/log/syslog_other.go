@@ -0,0 +1,28 @@
+//go:build windows || plan9
+
+package log
+
+import "errors"
+
+// SyslogWriter is unavailable on this platform.
+type SyslogWriter struct{}
+
+// NewSyslogWriter always fails because syslog is not supported on this platform.
+func NewSyslogWriter(facility, tag string) (*SyslogWriter, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
+
+// Write implements io.Writer.
+func (s *SyslogWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("syslog is not supported on this platform")
+}
+
+// WriteLevel implements LevelWriter.
+func (s *SyslogWriter) WriteLevel(level Level, p []byte) (int, error) {
+	return s.Write(p)
+}
+
+// Close implements io.Closer.
+func (s *SyslogWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,84 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+)
+
+// SyslogWriter sends log entries to the local syslog daemon, mapping each
+// entry's Level to a syslog severity.
+type SyslogWriter struct {
+	w *syslog.Writer
+}
+
+// NewSyslogWriter connects to the local syslog daemon with the given facility
+// (e.g. "daemon", "user", "local0") and tag.
+func NewSyslogWriter(facility, tag string) (*SyslogWriter, error) {
+	priority, err := parseFacility(facility)
+	if err != nil {
+		return nil, err
+	}
+	w, err := syslog.New(priority|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, fmt.Errorf("connect to syslog: %w", err)
+	}
+	return &SyslogWriter{w: w}, nil
+}
+
+// Write logs p at INFO severity.
+func (s *SyslogWriter) Write(p []byte) (int, error) {
+	return s.WriteLevel(LevelInfo, p)
+}
+
+// WriteLevel logs p at the syslog severity corresponding to level.
+func (s *SyslogWriter) WriteLevel(level Level, p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\n")
+	var err error
+	switch level {
+	case LevelDebug:
+		err = s.w.Debug(msg)
+	case LevelWarn:
+		err = s.w.Warning(msg)
+	case LevelError:
+		err = s.w.Err(msg)
+	default:
+		err = s.w.Info(msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to the syslog daemon.
+func (s *SyslogWriter) Close() error {
+	return s.w.Close()
+}
+
+var facilities = map[string]syslog.Priority{
+	"kern":   syslog.LOG_KERN,
+	"user":   syslog.LOG_USER,
+	"mail":   syslog.LOG_MAIL,
+	"daemon": syslog.LOG_DAEMON,
+	"auth":   syslog.LOG_AUTH,
+	"syslog": syslog.LOG_SYSLOG,
+	"local0": syslog.LOG_LOCAL0,
+	"local1": syslog.LOG_LOCAL1,
+	"local2": syslog.LOG_LOCAL2,
+	"local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4,
+	"local5": syslog.LOG_LOCAL5,
+	"local6": syslog.LOG_LOCAL6,
+	"local7": syslog.LOG_LOCAL7,
+}
+
+func parseFacility(name string) (syslog.Priority, error) {
+	priority, ok := facilities[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown syslog facility %q", name)
+	}
+	return priority, nil
+}
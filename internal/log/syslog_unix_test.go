@@ -0,0 +1,23 @@
+//go:build !windows && !plan9
+
+package log
+
+import (
+	"log/syslog"
+	"testing"
+)
+
+func TestParseFacility(t *testing.T) {
+	for name, want := range map[string]syslog.Priority{"daemon": syslog.LOG_DAEMON, "LOCAL3": syslog.LOG_LOCAL3, "user": syslog.LOG_USER} {
+		got, err := parseFacility(name)
+		if err != nil {
+			t.Fatalf("parseFacility(%q) error: %v", name, err)
+		}
+		if got != want {
+			t.Fatalf("parseFacility(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if _, err := parseFacility("nope"); err == nil {
+		t.Fatalf("expected error for unknown facility")
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/doridoridoriand/surveiller/internal/state"
+)
+
+// cachedSnapshot is an immutable store snapshot shared by concurrent scrapes.
+type cachedSnapshot struct {
+	targets []state.TargetStatus
+}
+
+// RunSnapshotCache serves scrapes from a copy of the store refreshed every
+// maxStaleness instead of reading the store on each request, so frequent
+// scrapes of large target sets do not contend with ping results for the
+// store lock. Each refresh replaces the whole snapshot at once; requests see
+// either the previous or the next one, never a mix. It blocks until ctx is
+// done, after which requests read the store directly again.
+func (s *Server) RunSnapshotCache(ctx context.Context, maxStaleness time.Duration) {
+	if maxStaleness <= 0 {
+		return
+	}
+	s.refreshSnapshot()
+	defer s.cache.Store(nil)
+
+	ticker := time.NewTicker(maxStaleness)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refreshSnapshot()
+		}
+	}
+}
+
+func (s *Server) refreshSnapshot() {
+	s.cache.Store(&cachedSnapshot{targets: s.store.GetSnapshot()})
+}
+
+// snapshot returns the cached snapshot when RunSnapshotCache is active and the
+// live store state otherwise. Callers must not modify the result.
+func (s *Server) snapshot() []state.TargetStatus {
+	if cached := s.cache.Load(); cached != nil {
+		return cached.targets
+	}
+	return s.store.GetSnapshot()
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doridoridoriand/surveiller/internal/config"
+	"github.com/doridoridoriand/surveiller/internal/ping"
+	"github.com/doridoridoriand/surveiller/internal/state"
+)
+
+func scrape(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
+
+func TestRunSnapshotCache(t *testing.T) {
+	store := state.NewStore([]config.TargetConfig{{Name: "web", Address: "192.0.2.1"}}, time.Second, state.Thresholds{})
+	server := NewServer(config.MetricsModeAggregated, store, "test")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.RunSnapshotCache(ctx, time.Hour)
+		close(done)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for server.cache.Load() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("snapshot cache was not populated")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Until the next refresh, scrapes see the snapshot taken at startup.
+	store.UpdateResult("web", ping.Result{Success: true, RTT: time.Millisecond})
+	if body := scrape(t, server.Handler()); !strings.Contains(body, "surveiller_targets_unknown 1") {
+		t.Fatalf("expected cached UNKNOWN status, got:\n%s", body)
+	}
+
+	server.refreshSnapshot()
+	if body := scrape(t, server.Handler()); !strings.Contains(body, "surveiller_targets_ok 1") {
+		t.Fatalf("expected refreshed OK status, got:\n%s", body)
+	}
+
+	cancel()
+	<-done
+	if server.cache.Load() != nil {
+		t.Fatal("expected the cache to be dropped once the context is done")
+	}
+}
+
+func TestRunSnapshotCacheDisabled(t *testing.T) {
+	server := NewServer(config.MetricsModeAggregated, fakeStore{}, "test")
+	server.RunSnapshotCache(context.Background(), 0)
+	if server.cache.Load() != nil {
+		t.Fatal("expected no cache when max staleness is 0")
+	}
+}
@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/doridoridoriand/surveiller/internal/config"
+)
+
+// configResponse is the JSON document served by ConfigHandler.
+type configResponse struct {
+	Global  globalOptionsJSON  `json:"global"`
+	Groups  []string           `json:"groups"`
+	Targets []targetConfigJSON `json:"targets"`
+}
+
+type globalOptionsJSON struct {
+	Interval               string  `json:"interval"`
+	Timeout                string  `json:"timeout"`
+	MaxConcurrency         int     `json:"max_concurrency"`
+	MaxConcurrencyExternal int     `json:"max_concurrency_external"`
+	ExternalPingCommand    string  `json:"external_ping_cmd,omitempty"`
+	MaxPPS                 int     `json:"max_pps"`
+	PayloadSize            int     `json:"payload_size"`
+	DedupeByAddress        bool    `json:"dedupe_by_address"`
+	ShutdownGrace          string  `json:"shutdown_grace"`
+	ResultBatchInterval    string  `json:"result_batch_interval"`
+	ResolveInterval        string  `json:"resolve_interval"`
+	ResolveCacheTTL        string  `json:"resolve_cache_ttl"`
+	MetricsMode            string  `json:"metrics_mode"`
+	MetricsListen          string  `json:"metrics_listen"`
+	MetricsPath            string  `json:"metrics_path"`
+	MetricsPrefix          string  `json:"metrics_prefix"`
+	MetricsMaxStaleness    string  `json:"metrics_max_staleness"`
+	MetricsRuntime         bool    `json:"metrics_runtime"`
+	MetricsEnableReload    bool    `json:"metrics_enable_reload"`
+	LogLevel               string  `json:"log_level"`
+	DefaultGroup           string  `json:"default_group"`
+	UIScale                int     `json:"ui_scale"`
+	UIDisable              bool    `json:"ui_disable"`
+	UITheme                string  `json:"ui_theme"`
+	UIRTTUnit              string  `json:"ui_rtt_unit"`
+	UIShowAddress          bool    `json:"ui_show_address"`
+	UIShowBar              bool    `json:"ui_show_bar"`
+	UISetTitle             bool    `json:"ui_set_title"`
+	OKThreshold            string  `json:"ok_threshold"`
+	WarnThreshold          string  `json:"warn_threshold"`
+	RTTDownThreshold       string  `json:"rtt_down_threshold"`
+	LossDownThreshold      float64 `json:"loss_down_threshold"`
+	WarnThresholdNG        int     `json:"warn_threshold_ng"`
+	DownThresholdNG        int     `json:"down_threshold_ng"`
+	LossWindow             int     `json:"loss_window"`
+	HistoryWindow          string  `json:"history_window"`
+	FlapThreshold          int     `json:"flap_threshold"`
+	FlapWindow             string  `json:"flap_window"`
+	TargetSoftLimit        int     `json:"target_soft_limit"`
+	TargetHardLimit        int     `json:"target_hard_limit"`
+}
+
+type targetConfigJSON struct {
+	Name            string            `json:"name"`
+	Address         string            `json:"address"`
+	Group           string            `json:"group"`
+	Groups          []string          `json:"groups,omitempty"`
+	Options         map[string]string `json:"options,omitempty"`
+	ResolveInterval string            `json:"resolve_interval,omitempty"`
+	ExcludeMetrics  bool              `json:"exclude_metrics,omitempty"`
+	Probe           string            `json:"probe,omitempty"`
+	TLS             bool              `json:"tls,omitempty"`
+	DSCP            int               `json:"dscp,omitempty"`
+}
+
+// SetConfigSource sets the function used by ConfigHandler to read the
+// effective configuration. It must return the state after any reload.
+func (s *Server) SetConfigSource(source func() config.Config) {
+	s.configSource = source
+}
+
+// ConfigHandler returns an http handler that serves the effective configuration as JSON.
+// It responds 404 when no config source has been set.
+func (s *Server) ConfigHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if s.configSource == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		s.logWriteError(r, json.NewEncoder(w).Encode(buildConfigResponse(s.configSource())))
+	})
+}
+
+func buildConfigResponse(cfg config.Config) configResponse {
+	global := cfg.Global
+	resp := configResponse{
+		Global: globalOptionsJSON{
+			Interval:               global.Interval.String(),
+			Timeout:                global.Timeout.String(),
+			MaxConcurrency:         global.MaxConcurrency,
+			MaxConcurrencyExternal: global.MaxConcurrencyExternal,
+			ExternalPingCommand:    global.ExternalPingCommand,
+			MaxPPS:                 global.MaxPPS,
+			PayloadSize:            global.PayloadSize,
+			DedupeByAddress:        global.DedupeByAddress,
+			ShutdownGrace:          global.ShutdownGrace.String(),
+			ResultBatchInterval:    global.ResultBatchInterval.String(),
+			ResolveInterval:        global.ResolveInterval.String(),
+			ResolveCacheTTL:        global.ResolveCacheTTL.String(),
+			MetricsMode:            string(global.MetricsMode),
+			MetricsListen:          global.MetricsListen,
+			MetricsPath:            global.MetricsPath,
+			MetricsPrefix:          global.MetricsPrefix,
+			MetricsMaxStaleness:    global.MetricsMaxStaleness.String(),
+			MetricsRuntime:         global.MetricsRuntime,
+			MetricsEnableReload:    global.MetricsEnableReload,
+			LogLevel:               global.LogLevel,
+			DefaultGroup:           global.DefaultGroup,
+			UIScale:                global.UIScale,
+			UIDisable:              global.UIDisable,
+			UITheme:                string(global.UITheme),
+			UIRTTUnit:              string(global.UIRTTUnit),
+			UIShowAddress:          !global.UIHideAddress,
+			UIShowBar:              !global.UIHideBar,
+			UISetTitle:             global.UISetTitle,
+			OKThreshold:            global.OKThreshold.String(),
+			WarnThreshold:          global.WarnThreshold.String(),
+			RTTDownThreshold:       global.RTTDownThreshold.String(),
+			LossDownThreshold:      global.LossDownThreshold,
+			WarnThresholdNG:        global.WarnThresholdNG,
+			DownThresholdNG:        global.DownThresholdNG,
+			LossWindow:             global.LossWindow,
+			HistoryWindow:          global.HistoryWindow.String(),
+			FlapThreshold:          global.FlapThreshold,
+			FlapWindow:             global.FlapWindow.String(),
+			TargetSoftLimit:        global.TargetSoftLimit,
+			TargetHardLimit:        global.TargetHardLimit,
+		},
+		Groups:  []string{},
+		Targets: make([]targetConfigJSON, 0, len(cfg.Targets)),
+	}
+
+	seenGroups := make(map[string]bool)
+	for _, target := range cfg.Targets {
+		for _, group := range append([]string{target.Group}, target.Groups...) {
+			if group != "" && !seenGroups[group] {
+				seenGroups[group] = true
+				resp.Groups = append(resp.Groups, group)
+			}
+		}
+		entry := targetConfigJSON{
+			Name:           target.Name,
+			Address:        target.Address,
+			Group:          target.Group,
+			Groups:         target.Groups,
+			ExcludeMetrics: target.ExcludeMetrics,
+			Probe:          string(target.Probe),
+			TLS:            target.TLS,
+			DSCP:           target.DSCP,
+		}
+		if len(target.Options) > 0 {
+			entry.Options = target.Options
+		}
+		if target.ResolveInterval > 0 {
+			entry.ResolveInterval = target.ResolveInterval.String()
+		}
+		resp.Targets = append(resp.Targets, entry)
+	}
+	return resp
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/doridoridoriand/surveiller/internal/config"
+)
+
+func TestConfigHandlerReturnsEffectiveConfig(t *testing.T) {
+	cfg := config.Config{
+		Global: config.GlobalOptions{
+			Interval:       2 * time.Second,
+			Timeout:        500 * time.Millisecond,
+			MaxConcurrency: 10,
+			MetricsMode:    config.MetricsModeBoth,
+			MetricsListen:  ":9100",
+			MetricsPath:    "/metrics",
+		},
+		Targets: []config.TargetConfig{
+			{Name: "web", Address: "192.0.2.10", Group: "frontend", Options: map[string]string{"metrics": "false"}, ExcludeMetrics: true},
+			{Name: "api", Address: "192.0.2.11", Group: "frontend"},
+			{Name: "db", Address: "db.example", Group: "backend", ResolveInterval: time.Minute},
+		},
+	}
+	server := NewServer(config.MetricsModeBoth, fakeStore{}, "test")
+	server.SetConfigSource(func() config.Config { return cfg })
+
+	rec := httptest.NewRecorder()
+	server.ConfigHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+
+	var resp configResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, rec.Body.String())
+	}
+	if resp.Global.Interval != "2s" || resp.Global.Timeout != "500ms" || resp.Global.MetricsMode != "both" {
+		t.Fatalf("unexpected global options: %+v", resp.Global)
+	}
+	if len(resp.Groups) != 2 || resp.Groups[0] != "frontend" || resp.Groups[1] != "backend" {
+		t.Fatalf("expected groups in config order, got %v", resp.Groups)
+	}
+	if len(resp.Targets) != 3 {
+		t.Fatalf("expected 3 targets, got %d", len(resp.Targets))
+	}
+	if !resp.Targets[0].ExcludeMetrics || resp.Targets[0].Options["metrics"] != "false" {
+		t.Fatalf("unexpected target entry: %+v", resp.Targets[0])
+	}
+	if resp.Targets[2].ResolveInterval != "1m0s" {
+		t.Fatalf("expected resolve_interval 1m0s, got %q", resp.Targets[2].ResolveInterval)
+	}
+
+	// リロード後の設定が反映されること
+	cfg.Global.Interval = 5 * time.Second
+	rec = httptest.NewRecorder()
+	server.ConfigHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Global.Interval != "5s" {
+		t.Fatalf("expected reloaded interval 5s, got %q", resp.Global.Interval)
+	}
+}
+
+func TestConfigHandlerWithoutSource(t *testing.T) {
+	server := NewServer(config.MetricsModeBoth, fakeStore{}, "test")
+
+	rec := httptest.NewRecorder()
+	server.ConfigHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without config source, got %d", rec.Code)
+	}
+
+	server.SetConfigSource(func() config.Config { return config.Config{} })
+	rec = httptest.NewRecorder()
+	server.ConfigHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/config", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for POST, got %d", rec.Code)
+	}
+}
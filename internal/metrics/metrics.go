@@ -5,22 +5,66 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"runtime"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/doridoridoriand/surveiller/internal/config"
+	"github.com/doridoridoriand/surveiller/internal/log"
 	"github.com/doridoridoriand/surveiller/internal/state"
 )
 
 // Server exposes Prometheus-style metrics based on current state.
 type Server struct {
-	mode  config.MetricsMode
-	store state.Store
+	mode         config.MetricsMode
+	store        state.Store
+	version      string
+	configSource func() config.Config
+	cache        atomic.Pointer[cachedSnapshot]
+	logger       *log.Logger
+	reload       func() error
 }
 
-// NewServer constructs a metrics server.
-func NewServer(mode config.MetricsMode, store state.Store) *Server {
-	return &Server{mode: mode, store: store}
+// NewServer constructs a metrics server. version is reported by <prefix>_build_info.
+func NewServer(mode config.MetricsMode, store state.Store, version string) *Server {
+	return &Server{mode: mode, store: store, version: version}
+}
+
+// SetLogger sets the logger Serve uses to report startup, shutdown and
+// failures, and the handlers use for responses that could not be written,
+// typically because the client went away. nil disables logging.
+func (s *Server) SetLogger(logger *log.Logger) {
+	s.logger = logger
+}
+
+// logEntry logs at level with component=metrics added to fields.
+func (s *Server) logEntry(level log.Level, message string, fields map[string]interface{}) {
+	if s.logger == nil {
+		return
+	}
+	fields["component"] = "metrics"
+	switch level {
+	case log.LevelDebug:
+		s.logger.Debug(message, fields)
+	case log.LevelError:
+		s.logger.Error(message, fields)
+	default:
+		s.logger.Info(message, fields)
+	}
+}
+
+func (s *Server) logWriteError(r *http.Request, err error) {
+	if err != nil {
+		s.logEntry(log.LevelDebug, "Failed to write HTTP response", map[string]interface{}{
+			"path":   r.URL.Path,
+			"remote": r.RemoteAddr,
+			"error":  err.Error(),
+		})
+	}
 }
 
 // Handler returns an http handler that serves metrics.
@@ -32,62 +76,182 @@ func (s *Server) Handler() http.Handler {
 		}
 		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
 		bw := bufio.NewWriter(w)
-		defer bw.Flush()
 		s.writeMetrics(bw)
+		s.logWriteError(r, bw.Flush())
 	})
 }
 
 func (s *Server) writeMetrics(w *bufio.Writer) {
-	snapshot := s.store.GetSnapshot()
+	snapshot := s.snapshot()
 	if s.mode == "" {
 		return
 	}
 
+	// metrics.prefix is read on every scrape so a reload can change it.
+	var cfg config.Config
+	prefix := config.DefaultMetricsPrefix
+	if s.configSource != nil {
+		cfg = s.configSource()
+		if cfg.Global.MetricsPrefix != "" {
+			prefix = cfg.Global.MetricsPrefix
+		}
+	}
+
+	writeBuildInfo(w, prefix, s.version)
+	if s.configSource != nil {
+		writeConfigReload(w, prefix, cfg.LoadedAt)
+		if cfg.Global.MetricsRuntime {
+			writeRuntime(w, prefix)
+		}
+	}
+
 	if s.mode == config.MetricsModeAggregated || s.mode == config.MetricsModeBoth {
-		writeAggregated(w, snapshot)
+		writeAggregated(w, prefix, snapshot)
+		writeGroupAggregated(w, prefix, snapshot)
 	}
 	if s.mode == config.MetricsModePerTarget || s.mode == config.MetricsModeBoth {
-		writePerTarget(w, snapshot)
+		writePerTarget(w, prefix, snapshot)
+	}
+}
+
+func writeBuildInfo(w *bufio.Writer, prefix, version string) {
+	fmt.Fprintf(w, "%s_build_info{version=%q,goversion=%q} 1\n", prefix, escapeLabel(version), escapeLabel(runtime.Version()))
+}
+
+// writeConfigReload reports when the running config was loaded, so a
+// SIGHUP or POST /-/reload can be confirmed from the metrics.
+func writeConfigReload(w *bufio.Writer, prefix string, loadedAt time.Time) {
+	if loadedAt.IsZero() {
+		return
 	}
+	fmt.Fprintf(w, "%s_config_last_reload_timestamp_seconds %d\n", prefix, loadedAt.Unix())
 }
 
-func writeAggregated(w *bufio.Writer, snapshot []state.TargetStatus) {
-	total := len(snapshot)
-	var okCount, warnCount, downCount, unknownCount int
+type statusCounts struct {
+	total, ok, warn, down, flapping, muted, unknown int
+}
+
+func countStatuses(snapshot []state.TargetStatus) statusCounts {
+	counts := statusCounts{total: len(snapshot)}
 	for _, target := range snapshot {
 		switch target.Status {
 		case state.StatusOK:
-			okCount++
+			counts.ok++
 		case state.StatusWarn:
-			warnCount++
+			counts.warn++
 		case state.StatusDown:
-			downCount++
+			counts.down++
+		case state.StatusFlapping:
+			counts.flapping++
+		case state.StatusMuted:
+			counts.muted++
 		default:
-			unknownCount++
+			counts.unknown++
 		}
 	}
-	fmt.Fprintf(w, "surveiller_targets_total %d\n", total)
-	fmt.Fprintf(w, "surveiller_targets_ok %d\n", okCount)
-	fmt.Fprintf(w, "surveiller_targets_warn %d\n", warnCount)
-	fmt.Fprintf(w, "surveiller_targets_down %d\n", downCount)
-	fmt.Fprintf(w, "surveiller_targets_unknown %d\n", unknownCount)
+	return counts
 }
 
-func writePerTarget(w *bufio.Writer, snapshot []state.TargetStatus) {
+func writeAggregated(w *bufio.Writer, prefix string, snapshot []state.TargetStatus) {
+	counts := countStatuses(snapshot)
+	fmt.Fprintf(w, "%s_targets_total %d\n", prefix, counts.total)
+	fmt.Fprintf(w, "%s_targets_ok %d\n", prefix, counts.ok)
+	fmt.Fprintf(w, "%s_targets_warn %d\n", prefix, counts.warn)
+	fmt.Fprintf(w, "%s_targets_down %d\n", prefix, counts.down)
+	fmt.Fprintf(w, "%s_targets_flapping %d\n", prefix, counts.flapping)
+	fmt.Fprintf(w, "%s_targets_muted %d\n", prefix, counts.muted)
+	fmt.Fprintf(w, "%s_targets_unknown %d\n", prefix, counts.unknown)
+
+	var sent, failed int
 	for _, target := range snapshot {
+		sent += target.TotalSuccess + target.TotalFailure
+		failed += target.TotalFailure
+	}
+	fmt.Fprintf(w, "%s_probes_sent_total %d\n", prefix, sent)
+	fmt.Fprintf(w, "%s_probes_failed_total %d\n", prefix, failed)
+}
+
+// writeGroupAggregated emits status counts broken down by group, sorted by group name.
+func writeGroupAggregated(w *bufio.Writer, prefix string, snapshot []state.TargetStatus) {
+	groups := make(map[string][]state.TargetStatus)
+	for _, target := range snapshot {
+		for _, name := range target.GroupNames() {
+			groups[name] = append(groups[name], target)
+		}
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		counts := countStatuses(groups[name])
+		label := fmt.Sprintf("group=%q", escapeLabel(name))
+		fmt.Fprintf(w, "%s_group_targets_total{%s} %d\n", prefix, label, counts.total)
+		fmt.Fprintf(w, "%s_group_targets_ok{%s} %d\n", prefix, label, counts.ok)
+		fmt.Fprintf(w, "%s_group_targets_warn{%s} %d\n", prefix, label, counts.warn)
+		fmt.Fprintf(w, "%s_group_targets_down{%s} %d\n", prefix, label, counts.down)
+		fmt.Fprintf(w, "%s_group_targets_flapping{%s} %d\n", prefix, label, counts.flapping)
+		fmt.Fprintf(w, "%s_group_targets_muted{%s} %d\n", prefix, label, counts.muted)
+		fmt.Fprintf(w, "%s_group_targets_unknown{%s} %d\n", prefix, label, counts.unknown)
+	}
+}
+
+func writePerTarget(w *bufio.Writer, prefix string, snapshot []state.TargetStatus) {
+	// The store's snapshot order follows map iteration; sort a copy by name
+	// so consecutive scrapes are byte-identical. snapshot may be shared.
+	sorted := make([]state.TargetStatus, len(snapshot))
+	copy(sorted, snapshot)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	for _, target := range sorted {
+		if target.ExcludeMetrics {
+			continue
+		}
 		labels := fmt.Sprintf(
 			"target=%q,address=%q,group=%q",
 			escapeLabel(target.Name),
 			escapeLabel(target.Address),
 			escapeLabel(target.Group),
 		)
-		up := 0
-		if target.Status == state.StatusOK {
-			up = 1
+		// Muted targets report <prefix>_target_muted instead of up, so
+		// "up == 0" alerts stay quiet during maintenance.
+		if target.Muted {
+			fmt.Fprintf(w, "%s_target_muted{%s} 1\n", prefix, labels)
+		} else {
+			up := 0
+			if target.Status == state.StatusOK {
+				up = 1
+			}
+			fmt.Fprintf(w, "%s_target_up{%s} %d\n", prefix, labels, up)
+		}
+		if target.Interval > 0 {
+			fmt.Fprintf(w, "%s_target_interval_seconds{%s} %g\n", prefix, labels, target.Interval.Seconds())
+		}
+		if target.Timeout > 0 {
+			fmt.Fprintf(w, "%s_target_timeout_seconds{%s} %g\n", prefix, labels, target.Timeout.Seconds())
+		}
+		if target.Backend != "" {
+			fmt.Fprintf(w, "%s_target_backend_info{%s,backend=%q} 1\n", prefix, labels, escapeLabel(target.Backend))
+		}
+		fmt.Fprintf(w, "%s_target_probes_sent_total{%s} %d\n", prefix, labels, target.TotalSuccess+target.TotalFailure)
+		fmt.Fprintf(w, "%s_target_probes_failed_total{%s} %d\n", prefix, labels, target.TotalFailure)
+		// LastRTT is kept from the last reply, so a target whose latest probe
+		// failed would otherwise report a stale, healthy-looking latency.
+		if target.LastRTT > 0 && target.ConsecutiveNG == 0 {
+			fmt.Fprintf(w, "%s_target_rtt_ms{%s} %d\n", prefix, labels, target.LastRTT.Milliseconds())
+		}
+		if len(target.Recent) > 0 {
+			fmt.Fprintf(w, "%s_target_loss_percent{%s} %.1f\n", prefix, labels, target.RecentLossPercent())
 		}
-		fmt.Fprintf(w, "surveiller_target_up{%s} %d\n", labels, up)
-		if target.LastRTT > 0 {
-			fmt.Fprintf(w, "surveiller_target_rtt_ms{%s} %d\n", labels, target.LastRTT.Milliseconds())
+		if target.LastTTL > 0 {
+			fmt.Fprintf(w, "%s_target_ttl{%s} %d\n", prefix, labels, target.LastTTL)
+		}
+		if len(target.History) > 1 {
+			jitter := float64(state.Jitter(target.History)) / float64(time.Millisecond)
+			fmt.Fprintf(w, "%s_target_rtt_jitter_ms{%s} %.3f\n", prefix, labels, jitter)
 		}
 	}
 }
@@ -98,28 +262,57 @@ func escapeLabel(value string) string {
 	return value
 }
 
-// Serve starts an HTTP server and blocks until context cancellation.
-func Serve(ctx context.Context, addr string, mode config.MetricsMode, store state.Store) error {
+// Serve starts an HTTP server for srv and blocks until context cancellation.
+// Metrics are served on path, or config.DefaultMetricsPath when path is empty.
+func Serve(ctx context.Context, addr string, path string, srv *Server) error {
+	if path == "" {
+		path = config.DefaultMetricsPath
+	}
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", NewServer(mode, store).Handler())
+	mux.Handle(path, srv.Handler())
+	mux.Handle("/status.json", srv.StatusHandler())
+	mux.Handle("/config", srv.ConfigHandler())
+	mux.Handle("/-/reload", srv.ReloadHandler())
 	server := &http.Server{
 		Addr:    addr,
 		Handler: mux,
 	}
 
+	// Bind before logging so a port conflict is reported as such rather
+	// than after a misleading "listening" entry.
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		srv.logEntry(log.LevelError, "Metrics server failed to listen", map[string]interface{}{
+			"address": addr,
+			"error":   err.Error(),
+		})
+		return err
+	}
+	srv.logEntry(log.LevelInfo, "Metrics server listening", map[string]interface{}{
+		"address": ln.Addr().String(),
+		"path":    path,
+	})
+
 	errCh := make(chan error, 1)
 	go func() {
-		errCh <- server.ListenAndServe()
+		errCh <- server.Serve(ln)
 	}()
 
 	select {
 	case <-ctx.Done():
 		_ = server.Shutdown(context.Background())
+		srv.logEntry(log.LevelInfo, "Metrics server stopped", map[string]interface{}{
+			"address": ln.Addr().String(),
+		})
 		return ctx.Err()
 	case err := <-errCh:
 		if errors.Is(err, http.ErrServerClosed) {
 			return context.Canceled
 		}
+		srv.logEntry(log.LevelError, "Metrics server failed", map[string]interface{}{
+			"address": ln.Addr().String(),
+			"error":   err.Error(),
+		})
 		return err
 	}
 }
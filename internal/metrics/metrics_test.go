@@ -4,13 +4,17 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/doridoridoriand/surveiller/internal/config"
+	"github.com/doridoridoriand/surveiller/internal/log"
 	"github.com/doridoridoriand/surveiller/internal/ping"
 	"github.com/doridoridoriand/surveiller/internal/state"
 )
@@ -29,29 +33,44 @@ func (f fakeStore) UpdateTargets(targets []config.TargetConfig) {}
 
 func (f fakeStore) UpdateTimeout(timeout time.Duration) {}
 
+func (f fakeStore) UpdateThresholds(thresholds state.Thresholds) {}
+
+func (f fakeStore) UpdateResolvedAddress(name string, ip string) {}
+
+func (f fakeStore) Reset(name string) {}
+
+func (f fakeStore) RecentEvents(limit int) []state.Event {
+	return nil
+}
+
 func (f fakeStore) GetTargetStatus(name string) (state.TargetStatus, bool) {
 	return state.TargetStatus{}, false
 }
 
 func TestWriteAggregated(t *testing.T) {
 	snapshot := []state.TargetStatus{
-		{Status: state.StatusOK},
+		{Status: state.StatusOK, TotalSuccess: 5},
 		{Status: state.StatusWarn},
-		{Status: state.StatusDown},
+		{Status: state.StatusDown, TotalSuccess: 2, TotalFailure: 3},
 		{Status: state.StatusUnknown},
+		{Status: state.StatusFlapping},
 	}
 	var buf bytes.Buffer
 	writer := bufio.NewWriter(&buf)
-	writeAggregated(writer, snapshot)
+	writeAggregated(writer, config.DefaultMetricsPrefix, snapshot)
 	_ = writer.Flush()
 
 	got := buf.String()
 	expected := strings.Join([]string{
-		"surveiller_targets_total 4",
+		"surveiller_targets_total 5",
 		"surveiller_targets_ok 1",
 		"surveiller_targets_warn 1",
 		"surveiller_targets_down 1",
+		"surveiller_targets_flapping 1",
+		"surveiller_targets_muted 0",
 		"surveiller_targets_unknown 1",
+		"surveiller_probes_sent_total 10",
+		"surveiller_probes_failed_total 3",
 		"",
 	}, "\n")
 	if got != expected {
@@ -59,34 +78,105 @@ func TestWriteAggregated(t *testing.T) {
 	}
 }
 
+func TestWriteGroupAggregated(t *testing.T) {
+	snapshot := []state.TargetStatus{
+		{Group: "web", Status: state.StatusDown},
+		{Group: "web", Status: state.StatusOK},
+		{Group: "", Status: state.StatusWarn},
+		{Group: "db", Status: state.StatusDown},
+	}
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	writeGroupAggregated(writer, config.DefaultMetricsPrefix, snapshot)
+	_ = writer.Flush()
+
+	expected := strings.Join([]string{
+		`surveiller_group_targets_total{group="db"} 1`,
+		`surveiller_group_targets_ok{group="db"} 0`,
+		`surveiller_group_targets_warn{group="db"} 0`,
+		`surveiller_group_targets_down{group="db"} 1`,
+		`surveiller_group_targets_flapping{group="db"} 0`,
+		`surveiller_group_targets_muted{group="db"} 0`,
+		`surveiller_group_targets_unknown{group="db"} 0`,
+		`surveiller_group_targets_total{group="default"} 1`,
+		`surveiller_group_targets_ok{group="default"} 0`,
+		`surveiller_group_targets_warn{group="default"} 1`,
+		`surveiller_group_targets_down{group="default"} 0`,
+		`surveiller_group_targets_flapping{group="default"} 0`,
+		`surveiller_group_targets_muted{group="default"} 0`,
+		`surveiller_group_targets_unknown{group="default"} 0`,
+		`surveiller_group_targets_total{group="web"} 2`,
+		`surveiller_group_targets_ok{group="web"} 1`,
+		`surveiller_group_targets_warn{group="web"} 0`,
+		`surveiller_group_targets_down{group="web"} 1`,
+		`surveiller_group_targets_flapping{group="web"} 0`,
+		`surveiller_group_targets_muted{group="web"} 0`,
+		`surveiller_group_targets_unknown{group="web"} 0`,
+		"",
+	}, "\n")
+	if buf.String() != expected {
+		t.Fatalf("unexpected group metrics:\n%s", buf.String())
+	}
+}
+
+func TestWriteGroupAggregatedMultipleGroups(t *testing.T) {
+	snapshot := []state.TargetStatus{
+		{Group: "web", Groups: []string{"web", "us-east"}, Status: state.StatusDown},
+		{Group: "web", Status: state.StatusOK},
+	}
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	writeGroupAggregated(writer, config.DefaultMetricsPrefix, snapshot)
+	_ = writer.Flush()
+
+	for _, line := range []string{
+		`surveiller_group_targets_total{group="us-east"} 1`,
+		`surveiller_group_targets_down{group="us-east"} 1`,
+		`surveiller_group_targets_total{group="web"} 2`,
+		`surveiller_group_targets_down{group="web"} 1`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("expected %s, got:\n%s", line, buf.String())
+		}
+	}
+}
+
 func TestWritePerTarget(t *testing.T) {
 	snapshot := []state.TargetStatus{
 		{
-			Name:    "name\"1",
-			Address: "addr\\path",
-			Group:   "grp",
-			Status:  state.StatusOK,
-			LastRTT: 15 * time.Millisecond,
+			Name:         "name\"1",
+			Address:      "addr\\path",
+			Group:        "grp",
+			Status:       state.StatusOK,
+			LastRTT:      15 * time.Millisecond,
+			TotalSuccess: 4,
+			TotalFailure: 1,
 		},
 		{
-			Name:    "down",
-			Address: "1.1.1.1",
-			Group:   "",
-			Status:  state.StatusDown,
+			Name:         "down",
+			Address:      "1.1.1.1",
+			Group:        "",
+			Status:       state.StatusDown,
+			TotalFailure: 3,
 		},
 	}
 
 	var buf bytes.Buffer
 	writer := bufio.NewWriter(&buf)
-	writePerTarget(writer, snapshot)
+	writePerTarget(writer, config.DefaultMetricsPrefix, snapshot)
 	_ = writer.Flush()
 
 	labels1 := `target="name\\\"1",address="addr\\\\path",group="grp"`
 	labels2 := `target="down",address="1.1.1.1",group=""`
+	// Targets are written in name order regardless of snapshot order.
 	expected := strings.Join([]string{
+		"surveiller_target_up{" + labels2 + "} 0",
+		"surveiller_target_probes_sent_total{" + labels2 + "} 3",
+		"surveiller_target_probes_failed_total{" + labels2 + "} 3",
 		"surveiller_target_up{" + labels1 + "} 1",
+		"surveiller_target_probes_sent_total{" + labels1 + "} 5",
+		"surveiller_target_probes_failed_total{" + labels1 + "} 1",
 		"surveiller_target_rtt_ms{" + labels1 + "} 15",
-		"surveiller_target_up{" + labels2 + "} 0",
 		"",
 	}, "\n")
 	if buf.String() != expected {
@@ -94,6 +184,51 @@ func TestWritePerTarget(t *testing.T) {
 	}
 }
 
+func TestHandlerOutputIsStableAcrossScrapes(t *testing.T) {
+	targets := make([]config.TargetConfig, 0, 50)
+	for i := 0; i < 50; i++ {
+		targets = append(targets, config.TargetConfig{Name: fmt.Sprintf("host%02d", i), Address: fmt.Sprintf("192.0.2.%d", i+1)})
+	}
+	store := state.NewStore(targets, time.Second, state.Thresholds{})
+	for _, target := range targets {
+		store.UpdateResult(target.Name, ping.Result{Success: true, RTT: 5 * time.Millisecond})
+	}
+	server := NewServer(config.MetricsModeBoth, store, "test")
+
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return rec.Body.String()
+	}
+	first := scrape()
+	for i := 0; i < 5; i++ {
+		if next := scrape(); next != first {
+			t.Fatalf("expected byte-identical scrapes, got:\n%s\nthen:\n%s", first, next)
+		}
+	}
+	if strings.Index(first, `target="host00"`) > strings.Index(first, `target="host49"`) {
+		t.Fatalf("expected targets in name order")
+	}
+}
+
+func TestHandlerReportsConfigReloadTime(t *testing.T) {
+	server := NewServer(config.MetricsModeAggregated, fakeStore{}, "test")
+	if body := scrape(t, server.Handler()); strings.Contains(body, "surveiller_config_last_reload_timestamp_seconds") {
+		t.Fatalf("expected no reload timestamp without a config source, got:\n%s", body)
+	}
+
+	cfg := config.Config{LoadedAt: time.Unix(1700000000, 0)}
+	server.SetConfigSource(func() config.Config { return cfg })
+	if body := scrape(t, server.Handler()); !strings.Contains(body, "\nsurveiller_config_last_reload_timestamp_seconds 1700000000\n") {
+		t.Fatalf("expected reload timestamp, got:\n%s", body)
+	}
+
+	cfg.LoadedAt = time.Unix(1700000600, 0)
+	if body := scrape(t, server.Handler()); !strings.Contains(body, "surveiller_config_last_reload_timestamp_seconds 1700000600") {
+		t.Fatalf("expected the timestamp to follow a reload, got:\n%s", body)
+	}
+}
+
 func TestEscapeLabel(t *testing.T) {
 	if got := escapeLabel(`value"slash\`); got != `value\"slash\\` {
 		t.Fatalf("unexpected escaped label: %q", got)
@@ -101,7 +236,7 @@ func TestEscapeLabel(t *testing.T) {
 }
 
 func TestHandlerMethodNotAllowed(t *testing.T) {
-	server := NewServer(config.MetricsModeAggregated, fakeStore{})
+	server := NewServer(config.MetricsModeAggregated, fakeStore{}, "test")
 	req := httptest.NewRequest(http.MethodPost, "/metrics", nil)
 	rec := httptest.NewRecorder()
 	server.Handler().ServeHTTP(rec, req)
@@ -115,7 +250,7 @@ func TestHandlerAggregatedOutput(t *testing.T) {
 	store := fakeStore{
 		snapshot: []state.TargetStatus{{Status: state.StatusOK}},
 	}
-	server := NewServer(config.MetricsModeAggregated, store)
+	server := NewServer(config.MetricsModeAggregated, store, "test")
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
 	rec := httptest.NewRecorder()
 	server.Handler().ServeHTTP(rec, req)
@@ -138,7 +273,7 @@ func TestServeContextCancellation(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	err := Serve(ctx, "127.0.0.1:0", config.MetricsModeAggregated, store)
+	err := Serve(ctx, "127.0.0.1:0", "", NewServer(config.MetricsModeAggregated, store, "test"))
 	if err == nil {
 		t.Fatalf("expected context cancellation error")
 	}
@@ -164,7 +299,7 @@ func TestHandlerPerTargetMode(t *testing.T) {
 			},
 		},
 	}
-	server := NewServer(config.MetricsModePerTarget, store)
+	server := NewServer(config.MetricsModePerTarget, store, "test")
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
 	rec := httptest.NewRecorder()
 	server.Handler().ServeHTTP(rec, req)
@@ -185,6 +320,9 @@ func TestHandlerPerTargetMode(t *testing.T) {
 	if strings.Contains(body, "surveiller_targets_total") {
 		t.Fatalf("should not contain aggregated metrics in per-target mode, got %q", body)
 	}
+	if strings.Contains(body, "surveiller_group_targets_") {
+		t.Fatalf("should not contain group metrics in per-target mode, got %q", body)
+	}
 }
 
 // Test aggregated mode metrics output
@@ -196,7 +334,7 @@ func TestHandlerAggregatedMode(t *testing.T) {
 			{Status: state.StatusDown},
 		},
 	}
-	server := NewServer(config.MetricsModeAggregated, store)
+	server := NewServer(config.MetricsModeAggregated, store, "test")
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
 	rec := httptest.NewRecorder()
 	server.Handler().ServeHTTP(rec, req)
@@ -238,7 +376,7 @@ func TestHandlerBothMode(t *testing.T) {
 			},
 		},
 	}
-	server := NewServer(config.MetricsModeBoth, store)
+	server := NewServer(config.MetricsModeBoth, store, "test")
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
 	rec := httptest.NewRecorder()
 	server.Handler().ServeHTTP(rec, req)
@@ -257,12 +395,25 @@ func TestHandlerBothMode(t *testing.T) {
 	}
 }
 
+func TestHandlerBuildInfo(t *testing.T) {
+	for _, mode := range []config.MetricsMode{config.MetricsModePerTarget, config.MetricsModeAggregated, config.MetricsModeBoth} {
+		server := NewServer(mode, fakeStore{}, "1.2.3")
+		rec := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		want := fmt.Sprintf("surveiller_build_info{version=\"1.2.3\",goversion=%q} 1\n", runtime.Version())
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("mode %s: expected %q without targets, got %q", mode, want, rec.Body.String())
+		}
+	}
+}
+
 // Test empty mode (no metrics output)
 func TestHandlerEmptyMode(t *testing.T) {
 	store := fakeStore{
 		snapshot: []state.TargetStatus{{Status: state.StatusOK}},
 	}
-	server := NewServer("", store) // Empty mode
+	server := NewServer("", store, "test") // Empty mode
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
 	rec := httptest.NewRecorder()
 	server.Handler().ServeHTTP(rec, req)
@@ -336,6 +487,9 @@ func TestWritePerTargetVariousStatuses(t *testing.T) {
 			Group:   "group1",
 			Status:  state.StatusOK,
 			LastRTT: 10 * time.Millisecond,
+			LastTTL: 58,
+			History: []state.RTTPoint{{RTT: 8 * time.Millisecond}, {RTT: 12 * time.Millisecond}},
+			Recent:  []bool{true, true, true, false},
 		},
 		{
 			Name:    "warn_target",
@@ -362,7 +516,7 @@ func TestWritePerTargetVariousStatuses(t *testing.T) {
 
 	var buf bytes.Buffer
 	writer := bufio.NewWriter(&buf)
-	writePerTarget(writer, snapshot)
+	writePerTarget(writer, config.DefaultMetricsPrefix, snapshot)
 	_ = writer.Flush()
 
 	output := buf.String()
@@ -391,6 +545,30 @@ func TestWritePerTargetVariousStatuses(t *testing.T) {
 		t.Errorf("expected RTT metric for WARN target, got %q", output)
 	}
 
+	// TTL is only reported when a reply carried one
+	if !strings.Contains(output, `surveiller_target_ttl{target="ok_target",address="1.1.1.1",group="group1"} 58`) {
+		t.Errorf("expected TTL metric for OK target, got %q", output)
+	}
+	if strings.Contains(output, `surveiller_target_ttl{target="warn_target"`) {
+		t.Errorf("should not have TTL metric for target without TTL, got %q", output)
+	}
+
+	// Loss is measured over the recent window only
+	if !strings.Contains(output, `surveiller_target_loss_percent{target="ok_target",address="1.1.1.1",group="group1"} 25.0`) {
+		t.Errorf("expected loss metric for OK target, got %q", output)
+	}
+	if strings.Contains(output, `surveiller_target_loss_percent{target="warn_target"`) {
+		t.Errorf("should not have loss metric without recent probes, got %q", output)
+	}
+
+	// Jitter needs at least two history points
+	if !strings.Contains(output, `surveiller_target_rtt_jitter_ms{target="ok_target",address="1.1.1.1",group="group1"} 2.000`) {
+		t.Errorf("expected jitter metric for OK target, got %q", output)
+	}
+	if strings.Contains(output, `surveiller_target_rtt_jitter_ms{target="warn_target"`) {
+		t.Errorf("should not have jitter metric without history, got %q", output)
+	}
+
 	// Check that targets with 0 RTT don't have RTT metrics
 	if strings.Contains(output, `surveiller_target_rtt_ms{target="down_target"`) {
 		t.Errorf("should not have RTT metric for DOWN target with 0 RTT, got %q", output)
@@ -400,6 +578,83 @@ func TestWritePerTargetVariousStatuses(t *testing.T) {
 	}
 }
 
+func TestWritePerTargetOmitsStaleRTT(t *testing.T) {
+	snapshot := []state.TargetStatus{
+		{
+			Name:          "down_target",
+			Address:       "192.0.2.1",
+			Status:        state.StatusDown,
+			LastRTT:       12 * time.Millisecond,
+			ConsecutiveNG: 3,
+		},
+		{
+			// rtt_down_threshold: still replying, so the RTT is current
+			Name:    "slow_target",
+			Address: "192.0.2.2",
+			Status:  state.StatusDown,
+			LastRTT: 900 * time.Millisecond,
+		},
+	}
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	writePerTarget(writer, config.DefaultMetricsPrefix, snapshot)
+	_ = writer.Flush()
+	output := buf.String()
+
+	if strings.Contains(output, `surveiller_target_rtt_ms{target="down_target"`) {
+		t.Errorf("should not report the last reply's RTT for a failing target, got %q", output)
+	}
+	if !strings.Contains(output, `surveiller_target_rtt_ms{target="slow_target",address="192.0.2.2",group=""} 900`) {
+		t.Errorf("expected RTT for a slow target that still replies, got %q", output)
+	}
+}
+
+func TestWritePerTargetTiming(t *testing.T) {
+	snapshot := []state.TargetStatus{
+		{Name: "web", Address: "192.0.2.1", Interval: 500 * time.Millisecond, Timeout: 2 * time.Second},
+		{Name: "untimed", Address: "192.0.2.2"},
+	}
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	writePerTarget(writer, config.DefaultMetricsPrefix, snapshot)
+	_ = writer.Flush()
+	output := buf.String()
+
+	for _, want := range []string{
+		`surveiller_target_interval_seconds{target="web",address="192.0.2.1",group=""} 0.5`,
+		`surveiller_target_timeout_seconds{target="web",address="192.0.2.1",group=""} 2`,
+	} {
+		if !strings.Contains(output, want+"\n") {
+			t.Errorf("expected %s, got %q", want, output)
+		}
+	}
+	if strings.Contains(output, `_seconds{target="untimed"`) {
+		t.Errorf("expected no timing gauges for a target without timing, got %q", output)
+	}
+}
+
+func TestWritePerTargetBackendInfo(t *testing.T) {
+	snapshot := []state.TargetStatus{
+		{Name: "probed", Address: "192.0.2.1", Status: state.StatusOK, Backend: "icmp"},
+		{Name: "pending", Address: "192.0.2.2", Status: state.StatusUnknown},
+	}
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	writePerTarget(writer, config.DefaultMetricsPrefix, snapshot)
+	_ = writer.Flush()
+	output := buf.String()
+
+	if !strings.Contains(output, `surveiller_target_backend_info{target="probed",address="192.0.2.1",group="",backend="icmp"} 1`) {
+		t.Errorf("expected backend info for a probed target, got %q", output)
+	}
+	if strings.Contains(output, `surveiller_target_backend_info{target="pending"`) {
+		t.Errorf("should not report a backend before the first probe, got %q", output)
+	}
+}
+
 // Test aggregated metrics with all status types
 func TestWriteAggregatedAllStatuses(t *testing.T) {
 	snapshot := []state.TargetStatus{
@@ -415,7 +670,7 @@ func TestWriteAggregatedAllStatuses(t *testing.T) {
 
 	var buf bytes.Buffer
 	writer := bufio.NewWriter(&buf)
-	writeAggregated(writer, snapshot)
+	writeAggregated(writer, config.DefaultMetricsPrefix, snapshot)
 	_ = writer.Flush()
 
 	got := buf.String()
@@ -424,7 +679,11 @@ func TestWriteAggregatedAllStatuses(t *testing.T) {
 		"surveiller_targets_ok 2",
 		"surveiller_targets_warn 3",
 		"surveiller_targets_down 1",
+		"surveiller_targets_flapping 0",
+		"surveiller_targets_muted 0",
 		"surveiller_targets_unknown 2",
+		"surveiller_probes_sent_total 0",
+		"surveiller_probes_failed_total 0",
 		"",
 	}, "\n")
 
@@ -439,7 +698,7 @@ func TestWriteMetricsEmptySnapshot(t *testing.T) {
 	writer := bufio.NewWriter(&buf)
 
 	// Test aggregated with empty snapshot
-	writeAggregated(writer, []state.TargetStatus{})
+	writeAggregated(writer, config.DefaultMetricsPrefix, []state.TargetStatus{})
 	_ = writer.Flush()
 
 	expected := strings.Join([]string{
@@ -447,7 +706,11 @@ func TestWriteMetricsEmptySnapshot(t *testing.T) {
 		"surveiller_targets_ok 0",
 		"surveiller_targets_warn 0",
 		"surveiller_targets_down 0",
+		"surveiller_targets_flapping 0",
+		"surveiller_targets_muted 0",
 		"surveiller_targets_unknown 0",
+		"surveiller_probes_sent_total 0",
+		"surveiller_probes_failed_total 0",
 		"",
 	}, "\n")
 
@@ -458,7 +721,7 @@ func TestWriteMetricsEmptySnapshot(t *testing.T) {
 	// Test per-target with empty snapshot
 	buf.Reset()
 	writer = bufio.NewWriter(&buf)
-	writePerTarget(writer, []state.TargetStatus{})
+	writePerTarget(writer, config.DefaultMetricsPrefix, []state.TargetStatus{})
 	_ = writer.Flush()
 
 	if buf.String() != "" {
@@ -476,7 +739,7 @@ func TestServeStartupShutdown(t *testing.T) {
 	defer cancel()
 
 	// Use port 0 to get a random available port
-	err := Serve(ctx, "127.0.0.1:0", config.MetricsModeAggregated, store)
+	err := Serve(ctx, "127.0.0.1:0", "", NewServer(config.MetricsModeAggregated, store, "test"))
 
 	// Should return context.Canceled when context is cancelled
 	if err != context.Canceled && err != context.DeadlineExceeded {
@@ -484,6 +747,50 @@ func TestServeStartupShutdown(t *testing.T) {
 	}
 }
 
+func TestServeLogsLifecycle(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewLogger(log.LevelInfo)
+	logger.SetOutput(&buf)
+	srv := NewServer(config.MetricsModeAggregated, fakeStore{}, "test")
+	srv.SetLogger(logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_ = Serve(ctx, "127.0.0.1:0", "", srv)
+
+	output := buf.String()
+	for _, want := range []string{`"message":"Metrics server listening"`, `"message":"Metrics server stopped"`, `"component":"metrics"`, `"path":"/metrics"`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected log to contain %s, got %q", want, output)
+		}
+	}
+}
+
+func TestServeLogsBindFailure(t *testing.T) {
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer occupied.Close()
+
+	var buf bytes.Buffer
+	logger := log.NewLogger(log.LevelInfo)
+	logger.SetOutput(&buf)
+	srv := NewServer(config.MetricsModeAggregated, fakeStore{}, "test")
+	srv.SetLogger(logger)
+
+	if err := Serve(context.Background(), occupied.Addr().String(), "", srv); err == nil {
+		t.Fatalf("expected an error for an address in use")
+	}
+	output := buf.String()
+	if !strings.Contains(output, `"message":"Metrics server failed to listen"`) || !strings.Contains(output, occupied.Addr().String()) {
+		t.Fatalf("expected the bind failure to be logged with its address, got %q", output)
+	}
+	if strings.Contains(output, "Metrics server listening") {
+		t.Fatalf("expected no listening entry after a bind failure, got %q", output)
+	}
+}
+
 // Test HTTP server with invalid address
 func TestServeInvalidAddress(t *testing.T) {
 	store := fakeStore{
@@ -494,7 +801,7 @@ func TestServeInvalidAddress(t *testing.T) {
 	defer cancel()
 
 	// Use an invalid address format
-	err := Serve(ctx, "invalid-address", config.MetricsModeAggregated, store)
+	err := Serve(ctx, "invalid-address", "", NewServer(config.MetricsModeAggregated, store, "test"))
 
 	// Should return an error (not context cancellation)
 	if err == nil {
@@ -510,7 +817,7 @@ func TestHandlerResponseHeaders(t *testing.T) {
 	store := fakeStore{
 		snapshot: []state.TargetStatus{{Status: state.StatusOK}},
 	}
-	server := NewServer(config.MetricsModeAggregated, store)
+	server := NewServer(config.MetricsModeAggregated, store, "test")
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
 	rec := httptest.NewRecorder()
 
@@ -531,7 +838,7 @@ func TestHandlerUnsupportedMethods(t *testing.T) {
 	store := fakeStore{
 		snapshot: []state.TargetStatus{{Status: state.StatusOK}},
 	}
-	server := NewServer(config.MetricsModeAggregated, store)
+	server := NewServer(config.MetricsModeAggregated, store, "test")
 
 	methods := []string{http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch}
 
@@ -559,7 +866,7 @@ func TestServePortAlreadyInUse(t *testing.T) {
 	defer cancel()
 
 	// Try to start server on an invalid port to trigger an error
-	err := Serve(ctx, "127.0.0.1:99999", config.MetricsModeAggregated, store)
+	err := Serve(ctx, "127.0.0.1:99999", "", NewServer(config.MetricsModeAggregated, store, "test"))
 
 	// Should return some kind of error (either bind error or context timeout)
 	if err == nil {
@@ -575,7 +882,7 @@ func TestHandlerDifferentPaths(t *testing.T) {
 
 	// Create a full mux like in the Serve function
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", NewServer(config.MetricsModeAggregated, store).Handler())
+	mux.Handle("/metrics", NewServer(config.MetricsModeAggregated, store, "test").Handler())
 
 	tests := []struct {
 		path           string
@@ -601,6 +908,97 @@ func TestHandlerDifferentPaths(t *testing.T) {
 	}
 }
 
+func TestServeCustomPath(t *testing.T) {
+	store := fakeStore{
+		snapshot: []state.TargetStatus{{Status: state.StatusOK}},
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = Serve(ctx, addr, "/surveiller/metrics", NewServer(config.MetricsModeAggregated, store, "test"))
+	}()
+
+	var resp *http.Response
+	for i := 0; i < 100; i++ {
+		resp, err = http.Get("http://" + addr + "/surveiller/metrics")
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("GET custom path: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 on custom path, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("GET default path: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 on default path, got %d", resp.StatusCode)
+	}
+}
+
+func TestWritePerTargetSkipsExcludedTargets(t *testing.T) {
+	snapshot := []state.TargetStatus{
+		{Name: "kept", Address: "192.0.2.1", Status: state.StatusOK, LastRTT: 5 * time.Millisecond},
+		{Name: "excluded", Address: "192.0.2.2", Status: state.StatusOK, LastRTT: 5 * time.Millisecond, ExcludeMetrics: true},
+	}
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	writePerTarget(writer, config.DefaultMetricsPrefix, snapshot)
+	writeAggregated(writer, config.DefaultMetricsPrefix, snapshot)
+	_ = writer.Flush()
+
+	output := buf.String()
+	if !strings.Contains(output, `target="kept"`) {
+		t.Errorf("expected metrics for kept target, got %q", output)
+	}
+	if strings.Contains(output, `target="excluded"`) {
+		t.Errorf("expected no per-target metrics for excluded target, got %q", output)
+	}
+	if !strings.Contains(output, "surveiller_targets_total 2") {
+		t.Errorf("expected excluded target to count toward aggregates, got %q", output)
+	}
+}
+
+func TestWriteMetricsMutedTarget(t *testing.T) {
+	snapshot := []state.TargetStatus{
+		{Name: "maint", Address: "192.0.2.1", Status: state.StatusMuted, Muted: true, TotalFailure: 2},
+	}
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	writePerTarget(writer, config.DefaultMetricsPrefix, snapshot)
+	writeAggregated(writer, config.DefaultMetricsPrefix, snapshot)
+	_ = writer.Flush()
+
+	output := buf.String()
+	if !strings.Contains(output, `surveiller_target_muted{target="maint",address="192.0.2.1",group=""} 1`) {
+		t.Errorf("expected muted metric, got %q", output)
+	}
+	if strings.Contains(output, "surveiller_target_up{") {
+		t.Errorf("expected no up metric for a muted target, got %q", output)
+	}
+	if !strings.Contains(output, "surveiller_targets_muted 1") || !strings.Contains(output, "surveiller_targets_down 0") {
+		t.Errorf("expected muted target counted as muted, not down, got %q", output)
+	}
+}
+
 // Test server graceful shutdown
 func TestServeGracefulShutdown(t *testing.T) {
 	store := fakeStore{
@@ -612,7 +1010,7 @@ func TestServeGracefulShutdown(t *testing.T) {
 	// Start server in goroutine
 	errCh := make(chan error, 1)
 	go func() {
-		errCh <- Serve(ctx, "127.0.0.1:0", config.MetricsModeAggregated, store)
+		errCh <- Serve(ctx, "127.0.0.1:0", "", NewServer(config.MetricsModeAggregated, store, "test"))
 	}()
 
 	// Give server time to start
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SetReloader sets the function ReloadHandler calls to reload the config. It
+// must validate and apply the config the same way a SIGHUP does.
+func (s *Server) SetReloader(reload func() error) {
+	s.reload = reload
+}
+
+// ReloadHandler returns an http handler that reloads the config on POST,
+// responding 200 once the new config is applied and 500 with the error when
+// it is rejected. It responds 404 unless a reloader has been set and the
+// running config has metrics.enable_reload, which is read on every request.
+func (s *Server) ReloadHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.reload == nil || s.configSource == nil || !s.configSource().Global.MetricsEnableReload {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if err := s.reload(); err != nil {
+			http.Error(w, fmt.Sprintf("reload failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err := fmt.Fprintln(w, "config reloaded")
+		s.logWriteError(r, err)
+	})
+}
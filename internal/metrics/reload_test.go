@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/doridoridoriand/surveiller/internal/config"
+)
+
+func TestReloadHandler(t *testing.T) {
+	server := NewServer(config.MetricsModeBoth, fakeStore{}, "test")
+
+	rec := httptest.NewRecorder()
+	server.ReloadHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without reloader, got %d", rec.Code)
+	}
+
+	calls := 0
+	var reloadErr error
+	server.SetReloader(func() error {
+		calls++
+		return reloadErr
+	})
+
+	// The endpoint stays hidden until metrics.enable_reload is set.
+	cfg := config.Config{}
+	server.SetConfigSource(func() config.Config { return cfg })
+	rec = httptest.NewRecorder()
+	server.ReloadHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+	if rec.Code != http.StatusNotFound || calls != 0 {
+		t.Fatalf("expected 404 without metrics.enable_reload, got %d (calls=%d)", rec.Code, calls)
+	}
+	cfg.Global.MetricsEnableReload = true
+
+	rec = httptest.NewRecorder()
+	server.ReloadHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/-/reload", nil))
+	if rec.Code != http.StatusMethodNotAllowed || calls != 0 {
+		t.Fatalf("expected 405 for GET without reloading, got %d (calls=%d)", rec.Code, calls)
+	}
+
+	rec = httptest.NewRecorder()
+	server.ReloadHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+	if rec.Code != http.StatusOK || calls != 1 {
+		t.Fatalf("expected 200 after one reload, got %d (calls=%d)", rec.Code, calls)
+	}
+
+	reloadErr = errors.New("line 3: invalid interval")
+	rec = httptest.NewRecorder()
+	server.ReloadHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 for a rejected config, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "line 3: invalid interval") {
+		t.Fatalf("expected the reload error in the body, got %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// writeRuntime emits process metrics of surveiller itself for metrics.runtime.
+// ReadMemStats briefly stops the world, which is why they are opt-in.
+func writeRuntime(w *bufio.Writer, prefix string) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	fmt.Fprintf(w, "%s_goroutines %d\n", prefix, runtime.NumGoroutine())
+	fmt.Fprintf(w, "%s_memory_alloc_bytes %d\n", prefix, mem.Alloc)
+	fmt.Fprintf(w, "%s_memory_sys_bytes %d\n", prefix, mem.Sys)
+	fmt.Fprintf(w, "%s_memory_heap_objects %d\n", prefix, mem.HeapObjects)
+	fmt.Fprintf(w, "%s_gc_cycles_total %d\n", prefix, mem.NumGC)
+	fmt.Fprintf(w, "%s_gc_pause_seconds_total %.6f\n", prefix, float64(mem.PauseTotalNs)/float64(time.Second))
+}
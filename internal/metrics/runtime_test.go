@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/doridoridoriand/surveiller/internal/config"
+)
+
+func TestRuntimeMetricsAreOptIn(t *testing.T) {
+	server := NewServer(config.MetricsModeAggregated, fakeStore{}, "test")
+	if body := scrape(t, server.Handler()); strings.Contains(body, "surveiller_goroutines") {
+		t.Fatalf("expected no runtime metrics by default, got:\n%s", body)
+	}
+
+	cfg := config.Config{Global: config.GlobalOptions{MetricsRuntime: true}}
+	server.SetConfigSource(func() config.Config { return cfg })
+	body := scrape(t, server.Handler())
+	for _, name := range []string{
+		"surveiller_goroutines ",
+		"surveiller_memory_alloc_bytes ",
+		"surveiller_memory_sys_bytes ",
+		"surveiller_memory_heap_objects ",
+		"surveiller_gc_cycles_total ",
+		"surveiller_gc_pause_seconds_total ",
+	} {
+		if !strings.Contains(body, "\n"+name) {
+			t.Errorf("expected %s in metrics, got:\n%s", strings.TrimSpace(name), body)
+		}
+	}
+
+	// A reload that turns the directive off takes effect on the next scrape.
+	cfg.Global.MetricsRuntime = false
+	if body := scrape(t, server.Handler()); strings.Contains(body, "surveiller_goroutines") {
+		t.Fatalf("expected runtime metrics to stop after reload, got:\n%s", body)
+	}
+}
+
+func TestMetricsPrefixFollowsConfig(t *testing.T) {
+	server := NewServer(config.MetricsModeAggregated, fakeStore{}, "test")
+	if body := scrape(t, server.Handler()); !strings.Contains(body, "\nsurveiller_targets_total ") {
+		t.Fatalf("expected default surveiller_ prefix, got:\n%s", body)
+	}
+
+	cfg := config.Config{Global: config.GlobalOptions{MetricsPrefix: "deadman", MetricsRuntime: true}}
+	server.SetConfigSource(func() config.Config { return cfg })
+	body := scrape(t, server.Handler())
+	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+		if !strings.HasPrefix(line, "deadman_") {
+			t.Errorf("expected deadman_ prefix, got %q", line)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/doridoridoriand/surveiller/internal/state"
+)
+
+// statusResponse is the JSON document served by StatusHandler.
+type statusResponse struct {
+	Targets []targetStatusJSON `json:"targets"`
+}
+
+type targetStatusJSON struct {
+	Name          string       `json:"name"`
+	Address       string       `json:"address"`
+	ResolvedIP    string       `json:"resolved_ip,omitempty"`
+	Group         string       `json:"group"`
+	Groups        []string     `json:"groups,omitempty"`
+	Status        state.Status `json:"status"`
+	Backend       string       `json:"backend,omitempty"`
+	RTTMs         float64      `json:"rtt_ms"`
+	LossPercent   float64      `json:"loss_percent"`
+	RecentLoss    float64      `json:"recent_loss_percent"`
+	ConsecutiveOK int          `json:"consecutive_ok"`
+	ConsecutiveNG int          `json:"consecutive_ng"`
+	TotalSuccess  int          `json:"total_success"`
+	TotalFailure  int          `json:"total_failure"`
+	LastSuccessAt *time.Time   `json:"last_success_at,omitempty"`
+	LastFailureAt *time.Time   `json:"last_failure_at,omitempty"`
+}
+
+// StatusHandler returns an http handler that serves the current snapshot as JSON.
+func (s *Server) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		s.logWriteError(r, json.NewEncoder(w).Encode(buildStatusResponse(s.snapshot())))
+	})
+}
+
+// buildStatusResponse sorts its output by name; snapshot itself may be shared
+// and is left untouched.
+func buildStatusResponse(snapshot []state.TargetStatus) statusResponse {
+	resp := statusResponse{Targets: make([]targetStatusJSON, 0, len(snapshot))}
+	for _, target := range snapshot {
+		resp.Targets = append(resp.Targets, targetStatusJSON{
+			Name:          target.Name,
+			Address:       target.Address,
+			ResolvedIP:    target.ResolvedIP,
+			Group:         target.Group,
+			Groups:        target.Groups,
+			Status:        target.Status,
+			Backend:       target.Backend,
+			RTTMs:         float64(target.LastRTT) / float64(time.Millisecond),
+			LossPercent:   target.LossPercent(),
+			RecentLoss:    target.RecentLossPercent(),
+			ConsecutiveOK: target.ConsecutiveOK,
+			ConsecutiveNG: target.ConsecutiveNG,
+			TotalSuccess:  target.TotalSuccess,
+			TotalFailure:  target.TotalFailure,
+			LastSuccessAt: optionalTime(target.LastSuccessAt),
+			LastFailureAt: optionalTime(target.LastFailureAt),
+		})
+	}
+	sort.Slice(resp.Targets, func(i, j int) bool {
+		return resp.Targets[i].Name < resp.Targets[j].Name
+	})
+	return resp
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
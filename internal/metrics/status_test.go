@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/doridoridoriand/surveiller/internal/config"
+	"github.com/doridoridoriand/surveiller/internal/state"
+)
+
+func TestStatusHandlerReturnsSnapshotJSON(t *testing.T) {
+	lastSuccess := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	store := fakeStore{
+		snapshot: []state.TargetStatus{
+			{
+				Name:          "web",
+				Address:       "192.0.2.10",
+				Group:         "frontend",
+				Status:        state.StatusOK,
+				LastRTT:       12500 * time.Microsecond,
+				LastSuccessAt: lastSuccess,
+				ConsecutiveOK: 3,
+				TotalSuccess:  3,
+				TotalFailure:  1,
+			},
+			{
+				Name:          "db",
+				Address:       "192.0.2.20",
+				Status:        state.StatusDown,
+				ConsecutiveNG: 4,
+				TotalFailure:  4,
+			},
+		},
+	}
+	server := NewServer(config.MetricsModePerTarget, store, "test")
+	req := httptest.NewRequest(http.MethodGet, "/status.json", nil)
+	rec := httptest.NewRecorder()
+	server.StatusHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("unexpected content type: %q", contentType)
+	}
+
+	var resp statusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, rec.Body.String())
+	}
+	if len(resp.Targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(resp.Targets))
+	}
+
+	db, web := resp.Targets[0], resp.Targets[1]
+	if db.Name != "db" || web.Name != "web" {
+		t.Fatalf("expected targets sorted by name, got %q, %q", db.Name, web.Name)
+	}
+	if db.Status != state.StatusDown || db.ConsecutiveNG != 4 || db.LossPercent != 100 {
+		t.Fatalf("unexpected db status: %+v", db)
+	}
+	if db.LastSuccessAt != nil {
+		t.Fatalf("expected last_success_at omitted for never-successful target")
+	}
+	if web.RTTMs != 12.5 || web.LossPercent != 25 || web.Group != "frontend" {
+		t.Fatalf("unexpected web status: %+v", web)
+	}
+	if web.LastSuccessAt == nil || !web.LastSuccessAt.Equal(lastSuccess) {
+		t.Fatalf("expected last_success_at %v, got %v", lastSuccess, web.LastSuccessAt)
+	}
+}
+
+func TestStatusHandlerMethodNotAllowed(t *testing.T) {
+	server := NewServer(config.MetricsModePerTarget, fakeStore{}, "test")
+	req := httptest.NewRequest(http.MethodPost, "/status.json", nil)
+	rec := httptest.NewRecorder()
+	server.StatusHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", rec.Code)
+	}
+}
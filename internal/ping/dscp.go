@@ -0,0 +1,17 @@
+package ping
+
+import "context"
+
+type dscpKey struct{}
+
+// WithDSCP returns a context that asks ICMPPinger to mark the echo request
+// with dscp (0-63). Other pingers ignore it.
+func WithDSCP(ctx context.Context, dscp int) context.Context {
+	return context.WithValue(ctx, dscpKey{}, dscp)
+}
+
+// DSCPFromContext returns the DSCP set with WithDSCP, if any.
+func DSCPFromContext(ctx context.Context) (int, bool) {
+	dscp, ok := ctx.Value(dscpKey{}).(int)
+	return dscp, ok
+}
@@ -2,45 +2,111 @@ package ping
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os/exec"
 	"regexp"
 	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
-var timePattern = regexp.MustCompile(`time=([0-9.]+)\s*ms`)
+// killWaitDelay is how long Ping waits for I/O to finish after killing a ping
+// process that outlived its deadline.
+const killWaitDelay = 100 * time.Millisecond
+
+// commandContext builds the ping command; tests replace it to simulate a
+// misbehaving ping binary.
+var commandContext = exec.CommandContext
+
+var (
+	// Unix prints "time=12.5 ms"; Windows prints "time=12ms" or "time<1ms".
+	timePattern = regexp.MustCompile(`time([=<])([0-9.]+)\s*ms`)
+	ttlPattern  = regexp.MustCompile(`(?i)(?:ttl|hlim)=([0-9]+)`)
+)
 
 // ExternalPinger invokes the system ping command for environments without raw socket access.
-type ExternalPinger struct{}
+type ExternalPinger struct {
+	mu      sync.Mutex
+	command []string
+}
 
 // NewExternalPinger returns a ping implementation that shells out to ping.
 func NewExternalPinger() *ExternalPinger {
 	return &ExternalPinger{}
 }
 
+// SetCommand replaces the ping binary with argv, e.g. ["/bin/ping"] or
+// ["busybox", "ping"]; the usual ping arguments are appended to it. An empty
+// argv restores ping (ping6 for IPv6 on macOS) looked up on PATH.
+func (p *ExternalPinger) SetCommand(argv []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.command = append([]string(nil), argv...)
+}
+
 // Ping runs the system ping command and parses the RTT from stdout.
 func (p *ExternalPinger) Ping(ctx context.Context, addr string, timeout time.Duration) Result {
-	args := pingArgs(addr, timeout)
-	cmdName := pingCommand(addr)
+	result := p.ping(ctx, addr, timeout)
+	result.Backend = BackendExternal
+	return result
+}
+
+func (p *ExternalPinger) ping(ctx context.Context, addr string, timeout time.Duration) Result {
+	p.mu.Lock()
+	command := p.command
+	p.mu.Unlock()
+
+	// Resolve once so the family flag matches the address ping actually uses.
+	ipAddr, _, err := resolveIP(ctx, addr, timeout)
+	if err != nil {
+		return Result{Success: false, Error: err}
+	}
+	target := ipAddr.String()
+	args := pingArgs(target, timeout)
+	cmdName := pingCommand(target)
+	if len(command) > 0 {
+		cmdName = command[0]
+		args = append(append([]string(nil), command[1:]...), args...)
+	}
+
+	// The ping command rounds its own timeout (to whole seconds on Linux), so
+	// enforce the same deadline as the ICMP path and kill the process if it
+	// outlives it.
+	pingCtx, cancel := context.WithDeadline(ctx, effectiveDeadline(ctx, timeout))
+	defer cancel()
+
 	start := time.Now()
-	cmd := exec.CommandContext(ctx, cmdName, args...)
+	cmd := commandContext(pingCtx, cmdName, args...)
+	cmd.Cancel = func() error { return cmd.Process.Kill() }
+	// Bound the wait for output pipes in case the killed process left a child holding them.
+	cmd.WaitDelay = killWaitDelay
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		// Check if context was cancelled due to timeout
-		if ctx.Err() == context.DeadlineExceeded {
-			return Result{Success: false, Error: fmt.Errorf("ping timeout: %w", ctx.Err())}
+		// A killed process only reports "signal: killed"; surface the context
+		// error instead so timeouts and shutdowns aren't logged as ping failures.
+		switch ctxErr := pingCtx.Err(); {
+		case errors.Is(ctxErr, context.DeadlineExceeded):
+			return Result{Success: false, Error: fmt.Errorf("ping timeout: %w", ctxErr)}
+		case ctxErr != nil:
+			return Result{Success: false, Error: fmt.Errorf("external ping: %w", ctxErr)}
 		}
 		return Result{Success: false, Error: fmt.Errorf("external ping failed: %w", err)}
 	}
 
 	rtt := parseRTT(out)
 	if rtt == 0 {
+		// Windows ping exits 0 when a router answers "Destination host
+		// unreachable", so a reply without a time is not a success there.
+		if runtime.GOOS == "windows" {
+			return Result{Success: false, Error: fmt.Errorf("external ping failed: no echo reply")}
+		}
 		rtt = time.Since(start)
 	}
-	return Result{Success: true, RTT: rtt}
+	return Result{Success: true, RTT: rtt, TTL: parseTTL(out)}
 }
 
 // pingCommand returns the appropriate ping command name for the given address.
@@ -54,7 +120,8 @@ func pingCommand(addr string) string {
 
 // isIPv6 checks if the given address is an IPv6 address.
 func isIPv6(addr string) bool {
-	ip := net.ParseIP(addr)
+	host, _, _ := strings.Cut(addr, "%") // link-local zone, e.g. fe80::1%eth0
+	ip := net.ParseIP(host)
 	if ip != nil {
 		return ip.To4() == nil
 	}
@@ -67,34 +134,79 @@ func isIPv6(addr string) bool {
 }
 
 func pingArgs(addr string, timeout time.Duration) []string {
-	isIPv6Addr := isIPv6(addr)
+	return pingArgsFor(runtime.GOOS, addr, timeout)
+}
 
-	switch runtime.GOOS {
+// pingArgsFor builds the ping arguments for goos. IPv6 targets get -6 except
+// on macOS, where pingCommand selects ping6 instead.
+func pingArgsFor(goos, addr string, timeout time.Duration) []string {
+	var family []string
+	if goos != "darwin" && isIPv6(addr) {
+		family = []string{"-6"}
+	}
+
+	switch goos {
+	case "windows":
+		// -n is the echo count and -w the reply timeout in milliseconds.
+		timeoutMs := maxInt(1, int(timeout.Milliseconds()))
+		return append(family, "-n", "1", "-w", strconv.Itoa(timeoutMs), addr)
 	case "darwin":
-		if isIPv6Addr {
+		if isIPv6(addr) {
 			// macOS ping6 doesn't support -W option, timeout is handled by context
 			return []string{"-n", "-c", "1", addr}
 		}
 		timeoutMs := maxInt(100, int(timeout.Milliseconds()))
 		return []string{"-n", "-c", "1", "-W", strconv.Itoa(timeoutMs), addr}
+	case "linux":
+		return append(family, "-n", "-c", "1", "-W", linuxWaitSeconds(timeout), addr)
 	default:
 		timeoutSec := maxInt(1, int(timeout.Seconds()+0.5))
-		return []string{"-n", "-c", "1", "-W", strconv.Itoa(timeoutSec), addr}
+		return append(family, "-n", "-c", "1", "-W", strconv.Itoa(timeoutSec), addr)
+	}
+}
+
+// linuxWaitSeconds formats timeout for iputils ping -W. Sub-second timeouts are
+// passed as fractional seconds (e.g. 0.2), which iputils has accepted since
+// 2018; longer ones keep the whole-second form every version understands.
+// Either way Ping's own deadline bounds the wait.
+func linuxWaitSeconds(timeout time.Duration) string {
+	if timeout > 0 && timeout < time.Second {
+		ms := maxInt(1, int(timeout.Milliseconds()))
+		return strconv.FormatFloat(float64(ms)/1000, 'f', -1, 64)
 	}
+	return strconv.Itoa(maxInt(1, int(timeout.Seconds()+0.5)))
 }
 
 func parseRTT(output []byte) time.Duration {
 	matches := timePattern.FindSubmatch(output)
-	if len(matches) < 2 {
+	if len(matches) < 3 {
 		return 0
 	}
-	value, err := strconv.ParseFloat(string(matches[1]), 64)
+	value, err := strconv.ParseFloat(string(matches[2]), 64)
 	if err != nil {
 		return 0
 	}
+	if string(matches[1]) == "<" {
+		// Windows reports replies faster than its 1ms resolution as "time<1ms";
+		// use half the bound rather than the process wall time.
+		value /= 2
+	}
 	return time.Duration(value * float64(time.Millisecond))
 }
 
+// parseTTL extracts the reply TTL (or IPv6 hop limit) from ping output, 0 if absent.
+func parseTTL(output []byte) int {
+	matches := ttlPattern.FindSubmatch(output)
+	if len(matches) < 2 {
+		return 0
+	}
+	value, err := strconv.Atoi(string(matches[1]))
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
 func maxInt(a, b int) int {
 	if a > b {
 		return a
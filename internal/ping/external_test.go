@@ -2,6 +2,8 @@ package ping
 
 import (
 	"context"
+	"errors"
+	"os/exec"
 	"reflect"
 	"runtime"
 	"strconv"
@@ -16,6 +18,8 @@ func TestPingArgs(t *testing.T) {
 
 	var expected []string
 	switch runtime.GOOS {
+	case "windows":
+		expected = []string{"-n", "1", "-w", "1500", "example.com"}
 	case "darwin":
 		timeoutMs := maxInt(100, int(timeout.Milliseconds()))
 		expected = []string{"-n", "-c", "1", "-W", strconv.Itoa(timeoutMs), "example.com"}
@@ -35,13 +39,17 @@ func TestPingArgsMinimumTimeout(t *testing.T) {
 
 	var expectedTimeout string
 	switch runtime.GOOS {
+	case "windows":
+		expectedTimeout = strconv.Itoa(10)
+	case "linux":
+		expectedTimeout = "0.01"
 	case "darwin":
 		expectedTimeout = strconv.Itoa(100)
 	default:
 		expectedTimeout = strconv.Itoa(1)
 	}
 
-	if len(args) < 5 || args[4] != expectedTimeout {
+	if len(args) < 5 || args[len(args)-2] != expectedTimeout {
 		t.Fatalf("expected timeout arg %q, got %v", expectedTimeout, args)
 	}
 }
@@ -61,6 +69,20 @@ func TestParseRTTInvalid(t *testing.T) {
 	}
 }
 
+func TestParseTTL(t *testing.T) {
+	cases := map[string]int{
+		"64 bytes from 8.8.8.8: icmp_seq=1 ttl=58 time=12.5 ms\n": 58,
+		"Reply from 8.8.8.8: bytes=32 time=12ms TTL=117\n":        117,
+		"16 bytes from ::1, icmp_seq=0 hlim=64 time=0.051 ms\n":   64,
+		"no ttl here\n": 0,
+	}
+	for output, want := range cases {
+		if got := parseTTL([]byte(output)); got != want {
+			t.Errorf("parseTTL(%q) = %d, want %d", output, got, want)
+		}
+	}
+}
+
 func TestMaxInt(t *testing.T) {
 	if maxInt(1, 2) != 2 {
 		t.Fatalf("expected maxInt to return 2")
@@ -176,7 +198,98 @@ func TestParseRTTVariousFormats(t *testing.T) {
 	}
 }
 
+func TestParseRTTWindowsFormats(t *testing.T) {
+	testCases := []struct {
+		output   string
+		expected time.Duration
+	}{
+		{"Reply from 8.8.8.8: bytes=32 time=12ms TTL=117\r\n", 12 * time.Millisecond},
+		{"Reply from 127.0.0.1: bytes=32 time<1ms TTL=128\r\n", 500 * time.Microsecond},
+		{"Reply from ::1: time<1ms \r\n", 500 * time.Microsecond},
+		{"Reply from 2001:db8::1: time=35ms \r\n", 35 * time.Millisecond},
+		{"Pinging 10.0.0.1 with 32 bytes of data:\r\nRequest timed out.\r\n", 0},
+		{"Reply from 10.0.0.254: Destination host unreachable.\r\n", 0},
+	}
+
+	for _, tc := range testCases {
+		result := parseRTT([]byte(tc.output))
+		if result != tc.expected {
+			t.Fatalf("parseRTT(%q) = %v, expected %v", tc.output, result, tc.expected)
+		}
+	}
+}
+
+func TestPingArgsWindows(t *testing.T) {
+	testCases := []struct {
+		addr     string
+		timeout  time.Duration
+		expected []string
+	}{
+		{"example.com", 1500 * time.Millisecond, []string{"-n", "1", "-w", "1500", "example.com"}},
+		{"::1", time.Second, []string{"-6", "-n", "1", "-w", "1000", "::1"}},
+		{"127.0.0.1", 0, []string{"-n", "1", "-w", "1", "127.0.0.1"}},
+	}
+
+	for _, tc := range testCases {
+		args := pingArgsFor("windows", tc.addr, tc.timeout)
+		if !reflect.DeepEqual(args, tc.expected) {
+			t.Fatalf("pingArgsFor(windows, %q, %v) = %v, expected %v", tc.addr, tc.timeout, args, tc.expected)
+		}
+	}
+}
+
+func TestPingArgsFamilyFlag(t *testing.T) {
+	testCases := []struct {
+		goos     string
+		addr     string
+		expected []string
+	}{
+		{"linux", "2001:db8::1", []string{"-6", "-n", "-c", "1", "-W", "2", "2001:db8::1"}},
+		{"linux", "192.0.2.1", []string{"-n", "-c", "1", "-W", "2", "192.0.2.1"}},
+		{"freebsd", "fe80::1%eth0", []string{"-6", "-n", "-c", "1", "-W", "2", "fe80::1%eth0"}},
+		// macOS switches to ping6 rather than passing a flag.
+		{"darwin", "2001:db8::1", []string{"-n", "-c", "1", "2001:db8::1"}},
+	}
+
+	for _, tc := range testCases {
+		args := pingArgsFor(tc.goos, tc.addr, 2*time.Second)
+		if !reflect.DeepEqual(args, tc.expected) {
+			t.Fatalf("pingArgsFor(%s, %q) = %v, expected %v", tc.goos, tc.addr, args, tc.expected)
+		}
+	}
+}
+
+func TestLinuxWaitSeconds(t *testing.T) {
+	testCases := []struct {
+		timeout  time.Duration
+		expected string
+	}{
+		{200 * time.Millisecond, "0.2"},
+		{999 * time.Millisecond, "0.999"},
+		{1500 * time.Microsecond, "0.001"},
+		{100 * time.Microsecond, "0.001"},
+		{time.Second, "1"},
+		{1500 * time.Millisecond, "2"},
+		{0, "1"},
+	}
+
+	for _, tc := range testCases {
+		if got := linuxWaitSeconds(tc.timeout); got != tc.expected {
+			t.Fatalf("linuxWaitSeconds(%v) = %q, expected %q", tc.timeout, got, tc.expected)
+		}
+	}
+
+	args := pingArgsFor("linux", "192.0.2.1", 200*time.Millisecond)
+	expected := []string{"-n", "-c", "1", "-W", "0.2", "192.0.2.1"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("expected args %v, got %v", expected, args)
+	}
+}
+
 func TestPingArgsVariousTimeouts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows flags are covered by TestPingArgsWindows")
+	}
 	testCases := []struct {
 		timeout time.Duration
 		addr    string
@@ -299,15 +412,88 @@ func TestPingArgsIPv6(t *testing.T) {
 
 	var expected []string
 	switch runtime.GOOS {
+	case "windows":
+		expected = []string{"-6", "-n", "1", "-w", "1500", "::1"}
 	case "darwin":
 		// macOS ping6 doesn't support -W option, timeout is handled by context
 		expected = []string{"-n", "-c", "1", "::1"}
 	default:
 		timeoutSec := maxInt(1, int(timeout.Seconds()+0.5))
-		expected = []string{"-n", "-c", "1", "-W", strconv.Itoa(timeoutSec), "::1"}
+		expected = []string{"-6", "-n", "-c", "1", "-W", strconv.Itoa(timeoutSec), "::1"}
 	}
 
 	if !reflect.DeepEqual(args, expected) {
 		t.Fatalf("expected args %v, got %v", expected, args)
 	}
 }
+
+func TestExternalPingerSetCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	var gotName string
+	var gotArgs []string
+	original := commandContext
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		gotName, gotArgs = name, args
+		return exec.CommandContext(ctx, "sh", "-c", "echo 'time=1.5 ms'")
+	}
+	defer func() { commandContext = original }()
+
+	pinger := NewExternalPinger()
+	pinger.SetCommand([]string{"busybox", "ping"})
+	result := pinger.Ping(context.Background(), "127.0.0.1", time.Second)
+	if !result.Success {
+		t.Fatalf("expected success, got %+v", result)
+	}
+	want := append([]string{"ping"}, pingArgs("127.0.0.1", time.Second)...)
+	if gotName != "busybox" || !reflect.DeepEqual(gotArgs, want) {
+		t.Fatalf("expected busybox %v, got %s %v", want, gotName, gotArgs)
+	}
+
+	pinger.SetCommand(nil)
+	pinger.Ping(context.Background(), "127.0.0.1", time.Second)
+	if gotName != "ping" || !reflect.DeepEqual(gotArgs, pingArgs("127.0.0.1", time.Second)) {
+		t.Fatalf("expected the default ping command after reset, got %s %v", gotName, gotArgs)
+	}
+}
+
+func TestExternalPingerReportsCancellation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	original := commandContext
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", "sleep 5")
+	}
+	defer func() { commandContext = original }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	result := NewExternalPinger().Ping(ctx, "127.0.0.1", 5*time.Second)
+	if result.Success || !errors.Is(result.Error, context.Canceled) {
+		t.Fatalf("expected a context.Canceled error, got %+v", result)
+	}
+}
+
+func TestExternalPingerKillsCommandAfterDeadline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	// A "ping" that ignores SIGTERM and leaves a child holding its output pipe.
+	original := commandContext
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", "-c", `trap "" TERM; sleep 5`)
+	}
+	defer func() { commandContext = original }()
+
+	start := time.Now()
+	result := NewExternalPinger().Ping(context.Background(), "127.0.0.1", 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected the command to be killed near the deadline, took %s", elapsed)
+	}
+	if result.Success || !errors.Is(result.Error, context.DeadlineExceeded) || !strings.HasPrefix(result.Error.Error(), "ping timeout:") {
+		t.Fatalf("expected a ping timeout error, got %+v", result)
+	}
+}
@@ -29,6 +29,14 @@ func (p *FallbackPinger) Ping(ctx context.Context, addr string, timeout time.Dur
 	return p.secondary.Ping(ctx, addr, timeout)
 }
 
+// ICMPAvailable sends one probe to loopback with p and reports whether it
+// succeeded without a permission error. It is used at startup to skip an ICMP
+// primary that can never work for an unprivileged process.
+func ICMPAvailable(ctx context.Context, p Pinger) bool {
+	result := p.Ping(ctx, "127.0.0.1", time.Second)
+	return result.Success || !isPermissionError(result.Error)
+}
+
 func isPermissionError(err error) bool {
 	if err == nil {
 		return false
@@ -1,10 +1,12 @@
 package ping
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net"
 	"os"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -15,24 +17,83 @@ import (
 
 const echoData = "surveiller"
 
+// MaxPayloadSize is the largest echo payload that fits in an IPv4 datagram.
+const MaxPayloadSize = 65535 - 20 - 8
+
+// replyOverhead leaves room for the ICMP header and the largest IPv4 header in
+// the read buffer, so that a reply carrying the full payload is never truncated.
+const (
+	replyOverhead  = 8 + 60
+	minReplyBuffer = 1500
+)
+
 // ICMPPinger sends ICMP echo requests using raw sockets.
 type ICMPPinger struct {
 	id  int
 	seq uint32
+
+	mu   sync.RWMutex
+	data []byte
+	bufs *sync.Pool
 }
 
 // NewICMPPinger initializes a pinger with a process-scoped identifier.
 func NewICMPPinger() (*ICMPPinger, error) {
-	return &ICMPPinger{id: os.Getpid() & 0xffff}, nil
+	p := &ICMPPinger{id: os.Getpid() & 0xffff}
+	if err := p.SetPayloadSize(0); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetPayloadSize sets the number of data bytes carried by each echo request.
+// Zero restores the default payload. The reply buffer is resized to match and
+// reused across probes.
+func (p *ICMPPinger) SetPayloadSize(n int) error {
+	if n < 0 || n > MaxPayloadSize {
+		return fmt.Errorf("invalid payload size %d: must be between 0 and %d", n, MaxPayloadSize)
+	}
+	data := []byte(echoData)
+	if n > 0 {
+		data = bytes.Repeat([]byte(echoData), n/len(echoData)+1)[:n]
+	}
+	size := len(data) + replyOverhead
+	if size < minReplyBuffer {
+		size = minReplyBuffer
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.bufs != nil && len(p.data) == len(data) {
+		return nil
+	}
+	p.data = data
+	p.bufs = &sync.Pool{New: func() interface{} {
+		buf := make([]byte, size)
+		return &buf
+	}}
+	return nil
+}
+
+func (p *ICMPPinger) payload() ([]byte, *sync.Pool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.data, p.bufs
 }
 
 // Ping sends one ICMP echo request and waits for the reply.
 func (p *ICMPPinger) Ping(ctx context.Context, addr string, timeout time.Duration) Result {
+	result := p.ping(ctx, addr, timeout)
+	result.Backend = BackendICMP
+	return result
+}
+
+func (p *ICMPPinger) ping(ctx context.Context, addr string, timeout time.Duration) Result {
 	if err := ctx.Err(); err != nil {
 		return Result{Success: false, Error: err}
 	}
 
-	ip, ipNet, err := resolveIP(addr)
+	ip, ipNet, err := resolveIP(ctx, addr, timeout)
 	if err != nil {
 		return Result{Success: false, Error: err}
 	}
@@ -43,7 +104,13 @@ func (p *ICMPPinger) Ping(ctx context.Context, addr string, timeout time.Duratio
 		return Result{Success: false, Error: err}
 	}
 	defer conn.Close()
+	if dscp, ok := DSCPFromContext(ctx); ok {
+		if err := setDSCP(conn, ipNet, dscp); err != nil {
+			return Result{Success: false, Error: err}
+		}
+	}
 
+	data, bufs := p.payload()
 	seq := int(atomic.AddUint32(&p.seq, 1))
 	msg := icmp.Message{
 		Type: requestType,
@@ -51,7 +118,7 @@ func (p *ICMPPinger) Ping(ctx context.Context, addr string, timeout time.Duratio
 		Body: &icmp.Echo{
 			ID:   p.id,
 			Seq:  seq,
-			Data: []byte(echoData),
+			Data: data,
 		},
 	}
 
@@ -70,13 +137,16 @@ func (p *ICMPPinger) Ping(ctx context.Context, addr string, timeout time.Duratio
 		return Result{Success: false, Error: err}
 	}
 
-	buf := make([]byte, 1500)
+	read := newReplyReader(conn, ipNet)
+	bufp := bufs.Get().(*[]byte)
+	defer bufs.Put(bufp)
+	buf := *bufp
 	for {
 		if err := ctx.Err(); err != nil {
 			return Result{Success: false, Error: err}
 		}
 
-		n, peer, err := conn.ReadFrom(buf)
+		n, peer, ttl, err := read(buf)
 		if err != nil {
 			// Handle timeout errors appropriately
 			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
@@ -102,20 +172,92 @@ func (p *ICMPPinger) Ping(ctx context.Context, addr string, timeout time.Duratio
 		if body.ID != p.id || body.Seq != seq {
 			continue
 		}
+		if !bytes.Equal(body.Data, data) {
+			return Result{Success: false, Error: fmt.Errorf("echo reply payload mismatch: got %d bytes, want %d", len(body.Data), len(data))}
+		}
 
-		return Result{Success: true, RTT: time.Since(start)}
+		return Result{Success: true, RTT: time.Since(start), TTL: ttl}
 	}
 }
 
-func resolveIP(addr string) (*net.IPAddr, net.IP, error) {
-	ipAddr, err := net.ResolveIPAddr("ip", addr)
+// setDSCP marks outgoing echo requests with dscp through the typed IPv4 or
+// IPv6 connection; the DSCP occupies the upper six bits of the ToS / Traffic
+// Class byte.
+func setDSCP(conn *icmp.PacketConn, ip net.IP, dscp int) error {
+	var err error
+	if ip.To4() != nil {
+		pc := conn.IPv4PacketConn()
+		if pc == nil {
+			return fmt.Errorf("set dscp %d: not an IPv4 connection", dscp)
+		}
+		err = pc.SetTOS(dscp << 2)
+	} else {
+		pc := conn.IPv6PacketConn()
+		if pc == nil {
+			return fmt.Errorf("set dscp %d: not an IPv6 connection", dscp)
+		}
+		err = pc.SetTrafficClass(dscp << 2)
+	}
+	if err != nil {
+		return fmt.Errorf("set dscp %d: %w", dscp, err)
+	}
+	return nil
+}
+
+// replyReader reads one ICMP message and reports the TTL (hop limit) it arrived with.
+type replyReader func(b []byte) (n int, peer net.Addr, ttl int, err error)
+
+// newReplyReader enables TTL/hop limit control messages on conn. If the platform
+// refuses them it falls back to plain reads that report a TTL of 0.
+func newReplyReader(conn *icmp.PacketConn, ip net.IP) replyReader {
+	if ip.To4() != nil {
+		if pc := conn.IPv4PacketConn(); pc != nil && pc.SetControlMessage(ipv4.FlagTTL, true) == nil {
+			return func(b []byte) (int, net.Addr, int, error) {
+				n, cm, peer, err := pc.ReadFrom(b)
+				if err != nil || cm == nil {
+					return n, peer, 0, err
+				}
+				return n, peer, cm.TTL, nil
+			}
+		}
+	} else if pc := conn.IPv6PacketConn(); pc != nil && pc.SetControlMessage(ipv6.FlagHopLimit, true) == nil {
+		return func(b []byte) (int, net.Addr, int, error) {
+			n, cm, peer, err := pc.ReadFrom(b)
+			if err != nil || cm == nil {
+				return n, peer, 0, err
+			}
+			return n, peer, cm.HopLimit, nil
+		}
+	}
+	return func(b []byte) (int, net.Addr, int, error) {
+		n, peer, err := conn.ReadFrom(b)
+		return n, peer, 0, err
+	}
+}
+
+// lookupIPAddr resolves hostnames; tests replace it to simulate slow DNS.
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// resolveIP resolves addr within the probe deadline, preferring IPv4. A lookup
+// that cannot finish in time fails the probe rather than delaying its result,
+// so unresolvable targets are reported as failures every interval. Results
+// are reused from resolveCache while SetResolveCacheTTL is in effect.
+func resolveIP(ctx context.Context, addr string, timeout time.Duration) (*net.IPAddr, net.IP, error) {
+	lookupCtx, cancel := context.WithDeadline(ctx, effectiveDeadline(ctx, timeout))
+	defer cancel()
+	addrs, err := resolveCache.lookup(lookupCtx, addr, lookupIPAddr, time.Now())
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, fmt.Errorf("resolve %s: %w", addr, err)
 	}
-	if ipAddr.IP == nil {
+	if len(addrs) == 0 || addrs[0].IP == nil {
 		return nil, nil, fmt.Errorf("invalid IP address: %s", addr)
 	}
-	return ipAddr, ipAddr.IP, nil
+	for i := range addrs {
+		if addrs[i].IP.To4() != nil {
+			return &addrs[i], addrs[i].IP, nil
+		}
+	}
+	return &addrs[0], addrs[0].IP, nil
 }
 
 func icmpSettings(ip net.IP) (network string, protocol int, requestType icmp.Type, replyType icmp.Type) {
@@ -22,7 +22,7 @@ func (s *stubPinger) Ping(ctx context.Context, addr string, timeout time.Duratio
 }
 
 func TestResolveIPValid(t *testing.T) {
-	ipAddr, ip, err := resolveIP("127.0.0.1")
+	ipAddr, ip, err := resolveIP(context.Background(), "127.0.0.1", time.Second)
 	if err != nil {
 		t.Fatalf("expected valid IP, got error: %v", err)
 	}
@@ -35,12 +35,35 @@ func TestResolveIPValid(t *testing.T) {
 }
 
 func TestResolveIPInvalid(t *testing.T) {
-	_, _, err := resolveIP("invalid@@")
+	_, _, err := resolveIP(context.Background(), "invalid@@", time.Second)
 	if err == nil {
 		t.Fatalf("expected error for invalid address")
 	}
 }
 
+func TestResolveIPHonoursTimeout(t *testing.T) {
+	original := lookupIPAddr
+	defer func() { lookupIPAddr = original }()
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		<-ctx.Done() // a DNS server that never answers
+		return nil, ctx.Err()
+	}
+
+	start := time.Now()
+	_, _, err := resolveIP(context.Background(), "unanswered.example", 20*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected lookup to stop at the probe timeout, took %s", elapsed)
+	}
+
+	result := (&ICMPPinger{}).Ping(context.Background(), "unanswered.example", 20*time.Millisecond)
+	if result.Success || result.Error == nil {
+		t.Fatalf("expected a failed result for an unresolvable target, got %+v", result)
+	}
+}
+
 func TestICMPSettings(t *testing.T) {
 	ipv4 := net.ParseIP("127.0.0.1")
 	network, _, _, _ := icmpSettings(ipv4)
@@ -97,6 +120,18 @@ func TestIsPermissionError(t *testing.T) {
 	}
 }
 
+func TestICMPAvailable(t *testing.T) {
+	if !ICMPAvailable(context.Background(), &stubPinger{result: Result{Success: true}}) {
+		t.Fatalf("expected successful probe to report ICMP available")
+	}
+	if ICMPAvailable(context.Background(), &stubPinger{result: Result{Success: false, Error: os.ErrPermission}}) {
+		t.Fatalf("expected permission error to report ICMP unavailable")
+	}
+	if !ICMPAvailable(context.Background(), &stubPinger{result: Result{Success: false, Error: errors.New("timeout")}}) {
+		t.Fatalf("expected non-permission failure to keep ICMP")
+	}
+}
+
 func TestFallbackPingerUsesPrimaryOnSuccess(t *testing.T) {
 	primary := &stubPinger{result: Result{Success: true}}
 	secondary := &stubPinger{result: Result{Success: true}}
@@ -125,6 +160,27 @@ func TestFallbackPingerFallsBackOnPermissionError(t *testing.T) {
 	}
 }
 
+func TestFallbackPingerReportsSecondaryBackend(t *testing.T) {
+	primary := &stubPinger{result: Result{Success: false, Error: os.ErrPermission, Backend: BackendICMP}}
+	secondary := &stubPinger{result: Result{Success: true, Backend: BackendExternal}}
+	pinger := NewFallbackPinger(primary, secondary)
+
+	result := pinger.Ping(context.Background(), "127.0.0.1", time.Second)
+	if result.Backend != BackendExternal {
+		t.Fatalf("expected backend %q after fallback, got %q", BackendExternal, result.Backend)
+	}
+}
+
+func TestICMPPingerReportsBackendOnFailure(t *testing.T) {
+	result := (&ICMPPinger{}).Ping(context.Background(), "invalid..host", 100*time.Millisecond)
+	if result.Success {
+		t.Fatalf("expected failure for an unresolvable address")
+	}
+	if result.Backend != BackendICMP {
+		t.Fatalf("expected backend %q, got %q", BackendICMP, result.Backend)
+	}
+}
+
 func TestFallbackPingerSkipsFallbackOnOtherErrors(t *testing.T) {
 	primary := &stubPinger{result: Result{Success: false, Error: errors.New("network down")}}
 	secondary := &stubPinger{result: Result{Success: true}}
@@ -154,6 +210,56 @@ func TestNewICMPPinger(t *testing.T) {
 	}
 }
 
+func TestICMPPingerSetPayloadSize(t *testing.T) {
+	pinger, err := NewICMPPinger()
+	if err != nil {
+		t.Fatalf("NewICMPPinger error: %v", err)
+	}
+	if string(pinger.data) != echoData {
+		t.Fatalf("expected default payload %q, got %q", echoData, pinger.data)
+	}
+	if buf := pinger.bufs.Get().(*[]byte); len(*buf) != minReplyBuffer {
+		t.Fatalf("expected default reply buffer of %d bytes, got %d", minReplyBuffer, len(*buf))
+	}
+
+	if err := pinger.SetPayloadSize(9000); err != nil {
+		t.Fatalf("SetPayloadSize error: %v", err)
+	}
+	if len(pinger.data) != 9000 {
+		t.Fatalf("expected 9000-byte payload, got %d", len(pinger.data))
+	}
+	if buf := pinger.bufs.Get().(*[]byte); len(*buf) != 9000+replyOverhead {
+		t.Fatalf("expected reply buffer of %d bytes, got %d", 9000+replyOverhead, len(*buf))
+	}
+
+	for _, n := range []int{-1, MaxPayloadSize + 1} {
+		if err := pinger.SetPayloadSize(n); err == nil {
+			t.Fatalf("expected error for payload size %d", n)
+		}
+	}
+}
+
+func TestICMPPingerLargePayloadLoopback(t *testing.T) {
+	pinger, err := NewICMPPinger()
+	if err != nil {
+		t.Skipf("skipping ICMP test: %v", err)
+	}
+
+	// Sizes just above an Ethernet MTU, a jumbo frame and the IPv4 maximum.
+	for _, size := range []int{1473, 8000, MaxPayloadSize} {
+		if err := pinger.SetPayloadSize(size); err != nil {
+			t.Fatalf("SetPayloadSize(%d) error: %v", size, err)
+		}
+		result := pinger.Ping(context.Background(), "127.0.0.1", time.Second)
+		if result.Error != nil && isPermissionError(result.Error) {
+			t.Skipf("skipping ICMP test: %v", result.Error)
+		}
+		if !result.Success {
+			t.Fatalf("expected %d-byte echo to loopback to succeed untruncated, got %v", size, result.Error)
+		}
+	}
+}
+
 func TestICMPPingerContextCancellation(t *testing.T) {
 	pinger, err := NewICMPPinger()
 	if err != nil {
@@ -213,6 +319,9 @@ func TestICMPPingerIPv4Address(t *testing.T) {
 			t.Logf("IPv4 ping failed (may be expected): %v", result.Error)
 		}
 	}
+	if result.Success && result.TTL <= 0 {
+		t.Logf("IPv4 reply did not report a TTL (control messages may be unsupported)")
+	}
 }
 
 func TestICMPPingerIPv6Address(t *testing.T) {
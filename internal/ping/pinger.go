@@ -5,11 +5,24 @@ import (
 	"time"
 )
 
+// Backends reported in Result.Backend.
+const (
+	BackendICMP     = "icmp"
+	BackendExternal = "external"
+)
+
 // Result captures a single ping result.
 type Result struct {
 	RTT     time.Duration
 	Success bool
 	Error   error
+	// TTL is the IPv4 TTL or IPv6 hop limit of the reply; 0 when unknown.
+	TTL int
+	// Backend is the pinger that produced the result, e.g. BackendExternal
+	// after FallbackPinger gave up on raw ICMP; empty when unknown.
+	Backend string
+	// Phases is the step breakdown of RTT reported by SyntheticPinger.
+	Phases Phases
 }
 
 // Pinger sends a single ping and returns the result.
@@ -0,0 +1,108 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxNegativeCacheTTL caps how long a name that does not resolve is cached,
+// so a newly added DNS record is picked up quickly.
+const maxNegativeCacheTTL = 5 * time.Second
+
+// resolveCache is the cache resolveIP reads through. It is shared by all
+// pingers so targets probing the same hostname cause one lookup per TTL.
+var resolveCache = &resolverCache{}
+
+// SetResolveCacheTTL sets how long resolved addresses are reused by the ICMP
+// and external pingers. Names that do not exist are cached for the shorter of
+// ttl and 5s. ttl <= 0 disables the cache and drops its entries.
+func SetResolveCacheTTL(ttl time.Duration) {
+	resolveCache.setTTL(ttl)
+}
+
+type resolverCacheEntry struct {
+	addrs   []net.IPAddr
+	err     error
+	expires time.Time
+}
+
+// resolverCache is a concurrency-safe, read-through cache of lookups. Lookups
+// themselves run outside the lock, so a slow name never blocks the others.
+type resolverCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]resolverCacheEntry
+	nextSweep time.Time
+}
+
+func (c *resolverCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ttl != c.ttl {
+		c.entries = nil
+	}
+	c.ttl = ttl
+}
+
+// lookup returns the cached result for host, or calls lookup and caches its
+// result: addresses for the TTL, a "not found" error for the negative TTL.
+// Other errors, such as timeouts, are never cached. IP literals bypass the cache.
+func (c *resolverCache) lookup(ctx context.Context, host string, lookup func(context.Context, string) ([]net.IPAddr, error), now time.Time) ([]net.IPAddr, error) {
+	c.mu.Lock()
+	ttl := c.ttl
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ttl <= 0 || isIPLiteral(host) {
+		return lookup(ctx, host)
+	}
+	if ok && now.Before(entry.expires) {
+		return append([]net.IPAddr(nil), entry.addrs...), entry.err
+	}
+
+	addrs, err := lookup(ctx, host)
+	var dnsErr *net.DNSError
+	switch {
+	case err == nil:
+		entry = resolverCacheEntry{addrs: addrs, expires: now.Add(ttl)}
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		entry = resolverCacheEntry{err: err, expires: now.Add(min(ttl, maxNegativeCacheTTL))}
+	default:
+		return addrs, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl != ttl {
+		// The TTL changed during the lookup; the entry belongs to the old cache.
+		return append([]net.IPAddr(nil), addrs...), err
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]resolverCacheEntry)
+	}
+	c.entries[host] = entry
+	c.sweep(now)
+	return append([]net.IPAddr(nil), addrs...), err
+}
+
+// sweep drops expired entries, at most once per TTL, so names of removed
+// targets do not accumulate. Callers must hold c.mu.
+func (c *resolverCache) sweep(now time.Time) {
+	if now.Before(c.nextSweep) {
+		return
+	}
+	for host, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, host)
+		}
+	}
+	c.nextSweep = now.Add(c.ttl)
+}
+
+func isIPLiteral(host string) bool {
+	host, _, _ = strings.Cut(host, "%") // link-local zone, e.g. fe80::1%eth0
+	return net.ParseIP(host) != nil
+}
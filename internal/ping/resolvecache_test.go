@@ -0,0 +1,135 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingLookup resolves every name to 192.0.2.1, or fails with err, and
+// counts the calls per name.
+type countingLookup struct {
+	mu    sync.Mutex
+	calls map[string]int
+	err   error
+}
+
+func (l *countingLookup) lookup(ctx context.Context, host string) ([]net.IPAddr, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.calls == nil {
+		l.calls = make(map[string]int)
+	}
+	l.calls[host]++
+	if l.err != nil {
+		return nil, l.err
+	}
+	return []net.IPAddr{{IP: net.ParseIP("192.0.2.1")}}, nil
+}
+
+func TestResolverCacheReusesUntilTTL(t *testing.T) {
+	c := &resolverCache{}
+	c.setTTL(time.Minute)
+	l := &countingLookup{}
+	now := time.Now()
+
+	for _, at := range []time.Duration{0, 30 * time.Second, 59 * time.Second} {
+		addrs, err := c.lookup(context.Background(), "example.com", l.lookup, now.Add(at))
+		if err != nil || len(addrs) != 1 || !addrs[0].IP.Equal(net.ParseIP("192.0.2.1")) {
+			t.Fatalf("unexpected result %v, %v", addrs, err)
+		}
+	}
+	if l.calls["example.com"] != 1 {
+		t.Fatalf("expected one lookup within the TTL, got %d", l.calls["example.com"])
+	}
+
+	c.lookup(context.Background(), "example.com", l.lookup, now.Add(time.Minute))
+	if l.calls["example.com"] != 2 {
+		t.Fatalf("expected a new lookup after the TTL, got %d", l.calls["example.com"])
+	}
+}
+
+func TestResolverCacheNegativeTTL(t *testing.T) {
+	c := &resolverCache{}
+	c.setTTL(time.Minute)
+	l := &countingLookup{err: &net.DNSError{Err: "no such host", Name: "missing.example", IsNotFound: true}}
+	now := time.Now()
+
+	for _, at := range []time.Duration{0, 4 * time.Second} {
+		if _, err := c.lookup(context.Background(), "missing.example", l.lookup, now.Add(at)); err == nil {
+			t.Fatalf("expected the cached lookup error")
+		}
+	}
+	if l.calls["missing.example"] != 1 {
+		t.Fatalf("expected the failure to be cached, got %d lookups", l.calls["missing.example"])
+	}
+	c.lookup(context.Background(), "missing.example", l.lookup, now.Add(maxNegativeCacheTTL))
+	if l.calls["missing.example"] != 2 {
+		t.Fatalf("expected the failure to expire after %s, got %d lookups", maxNegativeCacheTTL, l.calls["missing.example"])
+	}
+}
+
+func TestResolverCacheSkipsTransientErrorsAndLiterals(t *testing.T) {
+	c := &resolverCache{}
+	c.setTTL(time.Minute)
+	l := &countingLookup{err: errors.New("i/o timeout")}
+	now := time.Now()
+
+	c.lookup(context.Background(), "slow.example", l.lookup, now)
+	c.lookup(context.Background(), "slow.example", l.lookup, now)
+	if l.calls["slow.example"] != 2 {
+		t.Fatalf("expected transient errors not to be cached, got %d lookups", l.calls["slow.example"])
+	}
+
+	l.err = nil
+	c.lookup(context.Background(), "192.0.2.7", l.lookup, now)
+	c.lookup(context.Background(), "192.0.2.7", l.lookup, now)
+	if l.calls["192.0.2.7"] != 2 || len(c.entries) != 0 {
+		t.Fatalf("expected IP literals to bypass the cache, got %d lookups and %d entries", l.calls["192.0.2.7"], len(c.entries))
+	}
+}
+
+func TestResolverCacheDisabledAndTTLChange(t *testing.T) {
+	c := &resolverCache{}
+	l := &countingLookup{}
+	now := time.Now()
+
+	c.lookup(context.Background(), "example.com", l.lookup, now)
+	c.lookup(context.Background(), "example.com", l.lookup, now)
+	if l.calls["example.com"] != 2 {
+		t.Fatalf("expected no caching without a TTL, got %d lookups", l.calls["example.com"])
+	}
+
+	c.setTTL(time.Minute)
+	c.lookup(context.Background(), "example.com", l.lookup, now)
+	c.setTTL(time.Hour)
+	c.lookup(context.Background(), "example.com", l.lookup, now)
+	if l.calls["example.com"] != 4 {
+		t.Fatalf("expected a TTL change to drop cached entries, got %d lookups", l.calls["example.com"])
+	}
+}
+
+func TestResolverCacheConcurrentLookups(t *testing.T) {
+	c := &resolverCache{}
+	c.setTTL(time.Minute)
+	l := &countingLookup{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			host := []string{"a.example", "b.example"}[i%2]
+			if _, err := c.lookup(context.Background(), host, l.lookup, time.Now()); err != nil {
+				t.Errorf("lookup %s: %v", host, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(c.entries) != 2 {
+		t.Fatalf("expected two cached names, got %d", len(c.entries))
+	}
+}
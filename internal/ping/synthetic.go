@@ -0,0 +1,124 @@
+package ping
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// BackendSynthetic is reported in Result.Backend by SyntheticPinger.
+const BackendSynthetic = "synthetic"
+
+// Default ports of synthetic probes whose address has no port.
+const (
+	SyntheticDefaultPort    = "80"
+	SyntheticDefaultTLSPort = "443"
+)
+
+// Phases breaks the RTT of a synthetic probe down by step. A phase that was
+// skipped or not reached is zero.
+type Phases struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+}
+
+// SyntheticPinger times a DNS lookup, a TCP connect and, with TLS, a TLS
+// handshake against "host", "host:port" or "[v6]:port", like a client opening
+// a connection would. RTT is the sum of the phases.
+type SyntheticPinger struct {
+	// TLS adds a handshake after the connect; the certificate is verified.
+	TLS bool
+	// TLSConfig is cloned for each handshake; nil uses the system roots.
+	TLSConfig *tls.Config
+	// Resolver is used for the DNS phase; nil uses net.DefaultResolver.
+	Resolver *net.Resolver
+}
+
+// NewSyntheticPinger returns a synthetic pinger, with a TLS phase if withTLS.
+func NewSyntheticPinger(withTLS bool) *SyntheticPinger {
+	return &SyntheticPinger{TLS: withTLS}
+}
+
+// Ping runs the phases in order and stops at the first failure; the phases
+// completed by then are still reported.
+func (p *SyntheticPinger) Ping(ctx context.Context, addr string, timeout time.Duration) Result {
+	result := Result{Backend: BackendSynthetic}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	fail := func(phase string, err error) Result {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("ping timeout: %w", ctx.Err())
+		}
+		result.RTT = result.Phases.DNS + result.Phases.Connect + result.Phases.TLS
+		result.Error = fmt.Errorf("synthetic %s: %w", phase, err)
+		return result
+	}
+
+	host, port := p.splitAddress(addr)
+	ip := host
+	if net.ParseIP(host) == nil {
+		resolver := p.Resolver
+		if resolver == nil {
+			resolver = net.DefaultResolver
+		}
+		start := time.Now()
+		addrs, err := resolver.LookupIPAddr(ctx, host)
+		result.Phases.DNS = time.Since(start)
+		if err != nil {
+			return fail("dns", err)
+		}
+		if len(addrs) == 0 {
+			return fail("dns", fmt.Errorf("no addresses for %q", host))
+		}
+		ip = addrs[0].String()
+	}
+
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, port))
+	result.Phases.Connect = time.Since(start)
+	if err != nil {
+		return fail("connect", err)
+	}
+	defer conn.Close()
+
+	if p.TLS {
+		cfg := &tls.Config{}
+		if p.TLSConfig != nil {
+			cfg = p.TLSConfig.Clone()
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = host
+		}
+		tlsConn := tls.Client(conn, cfg)
+		start = time.Now()
+		err := tlsConn.HandshakeContext(ctx)
+		result.Phases.TLS = time.Since(start)
+		if err != nil {
+			return fail("tls", err)
+		}
+	}
+
+	result.RTT = result.Phases.DNS + result.Phases.Connect + result.Phases.TLS
+	result.Success = true
+	return result
+}
+
+// splitAddress returns the host and port of addr, using the default port of
+// the probe when addr has none.
+func (p *SyntheticPinger) splitAddress(addr string) (string, string) {
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		return host, port
+	}
+	if p.TLS {
+		return addr, SyntheticDefaultTLSPort
+	}
+	return addr, SyntheticDefaultPort
+}
@@ -0,0 +1,123 @@
+package ping
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyntheticPingerConnects(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	result := NewSyntheticPinger(false).Ping(context.Background(), listener.Addr().String(), time.Second)
+	if !result.Success || result.Error != nil {
+		t.Fatalf("expected success, got %+v", result)
+	}
+	if result.Backend != BackendSynthetic {
+		t.Fatalf("expected backend %q, got %q", BackendSynthetic, result.Backend)
+	}
+	if result.Phases.DNS != 0 || result.Phases.TLS != 0 || result.Phases.Connect <= 0 {
+		t.Fatalf("expected only a connect phase for an IP literal, got %+v", result.Phases)
+	}
+	if result.RTT != result.Phases.Connect {
+		t.Fatalf("expected RTT to equal the phase sum, got %v for %+v", result.RTT, result.Phases)
+	}
+}
+
+func TestSyntheticPingerTLSHandshake(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	// The unverified handshake below is expected to be rejected; keep it quiet.
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	pinger := NewSyntheticPinger(true)
+	pinger.TLSConfig = &tls.Config{RootCAs: roots}
+	result := pinger.Ping(context.Background(), addr, time.Second)
+	if !result.Success || result.Phases.Connect <= 0 || result.Phases.TLS <= 0 {
+		t.Fatalf("expected connect and TLS phases, got %+v", result)
+	}
+	if result.RTT != result.Phases.Connect+result.Phases.TLS {
+		t.Fatalf("expected RTT to equal the phase sum, got %v for %+v", result.RTT, result.Phases)
+	}
+
+	// Without the test CA the certificate cannot be verified.
+	result = NewSyntheticPinger(true).Ping(context.Background(), addr, time.Second)
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "synthetic tls") {
+		t.Fatalf("expected TLS failure, got %+v", result)
+	}
+	if result.Phases.Connect <= 0 {
+		t.Fatalf("expected the completed connect phase to be kept, got %+v", result.Phases)
+	}
+}
+
+func TestSyntheticPingerReportsFailedPhase(t *testing.T) {
+	pinger := NewSyntheticPinger(false)
+	pinger.Resolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, errors.New("no DNS server")
+		},
+	}
+	result := pinger.Ping(context.Background(), "unreachable.invalid", time.Second)
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "synthetic dns") {
+		t.Fatalf("expected DNS failure, got %+v", result)
+	}
+
+	// A port nothing listens on anymore.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	result = NewSyntheticPinger(false).Ping(context.Background(), addr, time.Second)
+	if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "synthetic connect") {
+		t.Fatalf("expected connect failure, got %+v", result)
+	}
+}
+
+func TestSyntheticPingerDefaultPorts(t *testing.T) {
+	tests := []struct {
+		addr       string
+		tls        bool
+		host, port string
+	}{
+		{"example.com", false, "example.com", "80"},
+		{"example.com", true, "example.com", "443"},
+		{"example.com:8080", true, "example.com", "8080"},
+		{"2001:db8::1", false, "2001:db8::1", "80"},
+		{"[2001:db8::1]:8443", true, "2001:db8::1", "8443"},
+	}
+	for _, tt := range tests {
+		host, port := NewSyntheticPinger(tt.tls).splitAddress(tt.addr)
+		if host != tt.host || port != tt.port {
+			t.Errorf("splitAddress(%q, tls=%v) = %q, %q; want %q, %q", tt.addr, tt.tls, host, port, tt.host, tt.port)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/doridoridoriand/surveiller/internal/ping"
+	"github.com/doridoridoriand/surveiller/internal/state"
+)
+
+// idleFlushInterval is how often the flusher checks for leftover results while
+// batching is disabled, e.g. after a reload turned it off.
+const idleFlushInterval = time.Second
+
+// batchUpdater is implemented by stores that can apply many results at once.
+type batchUpdater interface {
+	UpdateResults(updates []state.ResultUpdate)
+}
+
+// resultBatch accumulates probe results between flushes.
+type resultBatch struct {
+	mu      sync.Mutex
+	pending []state.ResultUpdate
+}
+
+func (b *resultBatch) add(update state.ResultUpdate) {
+	b.mu.Lock()
+	b.pending = append(b.pending, update)
+	b.mu.Unlock()
+}
+
+func (b *resultBatch) take() []state.ResultUpdate {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	pending := b.pending
+	b.pending = nil
+	return pending
+}
+
+// record hands a probe result to the store, either directly or, with
+// result_batch_interval, via the batch flushed by runBatchFlusher.
+func (s *Impl) record(name string, result ping.Result) {
+	if _, ok := s.state.(batchUpdater); ok && s.currentBatchInterval() > 0 {
+		s.batch.add(state.ResultUpdate{Name: name, Result: result, At: time.Now()})
+		return
+	}
+	s.state.UpdateResult(name, result)
+}
+
+// runBatchFlusher applies batched results every result_batch_interval until ctx
+// is done. It re-reads the interval after each flush so that reloads apply.
+func (s *Impl) runBatchFlusher(ctx context.Context) {
+	for {
+		interval := s.currentBatchInterval()
+		if interval <= 0 {
+			interval = idleFlushInterval
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.flushResults()
+	}
+}
+
+func (s *Impl) flushResults() {
+	updater, ok := s.state.(batchUpdater)
+	if !ok {
+		return
+	}
+	if pending := s.batch.take(); len(pending) > 0 {
+		updater.UpdateResults(pending)
+	}
+}
+
+func (s *Impl) currentBatchInterval() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.ResultBatchInterval
+}
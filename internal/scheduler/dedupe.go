@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/doridoridoriand/surveiller/internal/config"
+	"github.com/doridoridoriand/surveiller/internal/ping"
+)
+
+// sharedProbe is one probe whose result may be handed to several targets.
+type sharedProbe struct {
+	done   chan struct{}
+	result ping.Result
+	ok     bool
+	at     time.Time
+}
+
+// probeGroup coalesces probes to the same address when dedupe_by_address is
+// enabled: a target joins an in-flight probe or reuses a result that is still
+// fresh instead of sending its own.
+type probeGroup struct {
+	mu     sync.Mutex
+	probes map[string]*sharedProbe
+}
+
+func newProbeGroup() *probeGroup {
+	return &probeGroup{probes: make(map[string]*sharedProbe)}
+}
+
+// do returns the result for key, running fn only when no probe is in flight and
+// the last result is older than fresh. ok is false when fn (or waiting for it)
+// was abandoned because ctx ended.
+func (g *probeGroup) do(ctx context.Context, key string, fresh time.Duration, fn func() (ping.Result, bool)) (ping.Result, bool) {
+	for {
+		g.mu.Lock()
+		probe, exists := g.probes[key]
+		if exists {
+			select {
+			case <-probe.done:
+				if probe.ok && time.Since(probe.at) < fresh {
+					g.mu.Unlock()
+					return probe.result, true
+				}
+				exists = false
+			default:
+			}
+		}
+		if !exists {
+			probe = &sharedProbe{done: make(chan struct{})}
+			g.probes[key] = probe
+			g.mu.Unlock()
+
+			probe.result, probe.ok = fn()
+			probe.at = time.Now()
+			close(probe.done)
+			return probe.result, probe.ok
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ping.Result{}, false
+		case <-probe.done:
+		}
+		if probe.ok {
+			return probe.result, true
+		}
+		// The probe was abandoned by its owner; run our own.
+	}
+}
+
+// forget drops cached results for addresses no longer in use.
+func (g *probeGroup) forget(keep map[string]bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for key, probe := range g.probes {
+		if keep[key] {
+			continue
+		}
+		select {
+		case <-probe.done:
+			delete(g.probes, key)
+		default:
+		}
+	}
+}
+
+// probeKey identifies the probes dedupe_by_address may share: targets at the
+// same address that are probed the same way, with the same DSCP marking.
+func probeKey(target config.TargetConfig) string {
+	switch {
+	case target.Probe != config.ProbeSynthetic && target.DSCP > 0:
+		return fmt.Sprintf("dscp=%d %s", target.DSCP, target.Address)
+	case target.Probe != config.ProbeSynthetic:
+		return target.Address
+	case target.TLS:
+		return "synthetic+tls " + target.Address
+	default:
+		return "synthetic " + target.Address
+	}
+}
@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/doridoridoriand/surveiller/internal/config"
+	"github.com/doridoridoriand/surveiller/internal/ping"
+)
+
+func TestProbeGroupCoalescesInFlightProbe(t *testing.T) {
+	g := newProbeGroup()
+	release := make(chan struct{})
+	var calls int32
+	fn := func() (ping.Result, bool) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return ping.Result{Success: true, RTT: 3 * time.Millisecond}, true
+	}
+
+	var wg sync.WaitGroup
+	results := make([]ping.Result, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = g.do(context.Background(), "192.0.2.1", time.Second, fn)
+		}(i)
+	}
+	// Give every caller time to join before the probe completes.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("expected a single probe, got %d", n)
+	}
+	for i, result := range results {
+		if !result.Success || result.RTT != 3*time.Millisecond {
+			t.Fatalf("caller %d: expected shared result, got %+v", i, result)
+		}
+	}
+}
+
+func TestProbeKeySeparatesProbeTypes(t *testing.T) {
+	icmp := probeKey(config.TargetConfig{Address: "192.0.2.1"})
+	synthetic := probeKey(config.TargetConfig{Address: "192.0.2.1", Probe: config.ProbeSynthetic})
+	withTLS := probeKey(config.TargetConfig{Address: "192.0.2.1", Probe: config.ProbeSynthetic, TLS: true})
+	marked := probeKey(config.TargetConfig{Address: "192.0.2.1", DSCP: 46})
+	if icmp != "192.0.2.1" || icmp == synthetic || synthetic == withTLS || marked == icmp {
+		t.Fatalf("expected distinct keys, got %q, %q, %q, %q", icmp, synthetic, withTLS, marked)
+	}
+}
+
+func TestProbeGroupReusesOnlyFreshResults(t *testing.T) {
+	g := newProbeGroup()
+	var calls int
+	fn := func() (ping.Result, bool) {
+		calls++
+		return ping.Result{Success: true}, true
+	}
+
+	g.do(context.Background(), "a", time.Hour, fn)
+	g.do(context.Background(), "a", time.Hour, fn)
+	if calls != 1 {
+		t.Fatalf("expected fresh result to be reused, got %d probes", calls)
+	}
+
+	g.do(context.Background(), "a", 0, fn)
+	if calls != 2 {
+		t.Fatalf("expected stale result to trigger a new probe, got %d probes", calls)
+	}
+
+	g.do(context.Background(), "b", time.Hour, fn)
+	if calls != 3 {
+		t.Fatalf("expected a different address to probe separately, got %d probes", calls)
+	}
+}
+
+func TestProbeGroupAbandonedProbeIsNotShared(t *testing.T) {
+	g := newProbeGroup()
+	g.do(context.Background(), "a", time.Hour, func() (ping.Result, bool) {
+		return ping.Result{}, false
+	})
+
+	var calls int
+	result, ok := g.do(context.Background(), "a", time.Hour, func() (ping.Result, bool) {
+		calls++
+		return ping.Result{Success: true}, true
+	})
+	if calls != 1 || !ok || !result.Success {
+		t.Fatalf("expected an abandoned probe to be retried, got calls=%d ok=%v result=%+v", calls, ok, result)
+	}
+}
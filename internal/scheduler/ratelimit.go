@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket shared by all target loops. It holds up to one
+// second worth of tokens, so short bursts are allowed up to the configured rate.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a limiter for pps probes per second, or nil when unlimited.
+func newRateLimiter(pps int) *rateLimiter {
+	if pps <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		rate:   float64(pps),
+		burst:  float64(pps),
+		tokens: float64(pps),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or the context is cancelled.
+// A nil limiter never blocks.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimiterUnlimited(t *testing.T) {
+	if l := newRateLimiter(0); l != nil {
+		t.Fatalf("expected nil limiter for max_pps=0")
+	}
+	var l *rateLimiter
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("nil limiter should never block: %v", err)
+	}
+}
+
+func TestRateLimiterAllowsBurstThenWaits(t *testing.T) {
+	l := newRateLimiter(20)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Fatalf("expected burst of 20 to be immediate, took %v", elapsed)
+	}
+
+	start = time.Now()
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("expected to wait for a token (~50ms), waited %v", elapsed)
+	}
+}
+
+func TestRateLimiterRespectsCancellation(t *testing.T) {
+	l := newRateLimiter(1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded while waiting for token, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// lookupFunc resolves a hostname to the IP address that should be probed.
+type lookupFunc func(ctx context.Context, host string) (string, error)
+
+// addressResolver caches the IP a hostname target resolves to and refreshes it
+// once the configured resolve interval has elapsed.
+type addressResolver struct {
+	host       string
+	lookup     lookupFunc
+	ip         string
+	resolvedAt time.Time
+}
+
+// newAddressResolver returns nil for IP literals, which never need resolving.
+func newAddressResolver(addr string, lookup lookupFunc) *addressResolver {
+	if _, err := netip.ParseAddr(addr); err == nil {
+		return nil
+	}
+	return &addressResolver{host: addr, lookup: lookup}
+}
+
+// resolve returns the cached IP, re-resolving when it is older than every.
+// changed reports whether the IP differs from the previous resolution. On lookup
+// failure the last known IP is kept.
+func (r *addressResolver) resolve(ctx context.Context, every time.Duration, now time.Time) (ip string, changed bool, err error) {
+	if r.ip != "" && now.Sub(r.resolvedAt) < every {
+		return r.ip, false, nil
+	}
+	next, err := r.lookup(ctx, r.host)
+	if err != nil {
+		return r.ip, false, err
+	}
+	changed = next != r.ip
+	r.ip = next
+	r.resolvedAt = now
+	return r.ip, changed, nil
+}
+
+// reset forgets the cached IP so that the next resolve performs a lookup.
+func (r *addressResolver) reset() bool {
+	had := r.ip != ""
+	r.ip = ""
+	r.resolvedAt = time.Time{}
+	return had
+}
+
+// lookupIP resolves host, preferring IPv4 like the pingers do.
+func lookupIP(ctx context.Context, host string) (string, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("no addresses found for %s", host)
+	}
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			return addr.IP.String(), nil
+		}
+	}
+	return addrs[0].String(), nil
+}
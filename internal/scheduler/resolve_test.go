@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewAddressResolverSkipsIPLiterals(t *testing.T) {
+	for _, addr := range []string{"192.0.2.1", "2001:db8::1", "fe80::1%eth0"} {
+		if r := newAddressResolver(addr, nil); r != nil {
+			t.Errorf("expected no resolver for IP literal %q", addr)
+		}
+	}
+	if r := newAddressResolver("example.com", nil); r == nil {
+		t.Fatalf("expected resolver for hostname")
+	}
+}
+
+func TestAddressResolverCachesUntilIntervalElapses(t *testing.T) {
+	answers := []string{"192.0.2.1", "192.0.2.2"}
+	calls := 0
+	r := newAddressResolver("example.com", func(ctx context.Context, host string) (string, error) {
+		ip := answers[calls]
+		calls++
+		return ip, nil
+	})
+	ctx := context.Background()
+	now := time.Now()
+
+	ip, changed, err := r.resolve(ctx, time.Minute, now)
+	if err != nil || ip != "192.0.2.1" || !changed {
+		t.Fatalf("first resolve: ip=%q changed=%v err=%v", ip, changed, err)
+	}
+	ip, changed, _ = r.resolve(ctx, time.Minute, now.Add(30*time.Second))
+	if ip != "192.0.2.1" || changed || calls != 1 {
+		t.Fatalf("expected cached IP without lookup, got ip=%q changed=%v calls=%d", ip, changed, calls)
+	}
+	ip, changed, _ = r.resolve(ctx, time.Minute, now.Add(time.Minute))
+	if ip != "192.0.2.2" || !changed || calls != 2 {
+		t.Fatalf("expected re-resolution to new IP, got ip=%q changed=%v calls=%d", ip, changed, calls)
+	}
+}
+
+func TestAddressResolverKeepsLastIPOnFailure(t *testing.T) {
+	fail := false
+	r := newAddressResolver("example.com", func(ctx context.Context, host string) (string, error) {
+		if fail {
+			return "", errors.New("lookup failed")
+		}
+		return "192.0.2.1", nil
+	})
+	now := time.Now()
+	if _, _, err := r.resolve(context.Background(), time.Second, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fail = true
+	ip, changed, err := r.resolve(context.Background(), time.Second, now.Add(2*time.Second))
+	if err == nil {
+		t.Fatalf("expected lookup error")
+	}
+	if ip != "192.0.2.1" || changed {
+		t.Fatalf("expected last known IP to be kept, got ip=%q changed=%v", ip, changed)
+	}
+}
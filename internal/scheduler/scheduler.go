@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 type Scheduler interface {
 	Run(ctx context.Context) error
 	UpdateConfig(global config.GlobalOptions, targets []config.TargetConfig)
+	SetTiming(interval, timeout time.Duration)
 	Stop()
 }
 
@@ -28,12 +30,26 @@ type Impl struct {
 	state      state.Store
 	logger     *log.Logger
 	semaphore  chan struct{}
+	external   chan struct{} // max_concurrency_external slots; nil without a separate limit
+	isExternal func(ping.Pinger) bool
+	limiter    *rateLimiter
+	lookup     lookupFunc
+	synthetic  func(withTLS bool) ping.Pinger // pinger for probe=synthetic targets
+	shared     *probeGroup
+	batch      resultBatch
 	targetJobs map[string]context.CancelFunc
+	active     map[string]int
 	wg         sync.WaitGroup
 	cancel     context.CancelFunc
 	runCtx     context.Context
+	timingCh   chan struct{} // closed and replaced by SetTiming to wake target loops
+	onResult   ResultFunc
 }
 
+// ResultFunc receives every probe result after it has been passed to the store.
+// With result_batch_interval the store may apply it slightly later.
+type ResultFunc func(target string, result ping.Result)
+
 // NewScheduler constructs a scheduler instance.
 func NewScheduler(global config.GlobalOptions, targets []config.TargetConfig, pinger ping.Pinger, store state.Store, logger *log.Logger) *Impl {
 	s := &Impl{
@@ -43,7 +59,15 @@ func NewScheduler(global config.GlobalOptions, targets []config.TargetConfig, pi
 		state:      store,
 		logger:     logger,
 		semaphore:  make(chan struct{}, maxConcurrency(global.MaxConcurrency)),
+		external:   externalSemaphore(global.MaxConcurrencyExternal),
+		isExternal: isExternalPinger,
+		limiter:    newRateLimiter(global.MaxPPS),
+		lookup:     lookupIP,
+		synthetic:  newSyntheticPinger,
+		shared:     newProbeGroup(),
 		targetJobs: make(map[string]context.CancelFunc),
+		active:     make(map[string]int),
+		timingCh:   make(chan struct{}),
 	}
 	for _, tgt := range targets {
 		s.targets[tgt.Name] = tgt
@@ -70,9 +94,16 @@ func (s *Impl) Run(ctx context.Context) error {
 	for _, tgt := range targets {
 		s.startTarget(runCtx, tgt)
 	}
+	flusherDone := make(chan struct{})
+	go func() {
+		s.runBatchFlusher(runCtx)
+		close(flusherDone)
+	}()
 
 	<-runCtx.Done()
-	s.wg.Wait()
+	s.drain(s.currentShutdownGrace())
+	<-flusherDone
+	s.flushResults()
 	s.mu.Lock()
 	s.cancel = nil
 	s.runCtx = nil
@@ -83,8 +114,12 @@ func (s *Impl) Run(ctx context.Context) error {
 // UpdateConfig applies new global options and updates target goroutines.
 func (s *Impl) UpdateConfig(global config.GlobalOptions, targets []config.TargetConfig) {
 	s.mu.Lock()
+	if global.MaxPPS != s.cfg.MaxPPS {
+		s.limiter = newRateLimiter(global.MaxPPS)
+	}
 	s.cfg = global
 	s.semaphore = make(chan struct{}, maxConcurrency(global.MaxConcurrency))
+	s.external = externalSemaphore(global.MaxConcurrencyExternal)
 
 	updated := make(map[string]config.TargetConfig, len(targets))
 	for _, tgt := range targets {
@@ -102,7 +137,8 @@ func (s *Impl) UpdateConfig(global config.GlobalOptions, targets []config.Target
 			toStart = append(toStart, tgt)
 			continue
 		}
-		if existing.Address != tgt.Address {
+		if existing.Address != tgt.Address || existing.ResolveInterval != tgt.ResolveInterval ||
+			existing.Probe != tgt.Probe || existing.TLS != tgt.TLS || existing.DSCP != tgt.DSCP {
 			if cancel, ok := s.targetJobs[name]; ok {
 				toStop = append(toStop, cancel)
 				delete(s.targetJobs, name)
@@ -121,6 +157,12 @@ func (s *Impl) UpdateConfig(global config.GlobalOptions, targets []config.Target
 	s.targets = updated
 	s.mu.Unlock()
 
+	addresses := make(map[string]bool, len(updated))
+	for _, tgt := range updated {
+		addresses[probeKey(tgt)] = true
+	}
+	s.shared.forget(addresses)
+
 	for _, cancel := range toStop {
 		cancel()
 	}
@@ -135,6 +177,30 @@ func (s *Impl) UpdateConfig(global config.GlobalOptions, targets []config.Target
 	}
 }
 
+// SetTiming changes the probe interval and timeout without touching targets.
+// Loops waiting for their next probe restart the wait with the new interval,
+// so a shorter interval takes effect immediately. The next config reload
+// replaces both values again. As with UpdateConfig, updating the timing the
+// store reports is left to the caller.
+func (s *Impl) SetTiming(interval, timeout time.Duration) {
+	s.mu.Lock()
+	s.cfg.Interval = interval
+	s.cfg.Timeout = timeout
+	close(s.timingCh)
+	s.timingCh = make(chan struct{})
+	s.mu.Unlock()
+}
+
+// SetOnResult registers fn to be called with each probe result, for programs
+// embedding the scheduler. fn runs on the target's goroutine, so it may be
+// called concurrently for different targets and should return quickly. A nil
+// fn removes the hook.
+func (s *Impl) SetOnResult(fn ResultFunc) {
+	s.mu.Lock()
+	s.onResult = fn
+	s.mu.Unlock()
+}
+
 // Stop cancels all running target loops.
 func (s *Impl) Stop() {
 	s.mu.Lock()
@@ -153,18 +219,77 @@ func (s *Impl) startTarget(ctx context.Context, target config.TargetConfig) {
 	}
 	targetCtx, cancel := context.WithCancel(ctx)
 	s.targetJobs[target.Name] = cancel
+	s.active[target.Name]++
 	s.wg.Add(1)
 	s.mu.Unlock()
 
 	go func() {
 		defer s.wg.Done()
+		defer s.finishTarget(target.Name)
 		s.runTargetLoop(targetCtx, target)
 	}()
 }
 
+func (s *Impl) finishTarget(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[name] <= 1 {
+		delete(s.active, name)
+		return
+	}
+	s.active[name]--
+}
+
+// drain waits for target loops to exit, giving up after grace so that a stuck
+// ping cannot hold shutdown hostage. Loops still running are logged. A grace
+// of 0 (shutdown_grace=0) returns without waiting.
+func (s *Impl) drain(grace time.Duration) {
+	if grace <= 0 {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return
+	case <-timer.C:
+	}
+
+	pending := s.pendingTargets()
+	if s.logger != nil {
+		s.logger.Warn("Shutdown grace period expired", map[string]interface{}{
+			"grace":   grace.String(),
+			"targets": pending,
+		})
+	}
+}
+
+func (s *Impl) pendingTargets() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.active))
+	for name := range s.active {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (s *Impl) runTargetLoop(ctx context.Context, target config.TargetConfig) {
+	// Synthetic probes time the DNS lookup themselves, so they never use a
+	// cached address.
+	var resolver *addressResolver
+	if target.Probe != config.ProbeSynthetic {
+		resolver = newAddressResolver(target.Address, s.lookup)
+	}
 	for {
-		interval, timeout := s.currentTiming()
+		interval, timeout, changed := s.currentTiming()
 		if interval <= 0 {
 			interval = time.Second
 		}
@@ -174,35 +299,199 @@ func (s *Impl) runTargetLoop(ctx context.Context, target config.TargetConfig) {
 		case <-ctx.Done():
 			timer.Stop()
 			return
+		case <-changed:
+			timer.Stop()
+			continue
 		case <-timer.C:
 		}
 
-		sem, err := s.acquire(ctx)
-		if err != nil {
+		s.debug("Dispatching probe", map[string]interface{}{
+			"target":  target.Name,
+			"address": target.Address,
+		})
+		result, ok := s.probe(ctx, target, resolver, interval, timeout)
+		if !ok {
 			return
 		}
-		result := s.pingOnce(ctx, target.Address, timeout)
-		s.release(sem)
-		s.state.UpdateResult(target.Name, result)
+		s.record(target.Name, result)
+		if onResult := s.currentOnResult(); onResult != nil {
+			onResult(target.Name, result)
+		}
 		if s.logger != nil {
 			s.logger.LogPingResult(target.Name, result.Success, result.RTT, result.Error)
 		}
 	}
 }
 
-func (s *Impl) pingOnce(ctx context.Context, addr string, timeout time.Duration) ping.Result {
+// probe sends one probe for target, honouring max_pps and max_concurrency. With
+// dedupe_by_address, targets sharing an address share a probe: a result less
+// than half an interval old is reused instead of probing again. ok is false
+// when ctx ended before a result was available.
+func (s *Impl) probe(ctx context.Context, target config.TargetConfig, resolver *addressResolver, interval, timeout time.Duration) (ping.Result, bool) {
+	run := func() (ping.Result, bool) {
+		if err := s.currentLimiter().Wait(ctx); err != nil {
+			return ping.Result{}, false
+		}
+		waitStart := time.Now()
+		// Take the external slot first: a probe throttled by
+		// max_concurrency_external must not hold a max_concurrency slot that an
+		// ICMP probe could use, and the wait must not count against its timeout.
+		if ext := s.externalSlots(target); ext != nil {
+			if err := acquireSlot(ctx, ext); err != nil {
+				return ping.Result{}, false
+			}
+			defer s.release(ext)
+		}
+		sem, err := s.acquire(ctx)
+		if err != nil {
+			return ping.Result{}, false
+		}
+		defer s.release(sem)
+		s.debug("Acquired semaphore", map[string]interface{}{
+			"target": target.Name,
+			"wait":   time.Since(waitStart).String(),
+		})
+		return s.pingOnce(ctx, target, s.probeAddress(ctx, target, resolver, timeout), timeout), true
+	}
+	if !s.dedupeByAddress() {
+		return run()
+	}
+	return s.shared.do(ctx, probeKey(target), interval/2, func() (ping.Result, bool) {
+		result, ok := run()
+		// A probe cut short by this target's removal must not be shared.
+		return result, ok && ctx.Err() == nil
+	})
+}
+
+// probeAddress returns the address to ping for target. Hostnames with a resolve
+// interval are probed via their cached IP; otherwise the pinger resolves per probe.
+// Re-resolution is bounded by timeout so that an unresponsive DNS server cannot
+// hold back the probe result; the pinger then reports the failure.
+func (s *Impl) probeAddress(ctx context.Context, target config.TargetConfig, resolver *addressResolver, timeout time.Duration) string {
+	if resolver == nil {
+		return target.Address
+	}
+	every := s.resolveInterval(target)
+	if every <= 0 {
+		if resolver.reset() {
+			s.state.UpdateResolvedAddress(target.Name, "")
+		}
+		return target.Address
+	}
+
+	previous := resolver.ip
+	lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+	ip, changed, err := resolver.resolve(lookupCtx, every, time.Now())
+	cancel()
+	if err != nil && s.logger != nil {
+		s.logger.Warn("Failed to re-resolve target address", map[string]interface{}{
+			"target":  target.Name,
+			"address": target.Address,
+			"error":   err.Error(),
+		})
+	}
+	if changed {
+		s.state.UpdateResolvedAddress(target.Name, ip)
+		if previous != "" && s.logger != nil {
+			s.logger.Info("Target address changed", map[string]interface{}{
+				"target": target.Name,
+				"from":   previous,
+				"to":     ip,
+			})
+		}
+	}
+	if ip == "" {
+		return target.Address
+	}
+	return ip
+}
+
+func (s *Impl) pingOnce(ctx context.Context, target config.TargetConfig, addr string, timeout time.Duration) ping.Result {
 	pingCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	return s.pinger.Ping(pingCtx, addr, timeout)
+	if target.DSCP > 0 {
+		pingCtx = ping.WithDSCP(pingCtx, target.DSCP)
+	}
+	start := time.Now()
+	result := s.safePing(pingCtx, target, addr, timeout)
+	s.debug("Probe complete", map[string]interface{}{
+		"target":   target.Name,
+		"address":  addr,
+		"success":  result.Success,
+		"duration": time.Since(start).String(),
+	})
+	return result
+}
+
+// safePing calls the pinger and converts a panic into a failed result so that a
+// misbehaving probe cannot stop monitoring of its target.
+func (s *Impl) safePing(ctx context.Context, target config.TargetConfig, addr string, timeout time.Duration) (result ping.Result) {
+	defer func() {
+		if r := recover(); r != nil {
+			if s.logger != nil {
+				s.logger.Error("Pinger panicked", map[string]interface{}{
+					"target":  target.Name,
+					"address": addr,
+					"panic":   fmt.Sprint(r),
+				})
+			}
+			result = ping.Result{Success: false, Error: fmt.Errorf("pinger panic: %v", r)}
+		}
+	}()
+	return s.pingerFor(target).Ping(ctx, addr, timeout)
+}
+
+// pingerFor returns the pinger that probes target according to its probe option.
+func (s *Impl) pingerFor(target config.TargetConfig) ping.Pinger {
+	if target.Probe == config.ProbeSynthetic {
+		return s.synthetic(target.TLS)
+	}
+	return s.pinger
+}
+
+// externalSlots returns the max_concurrency_external semaphore when target is
+// probed by the external ping command, or nil when no separate limit applies.
+func (s *Impl) externalSlots(target config.TargetConfig) chan struct{} {
+	if !s.isExternal(s.pingerFor(target)) {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.external
+}
+
+// isExternalPinger reports whether p runs the system ping command. The fallback
+// pinger counts as ICMP: it only reaches the command after a permission error
+// within the probe, too late to wait for an external slot.
+func isExternalPinger(p ping.Pinger) bool {
+	_, ok := p.(*ping.ExternalPinger)
+	return ok
+}
+
+func newSyntheticPinger(withTLS bool) ping.Pinger {
+	return ping.NewSyntheticPinger(withTLS)
+}
+
+func (s *Impl) debug(message string, fields map[string]interface{}) {
+	if s.logger != nil {
+		s.logger.Debug(message, fields)
+	}
 }
 
 func (s *Impl) acquire(ctx context.Context) (chan struct{}, error) {
 	sem := s.currentSemaphore()
+	if err := acquireSlot(ctx, sem); err != nil {
+		return nil, err
+	}
+	return sem, nil
+}
+
+func acquireSlot(ctx context.Context, sem chan struct{}) error {
 	select {
 	case sem <- struct{}{}:
-		return sem, nil
+		return nil
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return ctx.Err()
 	}
 }
 
@@ -213,10 +502,37 @@ func (s *Impl) release(sem chan struct{}) {
 	}
 }
 
-func (s *Impl) currentTiming() (time.Duration, time.Duration) {
+func (s *Impl) currentTiming() (time.Duration, time.Duration, <-chan struct{}) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Interval, s.cfg.Timeout, s.timingCh
+}
+
+func (s *Impl) currentOnResult() ResultFunc {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.onResult
+}
+
+func (s *Impl) dedupeByAddress() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.DedupeByAddress
+}
+
+func (s *Impl) currentShutdownGrace() time.Duration {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.cfg.Interval, s.cfg.Timeout
+	return s.cfg.ShutdownGrace
+}
+
+func (s *Impl) resolveInterval(target config.TargetConfig) time.Duration {
+	if target.ResolveInterval > 0 {
+		return target.ResolveInterval
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.ResolveInterval
 }
 
 func (s *Impl) currentSemaphore() chan struct{} {
@@ -225,9 +541,24 @@ func (s *Impl) currentSemaphore() chan struct{} {
 	return s.semaphore
 }
 
+func (s *Impl) currentLimiter() *rateLimiter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.limiter
+}
+
 func maxConcurrency(value int) int {
 	if value <= 0 {
 		return 1
 	}
 	return value
 }
+
+// externalSemaphore sizes the max_concurrency_external semaphore; value <= 0
+// means no separate limit.
+func externalSemaphore(value int) chan struct{} {
+	if value <= 0 {
+		return nil
+	}
+	return make(chan struct{}, value)
+}
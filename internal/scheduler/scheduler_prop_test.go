@@ -53,7 +53,7 @@ func TestPropertySchedulerConcurrencyLimit(t *testing.T) {
 				return true
 			}
 			pinger := &blockingPinger{started: make(chan struct{})}
-			store := state.NewStore(nil, 5*time.Millisecond)
+			store := state.NewStore(nil, 5*time.Millisecond, state.Thresholds{})
 			targets := make([]config.TargetConfig, targetCount)
 			for i := 0; i < targetCount; i++ {
 				targets[i] = config.TargetConfig{
@@ -107,7 +107,7 @@ func TestPropertySchedulerTargetsStart(t *testing.T) {
 				return true
 			}
 			pinger := &timestampPinger{times: make(map[string][]time.Time)}
-			store := state.NewStore(nil, 2*time.Millisecond)
+			store := state.NewStore(nil, 2*time.Millisecond, state.Thresholds{})
 			targets := make([]config.TargetConfig, targetCount)
 			for i := 0; i < targetCount; i++ {
 				addr := fmt.Sprintf("192.0.2.%d", i+1)
@@ -155,7 +155,7 @@ func TestPropertySchedulerIntervalRespected(t *testing.T) {
 			}
 			interval := time.Duration(intervalMs) * time.Millisecond
 			pinger := &timestampPinger{times: make(map[string][]time.Time)}
-			store := state.NewStore(nil, 2*time.Millisecond)
+			store := state.NewStore(nil, 2*time.Millisecond, state.Thresholds{})
 			target := config.TargetConfig{Name: "a", Address: "192.0.2.1"}
 
 			logger := log.NewLogger(log.LevelInfo)
@@ -204,7 +204,7 @@ func TestPropertySchedulerStopsOnCancel(t *testing.T) {
 			}
 			interval := time.Duration(intervalMs) * time.Millisecond
 			pinger := &timestampPinger{times: make(map[string][]time.Time)}
-			store := state.NewStore(nil, 2*time.Millisecond)
+			store := state.NewStore(nil, 2*time.Millisecond, state.Thresholds{})
 			target := config.TargetConfig{Name: "a", Address: "192.0.2.1"}
 
 			logger := log.NewLogger(log.LevelInfo)
@@ -1,7 +1,9 @@
 package scheduler
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -15,7 +17,7 @@ import (
 
 func TestSchedulerMaxConcurrency(t *testing.T) {
 	pinger := &blockingPinger{started: make(chan struct{})}
-	store := state.NewStore(nil, 5*time.Millisecond)
+	store := state.NewStore(nil, 5*time.Millisecond, state.Thresholds{})
 	targets := []config.TargetConfig{
 		{Name: "a", Address: "192.0.2.1"},
 		{Name: "b", Address: "192.0.2.2"},
@@ -46,9 +48,51 @@ func TestSchedulerMaxConcurrency(t *testing.T) {
 	}
 }
 
+func TestSchedulerExternalLimitDoesNotStarveOtherProbes(t *testing.T) {
+	icmp := &recordingPinger{seen: make(map[string]int)}
+	external := &slowPinger{delay: 20 * time.Millisecond}
+	targets := []config.TargetConfig{
+		{Name: "icmp", Address: "192.0.2.1"},
+		{Name: "ext-a", Address: "192.0.2.2", Probe: config.ProbeSynthetic},
+		{Name: "ext-b", Address: "192.0.2.3", Probe: config.ProbeSynthetic},
+		{Name: "ext-c", Address: "192.0.2.4", Probe: config.ProbeSynthetic},
+	}
+	store := state.NewStore(targets, 30*time.Millisecond, state.Thresholds{})
+	s := NewScheduler(config.GlobalOptions{
+		Interval:               time.Millisecond,
+		Timeout:                30 * time.Millisecond,
+		MaxConcurrency:         2,
+		MaxConcurrencyExternal: 1,
+	}, targets, icmp, store, nil)
+	// The synthetic hook stands in for the external pinger.
+	s.synthetic = func(bool) ping.Pinger { return external }
+	s.isExternal = func(p ping.Pinger) bool { return p == external }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	// Three external targets queue for one slot; none may hold both
+	// max_concurrency slots while waiting.
+	icmp.waitFor(t, "192.0.2.1", 20, ctx)
+	cancel()
+
+	external.mu.Lock()
+	defer external.mu.Unlock()
+	if external.max > 1 {
+		t.Fatalf("expected at most 1 external probe at a time, got %d", external.max)
+	}
+	if external.ok == 0 {
+		t.Fatalf("expected external probes to complete")
+	}
+	if external.failed != 0 {
+		t.Fatalf("expected waiting for an external slot not to use up the timeout, got %d failed probes", external.failed)
+	}
+}
+
 func TestSchedulerUpdateConfigStartsNewTarget(t *testing.T) {
 	recorder := &recordingPinger{seen: make(map[string]int)}
-	store := state.NewStore(nil, 2*time.Millisecond)
+	store := state.NewStore(nil, 2*time.Millisecond, state.Thresholds{})
 
 	initial := []config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}
 	logger := log.NewLogger(log.LevelInfo)
@@ -74,6 +118,542 @@ func TestSchedulerUpdateConfigStartsNewTarget(t *testing.T) {
 	recorder.waitFor(t, "192.0.2.2", 1, ctx)
 }
 
+func TestSchedulerSetTimingWakesWaitingLoops(t *testing.T) {
+	recorder := &recordingPinger{seen: make(map[string]int)}
+	store := state.NewStore(nil, time.Second, state.Thresholds{})
+
+	targets := []config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}
+	logger := log.NewLogger(log.LevelInfo)
+	s := NewScheduler(config.GlobalOptions{
+		Interval:       time.Hour,
+		Timeout:        time.Second,
+		MaxConcurrency: 1,
+	}, targets, recorder, store, logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	go func() { _ = s.Run(ctx) }()
+	for len(s.pendingTargets()) == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("target loop did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// The loop is waiting out the hour-long interval; shortening it must not
+	// wait for that timer to fire.
+	s.SetTiming(time.Millisecond, 5*time.Millisecond)
+	recorder.waitFor(t, "192.0.2.1", 2, ctx)
+
+	if interval, timeout, _ := s.currentTiming(); interval != time.Millisecond || timeout != 5*time.Millisecond {
+		t.Fatalf("expected interval=1ms timeout=5ms, got interval=%s timeout=%s", interval, timeout)
+	}
+}
+
+func TestSchedulerOnResultHook(t *testing.T) {
+	recorder := &recordingPinger{seen: make(map[string]int)}
+	store := state.NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}, time.Second, state.Thresholds{})
+
+	targets := []config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}
+	s := NewScheduler(config.GlobalOptions{
+		Interval:       time.Millisecond,
+		Timeout:        time.Second,
+		MaxConcurrency: 1,
+	}, targets, recorder, store, nil)
+
+	type call struct {
+		target  string
+		result  ping.Result
+		success int
+	}
+	calls := make(chan call, 1)
+	s.SetOnResult(func(target string, result ping.Result) {
+		status, _ := store.GetTargetStatus(target)
+		select {
+		case calls <- call{target: target, result: result, success: status.TotalSuccess}:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	select {
+	case c := <-calls:
+		if c.target != "a" || !c.result.Success {
+			t.Fatalf("expected a successful result for a, got %s %+v", c.target, c.result)
+		}
+		if c.success < 1 {
+			t.Fatalf("expected the result to be in the store before the hook runs")
+		}
+	case <-ctx.Done():
+		t.Fatal("OnResult hook was not called")
+	}
+}
+
+func TestSchedulerMaxPPS(t *testing.T) {
+	recorder := &recordingPinger{seen: make(map[string]int)}
+	store := state.NewStore(nil, 2*time.Millisecond, state.Thresholds{})
+	targets := []config.TargetConfig{
+		{Name: "a", Address: "192.0.2.1"},
+		{Name: "b", Address: "192.0.2.2"},
+	}
+
+	s := NewScheduler(config.GlobalOptions{
+		Interval:       1 * time.Millisecond,
+		Timeout:        2 * time.Millisecond,
+		MaxConcurrency: 10,
+		MaxPPS:         20,
+	}, targets, recorder, store, log.NewLogger(log.LevelInfo))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = s.Run(ctx)
+
+	recorder.mu.Lock()
+	total := recorder.seen["192.0.2.1"] + recorder.seen["192.0.2.2"]
+	recorder.mu.Unlock()
+	// 20 tokens of burst plus ~4 refilled in 200ms; allow some slack.
+	if total > 30 {
+		t.Fatalf("expected at most ~24 probes with max_pps=20, got %d", total)
+	}
+	if total == 0 {
+		t.Fatalf("expected probes to be sent")
+	}
+}
+
+func TestSchedulerShutdownGraceBoundsDrain(t *testing.T) {
+	pinger := &stuckPinger{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(pinger.release)
+	store := state.NewStore(nil, 5*time.Millisecond, state.Thresholds{})
+	targets := []config.TargetConfig{{Name: "stuck", Address: "192.0.2.1"}}
+
+	var buf bytes.Buffer
+	logger := log.NewLogger(log.LevelInfo)
+	logger.SetOutput(&buf)
+	s := NewScheduler(config.GlobalOptions{
+		Interval:       1 * time.Millisecond,
+		Timeout:        5 * time.Millisecond,
+		MaxConcurrency: 1,
+		ShutdownGrace:  20 * time.Millisecond,
+	}, targets, pinger, store, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = s.Run(ctx)
+		close(done)
+	}()
+
+	<-pinger.started
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Run did not return after shutdown grace period")
+	}
+	if !strings.Contains(buf.String(), "stuck") {
+		t.Fatalf("expected stuck target to be logged, got %q", buf.String())
+	}
+}
+
+func TestSchedulerZeroShutdownGraceSkipsDrain(t *testing.T) {
+	pinger := &stuckPinger{started: make(chan struct{}), release: make(chan struct{})}
+	defer close(pinger.release)
+	store := state.NewStore(nil, 5*time.Millisecond, state.Thresholds{})
+	targets := []config.TargetConfig{{Name: "stuck", Address: "192.0.2.1"}}
+
+	var buf bytes.Buffer
+	logger := log.NewLogger(log.LevelInfo)
+	logger.SetOutput(&buf)
+	s := NewScheduler(config.GlobalOptions{
+		Interval:       1 * time.Millisecond,
+		Timeout:        5 * time.Millisecond,
+		MaxConcurrency: 1,
+	}, targets, pinger, store, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = s.Run(ctx)
+		close(done)
+	}()
+
+	<-pinger.started
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatalf("Run waited for the stuck ping despite shutdown_grace=0")
+	}
+	if strings.Contains(buf.String(), "grace") {
+		t.Fatalf("did not expect grace period warning, got %q", buf.String())
+	}
+}
+
+func TestSchedulerShutdownFastPath(t *testing.T) {
+	recorder := &recordingPinger{seen: make(map[string]int)}
+	store := state.NewStore(nil, 2*time.Millisecond, state.Thresholds{})
+	targets := []config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}
+
+	var buf bytes.Buffer
+	logger := log.NewLogger(log.LevelInfo)
+	logger.SetOutput(&buf)
+	s := NewScheduler(config.GlobalOptions{
+		Interval:       1 * time.Millisecond,
+		Timeout:        2 * time.Millisecond,
+		MaxConcurrency: 1,
+		ShutdownGrace:  time.Minute,
+	}, targets, recorder, store, logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_ = s.Run(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected prompt shutdown, took %v", elapsed)
+	}
+	if strings.Contains(buf.String(), "grace") {
+		t.Fatalf("did not expect grace period warning, got %q", buf.String())
+	}
+}
+
+func TestSchedulerProbesResolvedAddress(t *testing.T) {
+	recorder := &recordingPinger{seen: make(map[string]int)}
+	store := state.NewStore(nil, 2*time.Millisecond, state.Thresholds{})
+	targets := []config.TargetConfig{
+		{Name: "web", Address: "web.example", ResolveInterval: time.Hour},
+	}
+	store.UpdateTargets(targets)
+
+	s := NewScheduler(config.GlobalOptions{
+		Interval:       1 * time.Millisecond,
+		Timeout:        2 * time.Millisecond,
+		MaxConcurrency: 1,
+	}, targets, recorder, store, log.NewLogger(log.LevelInfo))
+	s.lookup = func(ctx context.Context, host string) (string, error) {
+		return "192.0.2.50", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	recorder.waitFor(t, "192.0.2.50", 1, ctx)
+	status, _ := store.GetTargetStatus("web")
+	if status.ResolvedIP != "192.0.2.50" {
+		t.Fatalf("expected resolved IP to be recorded, got %q", status.ResolvedIP)
+	}
+}
+
+func TestSchedulerResolveTimeoutStillProbes(t *testing.T) {
+	recorder := &recordingPinger{seen: make(map[string]int)}
+	store := state.NewStore(nil, 5*time.Millisecond, state.Thresholds{})
+	targets := []config.TargetConfig{
+		{Name: "web", Address: "web.example", ResolveInterval: time.Hour},
+	}
+	store.UpdateTargets(targets)
+
+	s := NewScheduler(config.GlobalOptions{
+		Interval:       1 * time.Millisecond,
+		Timeout:        5 * time.Millisecond,
+		MaxConcurrency: 1,
+	}, targets, recorder, store, log.NewLogger(log.LevelError))
+	s.lookup = func(ctx context.Context, host string) (string, error) {
+		<-ctx.Done() // a DNS server that never answers
+		return "", ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	// Every probe falls back to the pinger, whose outcome reaches the store.
+	recorder.waitFor(t, "web.example", 2, ctx)
+	status, _ := store.GetTargetStatus("web")
+	if status.TotalSuccess+status.TotalFailure == 0 {
+		t.Fatalf("expected probe results to be recorded, got %+v", status)
+	}
+}
+
+func TestSchedulerBatchesResults(t *testing.T) {
+	recorder := &recordingPinger{seen: make(map[string]int)}
+	targets := []config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}
+	store := state.NewStore(targets, 5*time.Millisecond, state.Thresholds{})
+
+	s := NewScheduler(config.GlobalOptions{
+		Interval:            1 * time.Millisecond,
+		Timeout:             5 * time.Millisecond,
+		MaxConcurrency:      1,
+		ResultBatchInterval: time.Hour,
+	}, targets, recorder, store, log.NewLogger(log.LevelInfo))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = s.Run(ctx)
+		close(done)
+	}()
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, time.Second)
+	defer waitCancel()
+	recorder.waitFor(t, "192.0.2.1", 3, waitCtx)
+	if status, _ := store.GetTargetStatus("a"); status.TotalSuccess != 0 {
+		t.Fatalf("expected results to wait for the flush, got %d applied", status.TotalSuccess)
+	}
+
+	// Results still pending at shutdown are flushed rather than lost.
+	cancel()
+	<-done
+	if status, _ := store.GetTargetStatus("a"); status.TotalSuccess < 3 || status.Status != state.StatusOK {
+		t.Fatalf("expected batched results applied on shutdown, got %+v", status)
+	}
+}
+
+func TestSchedulerDebugTrace(t *testing.T) {
+	recorder := &recordingPinger{seen: make(map[string]int)}
+	store := state.NewStore(nil, 2*time.Millisecond, state.Thresholds{})
+	targets := []config.TargetConfig{{Name: "traced", Address: "192.0.2.1"}}
+
+	var buf syncBuffer
+	logger := log.NewLogger(log.LevelDebug)
+	logger.SetOutput(&buf)
+	s := NewScheduler(config.GlobalOptions{
+		Interval:       1 * time.Millisecond,
+		Timeout:        2 * time.Millisecond,
+		MaxConcurrency: 1,
+	}, targets, recorder, store, logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		_ = s.Run(ctx)
+		close(done)
+	}()
+	recorder.waitFor(t, "192.0.2.1", 2, ctx)
+	cancel()
+	<-done
+
+	out := buf.String()
+	for _, want := range []string{"Dispatching probe", "Acquired semaphore", "Probe complete", `"target":"traced"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected debug log to contain %q, got %q", want, out)
+		}
+	}
+
+	buf.Reset()
+	logger.SetLevel(log.LevelInfo)
+	s.debug("Dispatching probe", nil)
+	if strings.Contains(buf.String(), "Dispatching probe") {
+		t.Fatalf("expected debug trace to be suppressed at info level")
+	}
+}
+
+func TestSchedulerRecoversFromPingerPanic(t *testing.T) {
+	pinger := &panickingPinger{}
+	store := state.NewStore(nil, 2*time.Millisecond, state.Thresholds{})
+	targets := []config.TargetConfig{{Name: "boom", Address: "192.0.2.1"}}
+	store.UpdateTargets(targets)
+
+	var buf syncBuffer
+	logger := log.NewLogger(log.LevelInfo)
+	logger.SetOutput(&buf)
+	s := NewScheduler(config.GlobalOptions{
+		Interval:       1 * time.Millisecond,
+		Timeout:        2 * time.Millisecond,
+		MaxConcurrency: 1,
+	}, targets, pinger, store, logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		_ = s.Run(ctx)
+		close(done)
+	}()
+
+	for atomic.LoadInt32(&pinger.calls) < 3 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("loop stopped after pinger panic; calls=%d", atomic.LoadInt32(&pinger.calls))
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+
+	status, _ := store.GetTargetStatus("boom")
+	if status.TotalFailure == 0 {
+		t.Fatalf("expected panics to be recorded as failures")
+	}
+	if !strings.Contains(buf.String(), "Pinger panicked") {
+		t.Fatalf("expected panic to be logged, got %q", buf.String())
+	}
+}
+
+func TestSchedulerDedupeByAddress(t *testing.T) {
+	recorder := &recordingPinger{seen: make(map[string]int)}
+	targets := []config.TargetConfig{
+		{Name: "a", Address: "192.0.2.1", Group: "one"},
+		{Name: "b", Address: "192.0.2.1", Group: "two"},
+	}
+	store := state.NewStore(targets, 50*time.Millisecond, state.Thresholds{})
+	s := NewScheduler(config.GlobalOptions{
+		Interval:        10 * time.Millisecond,
+		Timeout:         50 * time.Millisecond,
+		MaxConcurrency:  4,
+		DedupeByAddress: true,
+	}, targets, recorder, store, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = s.Run(ctx)
+
+	a, _ := store.GetTargetStatus("a")
+	b, _ := store.GetTargetStatus("b")
+	if a.TotalSuccess == 0 || b.TotalSuccess == 0 {
+		t.Fatalf("expected both targets to receive results, got a=%d b=%d", a.TotalSuccess, b.TotalSuccess)
+	}
+	recorder.mu.Lock()
+	probes := recorder.seen["192.0.2.1"]
+	recorder.mu.Unlock()
+	if probes >= a.TotalSuccess+b.TotalSuccess {
+		t.Fatalf("expected shared probes, got %d probes for %d results", probes, a.TotalSuccess+b.TotalSuccess)
+	}
+}
+
+func TestSchedulerUsesSyntheticPingerForProbeOption(t *testing.T) {
+	icmp := &recordingPinger{seen: make(map[string]int)}
+	synthetic := &recordingPinger{seen: make(map[string]int)}
+	targets := []config.TargetConfig{
+		{Name: "a", Address: "192.0.2.1"},
+		{Name: "b", Address: "web.example:443", Probe: config.ProbeSynthetic, TLS: true, ResolveInterval: time.Hour},
+	}
+	store := state.NewStore(targets, 50*time.Millisecond, state.Thresholds{})
+	s := NewScheduler(config.GlobalOptions{
+		Interval:       time.Millisecond,
+		Timeout:        50 * time.Millisecond,
+		MaxConcurrency: 4,
+	}, targets, icmp, store, nil)
+	var withTLS atomic.Bool
+	s.synthetic = func(tls bool) ping.Pinger {
+		withTLS.Store(tls)
+		return synthetic
+	}
+	s.lookup = func(ctx context.Context, host string) (string, error) {
+		t.Errorf("synthetic targets must not be resolved by the scheduler, got lookup of %q", host)
+		return "", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	icmp.waitFor(t, "192.0.2.1", 1, ctx)
+	synthetic.waitFor(t, "web.example:443", 1, ctx)
+	if !withTLS.Load() {
+		t.Fatalf("expected the synthetic pinger to be built with TLS")
+	}
+	icmp.mu.Lock()
+	defer icmp.mu.Unlock()
+	if icmp.seen["web.example:443"] != 0 {
+		t.Fatalf("expected synthetic target not to reach the ICMP pinger")
+	}
+}
+
+func TestSchedulerPassesDSCPToPinger(t *testing.T) {
+	var marked, unmarked atomic.Int32
+	pinger := pingerFunc(func(ctx context.Context, addr string, timeout time.Duration) ping.Result {
+		dscp, ok := ping.DSCPFromContext(ctx)
+		switch {
+		case addr == "192.0.2.1" && ok && dscp == 46:
+			marked.Add(1)
+		case addr == "192.0.2.2" && !ok:
+			unmarked.Add(1)
+		default:
+			t.Errorf("unexpected DSCP for %s: %d, %v", addr, dscp, ok)
+		}
+		return ping.Result{Success: true, RTT: time.Millisecond}
+	})
+	targets := []config.TargetConfig{
+		{Name: "voice", Address: "192.0.2.1", DSCP: 46},
+		{Name: "plain", Address: "192.0.2.2"},
+	}
+	store := state.NewStore(targets, 50*time.Millisecond, state.Thresholds{})
+	s := NewScheduler(config.GlobalOptions{
+		Interval:       time.Millisecond,
+		Timeout:        50 * time.Millisecond,
+		MaxConcurrency: 4,
+	}, targets, pinger, store, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_ = s.Run(ctx)
+	if marked.Load() == 0 || unmarked.Load() == 0 {
+		t.Fatalf("expected probes for both targets, got marked=%d unmarked=%d", marked.Load(), unmarked.Load())
+	}
+}
+
+// pingerFunc adapts a function to ping.Pinger.
+type pingerFunc func(ctx context.Context, addr string, timeout time.Duration) ping.Result
+
+func (f pingerFunc) Ping(ctx context.Context, addr string, timeout time.Duration) ping.Result {
+	return f(ctx, addr, timeout)
+}
+
+type panickingPinger struct {
+	calls int32
+}
+
+func (p *panickingPinger) Ping(ctx context.Context, addr string, timeout time.Duration) ping.Result {
+	atomic.AddInt32(&p.calls, 1)
+	panic("probe exploded")
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writers.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
+
+// stuckPinger ignores context cancellation to simulate a hung probe.
+type stuckPinger struct {
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *stuckPinger) Ping(ctx context.Context, addr string, timeout time.Duration) ping.Result {
+	p.once.Do(func() { close(p.started) })
+	<-p.release
+	return ping.Result{Success: false}
+}
+
 type blockingPinger struct {
 	inFlight int32
 	max      int32
@@ -103,6 +683,46 @@ func (p *blockingPinger) Ping(ctx context.Context, addr string, timeout time.Dur
 	return ping.Result{Success: false, Error: ctx.Err()}
 }
 
+// slowPinger takes delay per probe and counts how many probes the context cut
+// short.
+type slowPinger struct {
+	delay    time.Duration
+	mu       sync.Mutex
+	inFlight int
+	max      int
+	ok       int
+	failed   int
+}
+
+func (p *slowPinger) Ping(ctx context.Context, addr string, timeout time.Duration) ping.Result {
+	p.mu.Lock()
+	p.inFlight++
+	if p.inFlight > p.max {
+		p.max = p.inFlight
+	}
+	p.mu.Unlock()
+
+	timer := time.NewTimer(p.delay)
+	defer timer.Stop()
+	var result ping.Result
+	select {
+	case <-timer.C:
+		result = ping.Result{Success: true, RTT: p.delay}
+	case <-ctx.Done():
+		result = ping.Result{Success: false, Error: ctx.Err()}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inFlight--
+	if result.Success {
+		p.ok++
+	} else if ctx.Err() == context.DeadlineExceeded {
+		p.failed++
+	}
+	return result
+}
+
 type recordingPinger struct {
 	mu   sync.Mutex
 	seen map[string]int
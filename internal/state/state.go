@@ -1,6 +1,9 @@
 package state
 
 import (
+	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/doridoridoriand/surveiller/internal/config"
@@ -15,28 +18,182 @@ const (
 	StatusOK      Status = "OK"
 	StatusWarn    Status = "WARN"
 	StatusDown    Status = "DOWN"
+	// StatusFlapping marks a target whose status changed more than
+	// flap_threshold times within flap_window.
+	StatusFlapping Status = "FLAPPING"
+	// StatusMuted marks a target muted for maintenance. It is still probed,
+	// but its status is not reported as DOWN and records no transitions.
+	StatusMuted Status = "MUTED"
 )
 
+// DefaultGroupName is the group name used for targets without an explicit group.
+// The config parser stamps ungrouped targets when default_group is set.
+const DefaultGroupName = config.DefaultGroupName
+
 // RTTPoint records a single RTT measurement.
 type RTTPoint struct {
 	Time time.Time
 	RTT  time.Duration
 }
 
+// Jitter returns the population standard deviation of the RTTs in history.
+// Empty and single-point histories yield 0.
+func Jitter(history []RTTPoint) time.Duration {
+	if len(history) < 2 {
+		return 0
+	}
+	var sum float64
+	for _, point := range history {
+		sum += float64(point.RTT)
+	}
+	mean := sum / float64(len(history))
+	var variance float64
+	for _, point := range history {
+		d := float64(point.RTT) - mean
+		variance += d * d
+	}
+	variance /= float64(len(history))
+	return time.Duration(math.Sqrt(variance))
+}
+
+// Percentile returns the nearest-rank p-th percentile (0-100) of the RTTs in
+// history, or 0 when history is empty.
+func Percentile(history []RTTPoint, p float64) time.Duration {
+	if len(history) == 0 {
+		return 0
+	}
+	rtts := make([]time.Duration, len(history))
+	for i, point := range history {
+		rtts[i] = point.RTT
+	}
+	sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+	rank := int(math.Ceil(p / 100 * float64(len(rtts))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(rtts) {
+		rank = len(rtts)
+	}
+	return rtts[rank-1]
+}
+
+// GroupNames returns every group the target is shown and aggregated under:
+// the groups= list when set, otherwise just GroupName. Callers must not modify
+// the result.
+func (t TargetStatus) GroupNames() []string {
+	if len(t.Groups) > 0 {
+		return t.Groups
+	}
+	return []string{t.GroupName()}
+}
+
+// GroupName returns the target's group, or DefaultGroupName when it has none.
+func (t TargetStatus) GroupName() string {
+	name := strings.TrimSpace(t.Group)
+	if name == "" {
+		return DefaultGroupName
+	}
+	return name
+}
+
+// LossPercent returns the lifetime failure ratio as a percentage.
+func (t TargetStatus) LossPercent() float64 {
+	total := t.TotalSuccess + t.TotalFailure
+	if total == 0 {
+		return 0.0
+	}
+	return float64(t.TotalFailure) / float64(total) * 100.0
+}
+
+// RecentLossPercent returns the failure ratio over the recent outcome window as a percentage.
+func (t TargetStatus) RecentLossPercent() float64 {
+	if len(t.Recent) == 0 {
+		return 0.0
+	}
+	failures := 0
+	for _, ok := range t.Recent {
+		if !ok {
+			failures++
+		}
+	}
+	return float64(failures) / float64(len(t.Recent)) * 100.0
+}
+
+// Event records a status transition of a single target.
+type Event struct {
+	Time   time.Time
+	Target string
+	From   Status
+	To     Status
+}
+
 // TargetStatus captures the current state and history for a target.
 type TargetStatus struct {
-	Name          string
-	Address       string
-	Group         string
-	LastRTT       time.Duration
-	LastSuccessAt time.Time
-	LastFailureAt time.Time
-	ConsecutiveOK int
-	ConsecutiveNG int
-	TotalSuccess  int
-	TotalFailure  int
-	Status        Status
-	History       []RTTPoint
+	Name           string
+	Label          string // 表示名。空の場合はNameを表示
+	Address        string
+	ResolvedIP     string
+	Group          string
+	Groups         []string // groups= で指定された全グループ（先頭はGroupと同じ）
+	LastRTT        time.Duration
+	LastTTL        int
+	Backend        string        // 直近の結果を返したpinger（icmp/external/synthetic）
+	LastPhases     ping.Phases   // 直近のsyntheticプローブのフェーズ別所要時間
+	Interval       time.Duration // 実効のプローブ間隔（未設定なら0）
+	Timeout        time.Duration // 実効のタイムアウト
+	LastSuccessAt  time.Time
+	LastFailureAt  time.Time
+	ConsecutiveOK  int
+	ConsecutiveNG  int
+	TotalSuccess   int
+	TotalFailure   int
+	Status         Status
+	StatusSince    time.Time
+	History        []RTTPoint
+	Recent         []bool      // 直近のping結果（true=成功）、古い順
+	Transitions    []time.Time // flap_window内のステータス変化時刻（FLAPPING判定前）
+	ExcludeMetrics bool
+	Muted          bool
+
+	baseStatus    Status // FLAPPING判定前のステータス
+	unmutedStatus Status // ミュート中に判定されたステータス（ミュート解除時に復元）
+}
+
+// Thresholds holds explicit RTT thresholds used for status classification.
+// A zero value falls back to the timeout-relative default for that threshold.
+type Thresholds struct {
+	OK         time.Duration
+	Warn       time.Duration
+	RTTDown    time.Duration // recent average RTT above which a target is DOWN; 0 disables
+	LossDown   float64       // recent loss percentage above which a target is DOWN; 0 disables
+	LossWindow int           // number of recent probes loss is measured over; 0 uses the default
+	// HistoryWindow drops RTT history older than this instead of keeping a
+	// fixed number of points; 0 keeps the count-based default.
+	HistoryWindow time.Duration
+	// FlapCount is the number of status changes within FlapWindow above which a
+	// target is FLAPPING; 0 disables flap detection.
+	FlapCount  int
+	FlapWindow time.Duration
+	// WarnNG and DownNG are the consecutive failures after which a target is
+	// WARN and DOWN; 0 uses the defaults of 1 and 3.
+	WarnNG int
+	DownNG int
+}
+
+// ThresholdsFromOptions extracts the RTT thresholds configured in global options.
+func ThresholdsFromOptions(global config.GlobalOptions) Thresholds {
+	return Thresholds{
+		OK:            global.OKThreshold,
+		Warn:          global.WarnThreshold,
+		RTTDown:       global.RTTDownThreshold,
+		LossDown:      global.LossDownThreshold,
+		LossWindow:    global.LossWindow,
+		HistoryWindow: global.HistoryWindow,
+		FlapCount:     global.FlapThreshold,
+		FlapWindow:    global.FlapWindow,
+		WarnNG:        global.WarnThresholdNG,
+		DownNG:        global.DownThresholdNG,
+	}
 }
 
 // Store defines operations for tracking target state.
@@ -45,5 +202,9 @@ type Store interface {
 	GetSnapshot() []TargetStatus
 	UpdateTargets(targets []config.TargetConfig)
 	UpdateTimeout(timeout time.Duration)
+	UpdateThresholds(thresholds Thresholds)
+	UpdateResolvedAddress(name string, ip string)
 	GetTargetStatus(name string) (TargetStatus, bool)
+	RecentEvents(limit int) []Event
+	Reset(name string)
 }
@@ -1,6 +1,12 @@
 package state
 
 import (
+	"encoding/csv"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -10,45 +16,154 @@ import (
 
 const (
 	defaultHistorySize      = 100
-	defaultDownThreshold    = 3
+	windowedHistorySize     = 10000 // history_window指定時の上限（メモリ保護用）
+	defaultEventBufferSize  = 256
+	defaultDownThreshold    = config.DefaultDownThresholdNG
 	thresholdDataPointCount = 10 // 閾値判定に使うデータポイント数
+	defaultLossWindow       = 20 // 直近ロス率の計算に使う結果数
+	lossMinSamples          = 10 // ロス率による判定に必要な最小結果数
+	storeShardCount         = 16 // ターゲットマップのロック分割数
 )
 
-// StoreImpl is a thread-safe in-memory state store.
+// StoreImpl is a thread-safe in-memory state store. Targets are spread over
+// lock-striped shards so that results for different targets rarely contend.
+//
+// Lock order: mu, then shard locks in index order, then eventsMu.
 type StoreImpl struct {
-	mu            sync.RWMutex
-	targets       map[string]*TargetStatus
+	mu            sync.RWMutex // guards the settings below
 	historySize   int
 	downThreshold int
+	interval      time.Duration
 	timeout       time.Duration
+	thresholds    Thresholds
+
+	shards [storeShardCount]storeShard
+
+	eventsMu  sync.Mutex
+	events    []Event
+	eventSize int
+}
+
+// storeShard holds the targets whose names hash to it.
+type storeShard struct {
+	mu      sync.RWMutex
+	targets map[string]*TargetStatus
+}
+
+// ResultUpdate is a probe outcome passed to UpdateResults.
+type ResultUpdate struct {
+	Name   string
+	Result ping.Result
+	At     time.Time // when the probe finished; zero means the time of the update
 }
 
 // NewStore creates a store initialized with the provided targets.
-func NewStore(targets []config.TargetConfig, timeout time.Duration) *StoreImpl {
+func NewStore(targets []config.TargetConfig, timeout time.Duration, thresholds Thresholds) *StoreImpl {
 	store := &StoreImpl{
-		targets:       make(map[string]*TargetStatus),
 		historySize:   defaultHistorySize,
 		downThreshold: defaultDownThreshold,
 		timeout:       timeout,
+		thresholds:    thresholds,
+		eventSize:     defaultEventBufferSize,
+	}
+	for i := range store.shards {
+		store.shards[i].targets = make(map[string]*TargetStatus)
 	}
 	store.UpdateTargets(targets)
 	return store
 }
 
+func shardIndex(name string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return int(h.Sum32() % storeShardCount)
+}
+
+func (s *StoreImpl) shardFor(name string) *storeShard {
+	return &s.shards[shardIndex(name)]
+}
+
 // UpdateResult updates the target status based on a ping result.
 func (s *StoreImpl) UpdateResult(name string, result ping.Result) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.UpdateResults([]ResultUpdate{{Name: name, Result: result}})
+}
+
+// UpdateResults applies a batch of results in order, taking each shard lock
+// once for all of its targets. Results for one target must be in probe order.
+func (s *StoreImpl) UpdateResults(updates []ResultUpdate) {
+	if len(updates) == 0 {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	target, ok := s.targets[name]
+	var byShard [storeShardCount][]int
+	for i, update := range updates {
+		index := shardIndex(update.Name)
+		byShard[index] = append(byShard[index], i)
+	}
+
+	now := time.Now()
+	var events []Event
+	for index, positions := range byShard {
+		if len(positions) == 0 {
+			continue
+		}
+		shard := &s.shards[index]
+		shard.mu.Lock()
+		for _, i := range positions {
+			at := updates[i].At
+			if at.IsZero() {
+				at = now
+			}
+			if event, changed := s.updateTarget(shard, updates[i].Name, updates[i].Result, at); changed {
+				events = append(events, event)
+			}
+		}
+		shard.mu.Unlock()
+	}
+	s.appendEvents(events)
+}
+
+// updateTarget applies one result and returns the transition it caused, if any.
+// Callers must hold s.mu for reading and the shard lock.
+func (s *StoreImpl) updateTarget(shard *storeShard, name string, result ping.Result, now time.Time) (Event, bool) {
+	target, ok := shard.targets[name]
 	if !ok {
 		target = &TargetStatus{Name: name, Status: StatusUnknown}
-		s.targets[name] = target
+		shard.targets[name] = target
 	}
 
-	now := time.Now()
+	previous := target.Status
+	s.pruneHistory(target, now)
+	s.appendOutcome(target, result.Success)
+	s.applyResult(target, result, now)
+	s.applyLossThreshold(target)
+	s.applyFlapDetection(target, now)
+	if target.Muted {
+		target.unmutedStatus = target.Status
+		target.Status = StatusMuted
+	}
+	if target.Status == previous {
+		return Event{}, false
+	}
+	target.StatusSince = now
+	return Event{Time: now, Target: name, From: previous, To: target.Status}, true
+}
+
+// applyResult updates counters, history, and status of a target. Callers must
+// hold s.mu for reading and the target's shard lock.
+func (s *StoreImpl) applyResult(target *TargetStatus, result ping.Result, now time.Time) {
+	if result.Backend != "" {
+		target.Backend = result.Backend
+	}
+	// 失敗時も到達したフェーズまでの内訳を残す（どこで詰まったかが分かる）
+	target.LastPhases = result.Phases
 	if result.Success {
 		target.LastRTT = result.RTT
+		if result.TTL > 0 {
+			target.LastTTL = result.TTL
+		}
 		target.LastSuccessAt = now
 		target.ConsecutiveOK++
 		target.ConsecutiveNG = 0
@@ -65,12 +180,14 @@ func (s *StoreImpl) UpdateResult(name string, result ping.Result) {
 		}
 
 		// RTTに基づいてOK/WARNを判定
-		// OK: timeoutの25%以内
-		// WARN: timeoutの25%超、50%以内
+		// OK: timeoutの25%以内（ok_threshold指定時はその値以内）
+		// WARN: timeoutの25%超、50%以内（warn_threshold指定時はその値以内）
 		// timeoutの50%超もWARNとして扱う
-		okThreshold := s.timeout / 4   // 25%
-		warnThreshold := s.timeout / 2 // 50%
-		if avgRTT <= okThreshold {
+		// rtt_down_threshold指定時はそれを超えるとDOWN（連続成功数はそのまま）
+		okThreshold, warnThreshold := s.rttThresholds()
+		if s.thresholds.RTTDown > 0 && avgRTT > s.thresholds.RTTDown {
+			target.Status = StatusDown
+		} else if avgRTT <= okThreshold {
 			target.Status = StatusOK
 		} else if avgRTT <= warnThreshold {
 			target.Status = StatusWarn
@@ -85,79 +202,437 @@ func (s *StoreImpl) UpdateResult(name string, result ping.Result) {
 	target.ConsecutiveNG++
 	target.ConsecutiveOK = 0
 	target.TotalFailure++
-	if target.ConsecutiveNG >= s.downThreshold {
+	warnNG, downNG := s.failureThresholds()
+	switch {
+	case target.ConsecutiveNG >= downNG:
 		target.Status = StatusDown
-	} else {
+	case target.ConsecutiveNG >= warnNG:
 		target.Status = StatusWarn
+	default:
+		// warn_threshold_ng未満の失敗は許容し、前回の判定を維持する
+		target.Status = target.baseStatus
+		if target.Status == "" {
+			target.Status = StatusUnknown
+		}
+	}
+}
+
+// failureThresholds returns the consecutive failure counts at which a target
+// becomes WARN and DOWN. Callers must hold s.mu for reading.
+func (s *StoreImpl) failureThresholds() (int, int) {
+	warnNG, downNG := 1, s.downThreshold
+	if s.thresholds.WarnNG > 0 {
+		warnNG = s.thresholds.WarnNG
+	}
+	if s.thresholds.DownNG > 0 {
+		downNG = s.thresholds.DownNG
+	}
+	return warnNG, downNG
+}
+
+// RecentEvents returns up to limit status transitions, newest first.
+// A non-positive limit returns every buffered event.
+func (s *StoreImpl) RecentEvents(limit int) []Event {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+
+	if limit <= 0 || limit > len(s.events) {
+		limit = len(s.events)
+	}
+	result := make([]Event, 0, limit)
+	for i := len(s.events) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, s.events[i])
 	}
+	return result
 }
 
 // GetSnapshot returns a snapshot copy of all target states.
+// All shards are read-locked together so that the snapshot is consistent.
 func (s *StoreImpl) GetSnapshot() []TargetStatus {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	count := 0
+	for i := range s.shards {
+		s.shards[i].mu.RLock()
+		count += len(s.shards[i].targets)
+	}
+	defer func() {
+		for i := range s.shards {
+			s.shards[i].mu.RUnlock()
+		}
+	}()
 
-	result := make([]TargetStatus, 0, len(s.targets))
-	for _, target := range s.targets {
-		result = append(result, copyTargetStatus(target))
+	result := make([]TargetStatus, 0, count)
+	for i := range s.shards {
+		for _, target := range s.shards[i].targets {
+			result = append(result, copyTargetStatus(target))
+		}
 	}
 	return result
 }
 
 // UpdateTargets updates the target list, keeping history for existing targets.
 func (s *StoreImpl) UpdateTargets(targets []config.TargetConfig) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.mu.RLock()
+	interval, timeout := s.interval, s.timeout
+	s.mu.RUnlock()
 
-	updated := make(map[string]*TargetStatus, len(targets))
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+	}
+	defer func() {
+		for i := range s.shards {
+			s.shards[i].mu.Unlock()
+		}
+	}()
+
+	var updated [storeShardCount]map[string]*TargetStatus
+	for i := range updated {
+		updated[i] = make(map[string]*TargetStatus)
+	}
 	for _, tgt := range targets {
-		if existing, ok := s.targets[tgt.Name]; ok {
+		index := shardIndex(tgt.Name)
+		if existing, ok := s.shards[index].targets[tgt.Name]; ok {
+			if existing.Address != tgt.Address {
+				existing.ResolvedIP = ""
+			}
 			existing.Address = tgt.Address
 			existing.Group = tgt.Group
-			updated[tgt.Name] = existing
+			existing.Groups = tgt.Groups
+			existing.ExcludeMetrics = tgt.ExcludeMetrics
+			existing.Label = tgt.Label
+			existing.Interval, existing.Timeout = interval, timeout
+			setMuted(existing, tgt.Muted, time.Now())
+			updated[index][tgt.Name] = existing
 			continue
 		}
-		updated[tgt.Name] = &TargetStatus{
-			Name:    tgt.Name,
-			Address: tgt.Address,
-			Group:   tgt.Group,
-			Status:  StatusUnknown,
+		target := &TargetStatus{
+			Name:           tgt.Name,
+			Label:          tgt.Label,
+			Address:        tgt.Address,
+			Group:          tgt.Group,
+			Groups:         tgt.Groups,
+			ExcludeMetrics: tgt.ExcludeMetrics,
+			Interval:       interval,
+			Timeout:        timeout,
+			Status:         StatusUnknown,
 		}
+		setMuted(target, tgt.Muted, time.Now())
+		updated[index][tgt.Name] = target
 	}
 
-	s.targets = updated
+	for i := range s.shards {
+		s.shards[i].targets = updated[i]
+	}
+}
+
+// SetMuted mutes or unmutes a target at runtime and reports whether the target
+// exists. A reload resets it to the target's mute option.
+func (s *StoreImpl) SetMuted(name string, muted bool) bool {
+	shard := s.shardFor(name)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	target, ok := shard.targets[name]
+	if !ok {
+		return false
+	}
+	setMuted(target, muted, time.Now())
+	return true
 }
 
-// UpdateTimeout updates the timeout used for RTT threshold calculations.
+// setMuted switches the reported status between MUTED and the classified
+// status without recording a transition event. Callers must hold the shard lock.
+func setMuted(target *TargetStatus, muted bool, now time.Time) {
+	if target.Muted == muted {
+		return
+	}
+	target.Muted = muted
+	if muted {
+		target.unmutedStatus = target.Status
+		target.Status = StatusMuted
+	} else {
+		target.Status = target.unmutedStatus
+		if target.Status == "" {
+			target.Status = StatusUnknown
+		}
+		target.unmutedStatus = ""
+	}
+	target.StatusSince = now
+}
+
+// Reset clears the counters, RTT and loss history of a target, e.g. after
+// maintenance, and returns it to UNKNOWN. The target's configuration and mute
+// state are kept. Unknown names are ignored.
+func (s *StoreImpl) Reset(name string) {
+	shard := s.shardFor(name)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	target, ok := shard.targets[name]
+	if !ok {
+		return
+	}
+	muted := target.Muted
+	*target = TargetStatus{
+		Name:           target.Name,
+		Label:          target.Label,
+		Address:        target.Address,
+		ResolvedIP:     target.ResolvedIP,
+		Group:          target.Group,
+		Groups:         target.Groups,
+		ExcludeMetrics: target.ExcludeMetrics,
+		Interval:       target.Interval,
+		Timeout:        target.Timeout,
+		Status:         StatusUnknown,
+		StatusSince:    time.Now(),
+	}
+	setMuted(target, muted, target.StatusSince)
+}
+
+// UpdateTimeout updates the timeout used for RTT threshold calculations and
+// reported as every target's timeout.
 func (s *StoreImpl) UpdateTimeout(timeout time.Duration) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.timeout = timeout
+	s.stampTiming()
+}
+
+// UpdateTiming updates the probe interval and timeout reported by every
+// target, e.g. after a reload or an interval change in the TUI. The timeout is
+// also used for RTT threshold calculations.
+func (s *StoreImpl) UpdateTiming(interval, timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval, s.timeout = interval, timeout
+	s.stampTiming()
+}
+
+// stampTiming copies the store's timing to every target; there are no
+// per-target overrides, so all targets share it. Callers must hold s.mu.
+func (s *StoreImpl) stampTiming() {
+	for i := range s.shards {
+		shard := &s.shards[i]
+		shard.mu.Lock()
+		for _, target := range shard.targets {
+			target.Interval, target.Timeout = s.interval, s.timeout
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// UpdateThresholds updates the explicit RTT thresholds used for status classification.
+func (s *StoreImpl) UpdateThresholds(thresholds Thresholds) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.thresholds = thresholds
+}
+
+// UpdateResolvedAddress records the IP currently probed for a hostname target.
+func (s *StoreImpl) UpdateResolvedAddress(name string, ip string) {
+	shard := s.shardFor(name)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if target, ok := shard.targets[name]; ok {
+		target.ResolvedIP = ip
+	}
+}
+
+// RecentLossPercent returns the loss of a target over its last loss_window
+// probes. Unknown targets report 0.
+func (s *StoreImpl) RecentLossPercent(name string) float64 {
+	shard := s.shardFor(name)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	target, ok := shard.targets[name]
+	if !ok {
+		return 0
+	}
+	return target.RecentLossPercent()
 }
 
 // GetTargetStatus returns a copy of a single target status.
 func (s *StoreImpl) GetTargetStatus(name string) (TargetStatus, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	shard := s.shardFor(name)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
 
-	target, ok := s.targets[name]
+	target, ok := shard.targets[name]
 	if !ok {
 		return TargetStatus{}, false
 	}
 	return copyTargetStatus(target), true
 }
 
+// rttThresholds returns the OK and WARN RTT thresholds, preferring explicit
+// values and falling back to 25%/50% of the timeout.
+func (s *StoreImpl) rttThresholds() (time.Duration, time.Duration) {
+	okThreshold := s.timeout / 4   // 25%
+	warnThreshold := s.timeout / 2 // 50%
+	if s.thresholds.OK > 0 {
+		okThreshold = s.thresholds.OK
+	}
+	if s.thresholds.Warn > 0 {
+		warnThreshold = s.thresholds.Warn
+	}
+	return okThreshold, warnThreshold
+}
+
+// ExportHistoryCSV writes the RTT history of a target as timestamp,rtt_ms rows.
+func (s *StoreImpl) ExportHistoryCSV(name string, w io.Writer) error {
+	target, ok := s.GetTargetStatus(name)
+	if !ok {
+		return fmt.Errorf("unknown target: %q", name)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"timestamp", "rtt_ms"}); err != nil {
+		return err
+	}
+	for _, point := range target.History {
+		rttMs := float64(point.RTT) / float64(time.Millisecond)
+		record := []string{
+			point.Time.Format(time.RFC3339Nano),
+			strconv.FormatFloat(rttMs, 'f', 3, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// applyLossThreshold forces DOWN when the recent loss exceeds loss_down_threshold,
+// even if the latest probe succeeded.
+func (s *StoreImpl) applyLossThreshold(target *TargetStatus) {
+	minSamples := lossMinSamples
+	if window := s.lossWindow(); window < minSamples {
+		minSamples = window
+	}
+	if s.thresholds.LossDown <= 0 || len(target.Recent) < minSamples {
+		return
+	}
+	if target.RecentLossPercent() > s.thresholds.LossDown {
+		target.Status = StatusDown
+	}
+}
+
+// applyFlapDetection records changes of the classified status and replaces it
+// with FLAPPING while more than FlapCount changes fall within FlapWindow. The
+// target keeps FLAPPING, and so records no transition events, until it settles.
+func (s *StoreImpl) applyFlapDetection(target *TargetStatus, now time.Time) {
+	base := target.Status
+	if target.baseStatus != "" && target.baseStatus != StatusUnknown && base != target.baseStatus {
+		target.Transitions = append(target.Transitions, now)
+	}
+	target.baseStatus = base
+
+	if s.thresholds.FlapCount <= 0 {
+		target.Transitions = nil
+		return
+	}
+	cutoff := now.Add(-s.thresholds.FlapWindow)
+	keep := 0
+	for keep < len(target.Transitions) && !target.Transitions[keep].After(cutoff) {
+		keep++
+	}
+	target.Transitions = target.Transitions[keep:]
+	if len(target.Transitions) > s.thresholds.FlapCount {
+		target.Status = StatusFlapping
+	}
+}
+
+func (s *StoreImpl) appendOutcome(target *TargetStatus, success bool) {
+	window := s.lossWindow()
+	if len(target.Recent) > window {
+		// loss_windowが縮小された場合は古い結果を捨てる
+		target.Recent = append([]bool(nil), target.Recent[len(target.Recent)-window:]...)
+	}
+	if len(target.Recent) < window {
+		target.Recent = append(target.Recent, success)
+		return
+	}
+	copy(target.Recent, target.Recent[1:])
+	target.Recent[len(target.Recent)-1] = success
+}
+
+// lossWindow returns the configured loss window. Callers must hold s.mu for reading.
+func (s *StoreImpl) lossWindow() int {
+	if s.thresholds.LossWindow > 0 {
+		return s.thresholds.LossWindow
+	}
+	return defaultLossWindow
+}
+
 func (s *StoreImpl) appendHistory(target *TargetStatus, rtt time.Duration, at time.Time) {
 	point := RTTPoint{Time: at, RTT: rtt}
 	if s.historySize <= 0 {
 		return
 	}
-	if len(target.History) < s.historySize {
+	limit := s.historySize
+	if s.thresholds.HistoryWindow > 0 {
+		limit = windowedHistorySize
+	}
+	if len(target.History) < limit {
 		target.History = append(target.History, point)
 		return
 	}
-	copy(target.History, target.History[1:])
-	target.History[len(target.History)-1] = point
+	// The limit may have shrunk since the last append (history_window removed on reload).
+	drop := len(target.History) - limit + 1
+	copy(target.History, target.History[drop:])
+	target.History = target.History[:limit]
+	target.History[limit-1] = point
+}
+
+// pruneHistory drops history points older than HistoryWindow. It runs on every
+// result so that a failing target's history still ages out. Callers must hold
+// s.mu for reading and the target's shard lock.
+func (s *StoreImpl) pruneHistory(target *TargetStatus, now time.Time) {
+	if s.thresholds.HistoryWindow <= 0 {
+		return
+	}
+	cutoff := now.Add(-s.thresholds.HistoryWindow)
+	i := sort.Search(len(target.History), func(i int) bool {
+		return !target.History[i].Time.Before(cutoff)
+	})
+	if i > 0 {
+		target.History = target.History[i:]
+	}
+}
+
+// appendEvents adds transitions to the bounded event buffer, keeping it in time
+// order even when concurrent updates or batches finish out of order.
+func (s *StoreImpl) appendEvents(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	for _, event := range events {
+		s.appendEvent(event)
+	}
+}
+
+// appendEvent inserts a transition by time into the bounded event buffer,
+// dropping the oldest. Callers must hold s.eventsMu.
+func (s *StoreImpl) appendEvent(event Event) {
+	if s.eventSize <= 0 {
+		return
+	}
+	i := len(s.events)
+	for i > 0 && s.events[i-1].Time.After(event.Time) {
+		i--
+	}
+	if len(s.events) >= s.eventSize {
+		if i == 0 {
+			return // older than everything kept
+		}
+		copy(s.events, s.events[1:i])
+		i--
+		s.events[i] = event
+		return
+	}
+	s.events = append(s.events, Event{})
+	copy(s.events[i+1:], s.events[i:])
+	s.events[i] = event
 }
 
 func copyTargetStatus(source *TargetStatus) TargetStatus {
@@ -165,6 +640,12 @@ func copyTargetStatus(source *TargetStatus) TargetStatus {
 	if len(source.History) > 0 {
 		clone.History = append([]RTTPoint(nil), source.History...)
 	}
+	if len(source.Recent) > 0 {
+		clone.Recent = append([]bool(nil), source.Recent...)
+	}
+	if len(source.Transitions) > 0 {
+		clone.Transitions = append([]time.Time(nil), source.Transitions...)
+	}
 	return clone
 }
 
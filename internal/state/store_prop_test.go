@@ -28,7 +28,7 @@ func TestPropertyPingResultStateUpdate(t *testing.T) {
 
 			store := NewStore([]config.TargetConfig{
 				{Name: "test", Address: "192.0.2.1"},
-			}, timeout)
+			}, timeout, Thresholds{})
 
 			// Send 10 successful pings with the same RTT to fill history
 			for i := 0; i < 10; i++ {
@@ -82,7 +82,7 @@ func TestPropertyPingResultStateUpdate(t *testing.T) {
 			timeout := 100 * time.Millisecond
 			store := NewStore([]config.TargetConfig{
 				{Name: "test", Address: "192.0.2.1"},
-			}, timeout)
+			}, timeout, Thresholds{})
 
 			// Send consecutive failures
 			for i := 0; i < failureCount; i++ {
@@ -122,7 +122,7 @@ func TestPropertyPingResultStateUpdate(t *testing.T) {
 			rtt := time.Duration(rttMs) * time.Millisecond
 			store := NewStore([]config.TargetConfig{
 				{Name: "test", Address: "192.0.2.1"},
-			}, timeout)
+			}, timeout, Thresholds{})
 
 			// Send failures
 			for i := 0; i < failureCount; i++ {
@@ -166,7 +166,7 @@ func TestPropertyPingResultStateUpdate(t *testing.T) {
 			timeout := 100 * time.Millisecond
 			store := NewStore([]config.TargetConfig{
 				{Name: "test", Address: "192.0.2.1"},
-			}, timeout)
+			}, timeout, Thresholds{})
 
 			// Send successes
 			for i := 0; i < successCount; i++ {
@@ -220,7 +220,7 @@ func TestPropertyDynamicConfigUpdate(t *testing.T) {
 				}
 			}
 
-			store := NewStore(initialTargets, timeout)
+			store := NewStore(initialTargets, timeout, Thresholds{})
 
 			// Add some history to initial targets
 			for i := 0; i < initialCount; i++ {
@@ -287,7 +287,7 @@ func TestPropertyDynamicConfigUpdate(t *testing.T) {
 				}
 			}
 
-			store := NewStore(initialTargets, timeout)
+			store := NewStore(initialTargets, timeout, Thresholds{})
 
 			// Create updated target list with some targets removed
 			remainingCount := initialCount - removeCount
@@ -341,7 +341,7 @@ func TestPropertyDynamicConfigUpdate(t *testing.T) {
 				}
 			}
 
-			store := NewStore(initialTargets, timeout)
+			store := NewStore(initialTargets, timeout, Thresholds{})
 
 			// Add history to targets
 			for i := 0; i < targetCount; i++ {
@@ -440,7 +440,7 @@ func TestPropertyHistoryPreservation(t *testing.T) {
 				}
 			}
 
-			store := NewStore(initialTargets, timeout)
+			store := NewStore(initialTargets, timeout, Thresholds{})
 
 			// Add history to all initial targets
 			for i := 0; i < totalInitial; i++ {
@@ -560,7 +560,7 @@ func TestPropertyHistoryPreservation(t *testing.T) {
 				}
 			}
 
-			store := NewStore(initialTargets, timeout)
+			store := NewStore(initialTargets, timeout, Thresholds{})
 
 			// Add initial history
 			for i := 0; i < targetCount; i++ {
@@ -1,6 +1,11 @@
 package state
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,7 +16,7 @@ import (
 func TestStoreUpdateResultSuccessAndFailure(t *testing.T) {
 	store := NewStore([]config.TargetConfig{
 		{Name: "example", Address: "192.0.2.1", Group: "group-1"},
-	}, 100*time.Millisecond)
+	}, 100*time.Millisecond, Thresholds{})
 
 	store.UpdateResult("example", ping.Result{Success: false, Error: errSentinel{}})
 	status, ok := store.GetTargetStatus("example")
@@ -45,8 +50,378 @@ func TestStoreUpdateResultSuccessAndFailure(t *testing.T) {
 	}
 }
 
+func TestStoreUpdateResultKeepsLastTTL(t *testing.T) {
+	store := NewStore([]config.TargetConfig{
+		{Name: "example", Address: "192.0.2.1"},
+	}, 100*time.Millisecond, Thresholds{})
+
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 5 * time.Millisecond, TTL: 57})
+	store.UpdateResult("example", ping.Result{Success: false, Error: errSentinel{}})
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 5 * time.Millisecond})
+
+	status, _ := store.GetTargetStatus("example")
+	if status.LastTTL != 57 {
+		t.Fatalf("expected last known TTL 57, got %d", status.LastTTL)
+	}
+}
+
+func TestStoreUpdateResultRecordsBackend(t *testing.T) {
+	store := NewStore([]config.TargetConfig{
+		{Name: "example", Address: "192.0.2.1"},
+	}, 100*time.Millisecond, Thresholds{})
+
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 5 * time.Millisecond, Backend: ping.BackendExternal})
+
+	status, _ := store.GetTargetStatus("example")
+	if status.Backend != ping.BackendExternal {
+		t.Fatalf("expected backend %q, got %q", ping.BackendExternal, status.Backend)
+	}
+}
+
+func TestStoreUpdateResultRecordsPhases(t *testing.T) {
+	store := NewStore([]config.TargetConfig{
+		{Name: "web", Address: "example.com", Probe: config.ProbeSynthetic},
+	}, 100*time.Millisecond, Thresholds{})
+
+	phases := ping.Phases{DNS: time.Millisecond, Connect: 4 * time.Millisecond}
+	store.UpdateResult("web", ping.Result{Error: errors.New("synthetic tls: handshake failed"), Backend: ping.BackendSynthetic, Phases: phases})
+
+	status, _ := store.GetTargetStatus("web")
+	if status.LastPhases != phases {
+		t.Fatalf("expected phases of the failed probe %+v, got %+v", phases, status.LastPhases)
+	}
+}
+
+func TestStoreUpdateTimingStampsTargets(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}, time.Second, Thresholds{})
+	if status, _ := store.GetTargetStatus("a"); status.Timeout != time.Second || status.Interval != 0 {
+		t.Fatalf("expected the constructor timeout only, got interval=%s timeout=%s", status.Interval, status.Timeout)
+	}
+
+	store.UpdateTiming(500*time.Millisecond, 2*time.Second)
+	store.UpdateTargets([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}, {Name: "b", Address: "192.0.2.2"}})
+	for _, name := range []string{"a", "b"} {
+		status, _ := store.GetTargetStatus(name)
+		if status.Interval != 500*time.Millisecond || status.Timeout != 2*time.Second {
+			t.Fatalf("%s: expected interval=500ms timeout=2s, got interval=%s timeout=%s", name, status.Interval, status.Timeout)
+		}
+	}
+
+	store.UpdateTimeout(3 * time.Second)
+	if status, _ := store.GetTargetStatus("b"); status.Timeout != 3*time.Second || status.Interval != 500*time.Millisecond {
+		t.Fatalf("expected UpdateTimeout to keep the interval, got interval=%s timeout=%s", status.Interval, status.Timeout)
+	}
+}
+
+func TestStoreUpdateResolvedAddress(t *testing.T) {
+	store := NewStore([]config.TargetConfig{
+		{Name: "web", Address: "web.example"},
+	}, 100*time.Millisecond, Thresholds{})
+
+	store.UpdateResolvedAddress("web", "192.0.2.1")
+	store.UpdateResolvedAddress("missing", "192.0.2.2")
+	status, _ := store.GetTargetStatus("web")
+	if status.ResolvedIP != "192.0.2.1" {
+		t.Fatalf("expected resolved IP 192.0.2.1, got %q", status.ResolvedIP)
+	}
+	if _, ok := store.GetTargetStatus("missing"); ok {
+		t.Fatalf("unknown target should not be created")
+	}
+
+	// アドレスが変わったら解決済みIPは破棄する
+	store.UpdateTargets([]config.TargetConfig{{Name: "web", Address: "web2.example"}})
+	status, _ = store.GetTargetStatus("web")
+	if status.ResolvedIP != "" {
+		t.Fatalf("expected resolved IP to be cleared on address change, got %q", status.ResolvedIP)
+	}
+}
+
+func TestStoreRecordsStatusSince(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "example", Address: "192.0.2.1"}}, 100*time.Millisecond, Thresholds{})
+
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 5 * time.Millisecond})
+	first, _ := store.GetTargetStatus("example")
+	if first.StatusSince.IsZero() {
+		t.Fatalf("expected StatusSince to be set on first transition")
+	}
+
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 5 * time.Millisecond})
+	second, _ := store.GetTargetStatus("example")
+	if !second.StatusSince.Equal(first.StatusSince) {
+		t.Fatalf("expected StatusSince to be unchanged without a transition")
+	}
+}
+
+func TestStoreUpdateTargetsCopiesExcludeMetrics(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1", ExcludeMetrics: true}}, time.Second, Thresholds{})
+	status, _ := store.GetTargetStatus("a")
+	if !status.ExcludeMetrics {
+		t.Fatalf("expected ExcludeMetrics to be copied from config")
+	}
+
+	store.UpdateTargets([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}})
+	status, _ = store.GetTargetStatus("a")
+	if status.ExcludeMetrics {
+		t.Fatalf("expected ExcludeMetrics to be cleared on reload")
+	}
+}
+
+func TestStoreUpdateTargetsCopiesLabel(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1", Label: "Alpha"}}, time.Second, Thresholds{})
+	status, _ := store.GetTargetStatus("a")
+	if status.Label != "Alpha" {
+		t.Fatalf("expected label to be copied from config, got %q", status.Label)
+	}
+
+	store.UpdateTargets([]config.TargetConfig{{Name: "a", Address: "192.0.2.1", Label: "Beta"}})
+	status, _ = store.GetTargetStatus("a")
+	if status.Label != "Beta" {
+		t.Fatalf("expected label to be updated on reload, got %q", status.Label)
+	}
+}
+
+func TestStoreLossDownThreshold(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "flappy", Address: "192.0.2.1"}}, 100*time.Millisecond, Thresholds{LossDown: 50})
+
+	// 成功と失敗を交互に繰り返す（ロス率60%）
+	pattern := []bool{false, true, false, true, false, false, true, false, true, false}
+	for _, ok := range pattern {
+		store.UpdateResult("flappy", ping.Result{Success: ok, RTT: 5 * time.Millisecond})
+	}
+	store.UpdateResult("flappy", ping.Result{Success: true, RTT: 5 * time.Millisecond})
+
+	status, _ := store.GetTargetStatus("flappy")
+	if status.RecentLossPercent() <= 50 {
+		t.Fatalf("expected recent loss above 50%%, got %.1f", status.RecentLossPercent())
+	}
+	if status.Status != StatusDown {
+		t.Fatalf("expected DOWN on high recent loss despite success, got %s", status.Status)
+	}
+
+	// 閾値未設定なら従来通り
+	store.UpdateThresholds(Thresholds{})
+	store.UpdateResult("flappy", ping.Result{Success: true, RTT: 5 * time.Millisecond})
+	status, _ = store.GetTargetStatus("flappy")
+	if status.Status != StatusOK {
+		t.Fatalf("expected OK without loss_down_threshold, got %s", status.Status)
+	}
+}
+
+func TestStoreLossDownThresholdNeedsSamples(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "new", Address: "192.0.2.1"}}, 100*time.Millisecond, Thresholds{LossDown: 10})
+	store.UpdateResult("new", ping.Result{Success: false})
+	store.UpdateResult("new", ping.Result{Success: true, RTT: 5 * time.Millisecond})
+
+	status, _ := store.GetTargetStatus("new")
+	if status.Status != StatusOK {
+		t.Fatalf("expected OK before enough samples, got %s", status.Status)
+	}
+}
+
+func TestStoreFlapDetection(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "flappy", Address: "192.0.2.1"}}, 100*time.Millisecond, Thresholds{FlapCount: 2, FlapWindow: 50 * time.Millisecond})
+	ok := ping.Result{Success: true, RTT: time.Millisecond}
+	fail := ping.Result{Success: false}
+
+	// UNKNOWN -> OK is not a flap; OK -> WARN -> OK are two changes, still allowed.
+	for _, result := range []ping.Result{ok, fail, ok} {
+		store.UpdateResult("flappy", result)
+	}
+	if status, _ := store.GetTargetStatus("flappy"); status.Status != StatusOK {
+		t.Fatalf("expected OK after two changes, got %s", status.Status)
+	}
+
+	store.UpdateResult("flappy", fail)
+	if status, _ := store.GetTargetStatus("flappy"); status.Status != StatusFlapping {
+		t.Fatalf("expected FLAPPING after a third change, got %s", status.Status)
+	}
+	events := len(store.RecentEvents(0))
+	store.UpdateResult("flappy", ok)
+	store.UpdateResult("flappy", fail)
+	if got := len(store.RecentEvents(0)); got != events {
+		t.Fatalf("expected transitions to be suppressed while flapping, got %d new events", got-events)
+	}
+
+	// Once the window has passed the target settles on its classified status.
+	time.Sleep(60 * time.Millisecond)
+	store.UpdateResult("flappy", ok)
+	status, _ := store.GetTargetStatus("flappy")
+	if status.Status != StatusOK {
+		t.Fatalf("expected OK once changes age out of the window, got %s", status.Status)
+	}
+	if latest := store.RecentEvents(1)[0]; latest.From != StatusFlapping || latest.To != StatusOK {
+		t.Fatalf("expected FLAPPING -> OK event, got %s -> %s", latest.From, latest.To)
+	}
+}
+
+func TestStoreMute(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}, 100*time.Millisecond, Thresholds{})
+	fail := ping.Result{Success: false}
+	store.UpdateResult("a", ping.Result{Success: true, RTT: time.Millisecond})
+	events := len(store.RecentEvents(0))
+
+	if !store.SetMuted("a", true) {
+		t.Fatalf("expected SetMuted to find the target")
+	}
+	for i := 0; i < 5; i++ {
+		store.UpdateResult("a", fail)
+	}
+	status, _ := store.GetTargetStatus("a")
+	if status.Status != StatusMuted || !status.Muted {
+		t.Fatalf("expected MUTED while muted, got %s", status.Status)
+	}
+	if status.TotalFailure != 5 || status.ConsecutiveNG != 5 {
+		t.Fatalf("expected probing to continue while muted, got %+v", status)
+	}
+	if got := len(store.RecentEvents(0)); got != events {
+		t.Fatalf("expected no transitions while muted, got %d new events", got-events)
+	}
+
+	// ミュート解除で判定済みのステータスに戻る
+	store.SetMuted("a", false)
+	if status, _ := store.GetTargetStatus("a"); status.Status != StatusDown {
+		t.Fatalf("expected DOWN after unmuting, got %s", status.Status)
+	}
+	if store.SetMuted("missing", true) {
+		t.Fatalf("expected SetMuted to report an unknown target")
+	}
+}
+
+func TestStoreUpdateTargetsAppliesMuteOption(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1", Muted: true}}, 100*time.Millisecond, Thresholds{})
+	if status, _ := store.GetTargetStatus("a"); status.Status != StatusMuted {
+		t.Fatalf("expected mute=true to start MUTED, got %s", status.Status)
+	}
+
+	store.UpdateTargets([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}})
+	if status, _ := store.GetTargetStatus("a"); status.Status != StatusUnknown || status.Muted {
+		t.Fatalf("expected reload without mute to unmute, got %s", status.Status)
+	}
+}
+
+func TestStoreFlapDetectionDisabledByDefault(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}, 100*time.Millisecond, Thresholds{})
+	for i := 0; i < 10; i++ {
+		store.UpdateResult("a", ping.Result{Success: i%2 == 0, RTT: time.Millisecond})
+	}
+	if status, _ := store.GetTargetStatus("a"); status.Status == StatusFlapping {
+		t.Fatalf("expected flap detection to be disabled without flap_threshold")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	history := make([]RTTPoint, 0, 20)
+	for i := 20; i >= 1; i-- {
+		history = append(history, RTTPoint{RTT: time.Duration(i) * time.Millisecond})
+	}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 0, want: 1 * time.Millisecond},
+		{p: 50, want: 10 * time.Millisecond},
+		{p: 95, want: 19 * time.Millisecond},
+		{p: 100, want: 20 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := Percentile(history, tt.p); got != tt.want {
+			t.Errorf("Percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := Percentile(nil, 95); got != 0 {
+		t.Errorf("expected 0 for empty history, got %v", got)
+	}
+	if history[0].RTT != 20*time.Millisecond {
+		t.Errorf("expected Percentile to leave history unsorted")
+	}
+}
+
+func TestJitter(t *testing.T) {
+	points := func(ms ...int) []RTTPoint {
+		out := make([]RTTPoint, len(ms))
+		for i, v := range ms {
+			out[i] = RTTPoint{RTT: time.Duration(v) * time.Millisecond}
+		}
+		return out
+	}
+	tests := []struct {
+		name    string
+		history []RTTPoint
+		want    time.Duration
+	}{
+		{name: "empty", history: nil, want: 0},
+		{name: "single point", history: points(40), want: 0},
+		{name: "constant", history: points(10, 10, 10), want: 0},
+		{name: "spread", history: points(2, 4, 4, 4, 5, 5, 7, 9), want: 2 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := Jitter(tt.history); got != tt.want {
+			t.Errorf("%s: Jitter() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecentLossPercentWindow(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}, 100*time.Millisecond, Thresholds{})
+	for i := 0; i < defaultLossWindow; i++ {
+		store.UpdateResult("a", ping.Result{Success: false})
+	}
+	for i := 0; i < defaultLossWindow; i++ {
+		store.UpdateResult("a", ping.Result{Success: true, RTT: time.Millisecond})
+	}
+	status, _ := store.GetTargetStatus("a")
+	if len(status.Recent) != defaultLossWindow {
+		t.Fatalf("expected window of %d outcomes, got %d", defaultLossWindow, len(status.Recent))
+	}
+	if status.RecentLossPercent() != 0 {
+		t.Fatalf("expected old failures to age out, got %.1f%%", status.RecentLossPercent())
+	}
+	if status.LossPercent() != 50 {
+		t.Fatalf("expected lifetime loss 50%%, got %.1f%%", status.LossPercent())
+	}
+}
+
+func TestGetTargetStatusCopiesRecent(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}, 100*time.Millisecond, Thresholds{})
+	for i := 0; i < defaultLossWindow; i++ {
+		store.UpdateResult("a", ping.Result{Success: true, RTT: time.Millisecond})
+	}
+	status, _ := store.GetTargetStatus("a")
+	snapshot := store.GetSnapshot()
+
+	// A full window is rewritten in place; the copies must not see it.
+	store.UpdateResult("a", ping.Result{Success: false})
+	if status.RecentLossPercent() != 0 {
+		t.Fatalf("expected the GetTargetStatus copy to keep its window, got %.1f%%", status.RecentLossPercent())
+	}
+	if snapshot[0].RecentLossPercent() != 0 {
+		t.Fatalf("expected the GetSnapshot copy to keep its window, got %.1f%%", snapshot[0].RecentLossPercent())
+	}
+}
+
+func TestStoreLossWindow(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}, 100*time.Millisecond, Thresholds{LossWindow: 4})
+	for _, success := range []bool{false, false, true, false, true, true} {
+		store.UpdateResult("a", ping.Result{Success: success, RTT: time.Millisecond})
+	}
+	// Only the last four outcomes (true, false, true, true) count.
+	if got := store.RecentLossPercent("a"); got != 25 {
+		t.Fatalf("expected 25%% loss over the last 4 probes, got %.1f%%", got)
+	}
+	if got := store.RecentLossPercent("missing"); got != 0 {
+		t.Fatalf("expected 0 for unknown target, got %.1f%%", got)
+	}
+
+	store.UpdateThresholds(Thresholds{LossWindow: 2})
+	store.UpdateResult("a", ping.Result{Success: false})
+	status, _ := store.GetTargetStatus("a")
+	if len(status.Recent) != 2 || store.RecentLossPercent("a") != 50 {
+		t.Fatalf("expected the window to shrink to 2 outcomes, got %v", status.Recent)
+	}
+}
+
 func TestStoreHistorySize(t *testing.T) {
-	store := NewStore([]config.TargetConfig{{Name: "example"}}, 100*time.Millisecond)
+	store := NewStore([]config.TargetConfig{{Name: "example"}}, 100*time.Millisecond, Thresholds{})
 	store.historySize = 2
 
 	store.UpdateResult("example", ping.Result{Success: true, RTT: 10 * time.Millisecond})
@@ -62,8 +437,44 @@ func TestStoreHistorySize(t *testing.T) {
 	}
 }
 
+func TestStoreHistoryWindow(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "example"}}, 100*time.Millisecond, Thresholds{HistoryWindow: time.Hour})
+	store.historySize = 2
+
+	now := time.Now()
+	store.shardFor("example").targets["example"].History = []RTTPoint{
+		{Time: now.Add(-2 * time.Hour), RTT: 1 * time.Millisecond},
+		{Time: now.Add(-90 * time.Minute), RTT: 2 * time.Millisecond},
+		{Time: now.Add(-30 * time.Minute), RTT: 3 * time.Millisecond},
+	}
+
+	// 失敗時も古いデータポイントは削除される
+	store.UpdateResult("example", ping.Result{Success: false})
+	status, _ := store.GetTargetStatus("example")
+	if len(status.History) != 1 || status.History[0].RTT != 3*time.Millisecond {
+		t.Fatalf("expected only the point inside the window, got %+v", status.History)
+	}
+
+	// history_window指定時は件数上限（historySize）を超えて保持する
+	for i := 0; i < 3; i++ {
+		store.UpdateResult("example", ping.Result{Success: true, RTT: 10 * time.Millisecond})
+	}
+	status, _ = store.GetTargetStatus("example")
+	if len(status.History) != 4 {
+		t.Fatalf("expected 4 points within the window, got %d", len(status.History))
+	}
+
+	// 件数ベースに戻すと次の追加で上限まで切り詰める
+	store.UpdateThresholds(Thresholds{})
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 20 * time.Millisecond})
+	status, _ = store.GetTargetStatus("example")
+	if len(status.History) != 2 || status.History[1].RTT != 20*time.Millisecond {
+		t.Fatalf("expected history trimmed to 2 points, got %+v", status.History)
+	}
+}
+
 func TestStoreUpdateTargetsKeepsHistory(t *testing.T) {
-	store := NewStore([]config.TargetConfig{{Name: "example", Address: "192.0.2.1"}}, 100*time.Millisecond)
+	store := NewStore([]config.TargetConfig{{Name: "example", Address: "192.0.2.1"}}, 100*time.Millisecond, Thresholds{})
 	store.UpdateResult("example", ping.Result{Success: true, RTT: 10 * time.Millisecond})
 
 	store.UpdateTargets([]config.TargetConfig{
@@ -92,7 +503,7 @@ func TestStoreUpdateResultRTTThresholds(t *testing.T) {
 	timeout := 100 * time.Millisecond
 	store := NewStore([]config.TargetConfig{
 		{Name: "example", Address: "192.0.2.1"},
-	}, timeout)
+	}, timeout, Thresholds{})
 
 	// 直近10個のデータポイントで平均を計算するため、同じRTT値を10回送信して
 	// Historyを満たしてからテストを実行する
@@ -150,6 +561,342 @@ func TestStoreUpdateResultRTTThresholds(t *testing.T) {
 	}
 }
 
+func TestStoreUpdateResultExplicitThresholds(t *testing.T) {
+	// timeoutとは無関係にok_threshold/warn_thresholdで判定する
+	store := NewStore([]config.TargetConfig{
+		{Name: "example", Address: "192.0.2.1"},
+	}, 1*time.Second, Thresholds{OK: 100 * time.Millisecond, Warn: 300 * time.Millisecond})
+
+	for i := 0; i < 10; i++ {
+		store.UpdateResult("example", ping.Result{Success: true, RTT: 90 * time.Millisecond})
+	}
+	status, _ := store.GetTargetStatus("example")
+	if status.Status != StatusOK {
+		t.Fatalf("expected OK for avg RTT 90ms with ok_threshold 100ms, got %s", status.Status)
+	}
+
+	for i := 0; i < 10; i++ {
+		store.UpdateResult("example", ping.Result{Success: true, RTT: 150 * time.Millisecond})
+	}
+	status, _ = store.GetTargetStatus("example")
+	if status.Status != StatusWarn {
+		t.Fatalf("expected WARN for avg RTT 150ms with ok_threshold 100ms, got %s", status.Status)
+	}
+}
+
+func TestStoreRTTDownThreshold(t *testing.T) {
+	store := NewStore([]config.TargetConfig{
+		{Name: "example", Address: "192.0.2.1"},
+	}, 1*time.Second, Thresholds{RTTDown: 400 * time.Millisecond})
+
+	for i := 0; i < 10; i++ {
+		store.UpdateResult("example", ping.Result{Success: true, RTT: 450 * time.Millisecond})
+	}
+	status, _ := store.GetTargetStatus("example")
+	if status.Status != StatusDown {
+		t.Fatalf("expected DOWN for avg RTT 450ms with rtt_down_threshold 400ms, got %s", status.Status)
+	}
+	if status.ConsecutiveOK != 10 || status.TotalSuccess != 10 {
+		t.Fatalf("expected probes to still count as successes, got %+v", status)
+	}
+
+	// 平均が閾値を下回れば通常の判定に戻る
+	for i := 0; i < 10; i++ {
+		store.UpdateResult("example", ping.Result{Success: true, RTT: 300 * time.Millisecond})
+	}
+	status, _ = store.GetTargetStatus("example")
+	if status.Status != StatusWarn {
+		t.Fatalf("expected WARN once avg RTT drops below rtt_down_threshold, got %s", status.Status)
+	}
+
+	// 未設定時はWARNが上限
+	store.UpdateThresholds(Thresholds{})
+	for i := 0; i < 10; i++ {
+		store.UpdateResult("example", ping.Result{Success: true, RTT: 900 * time.Millisecond})
+	}
+	status, _ = store.GetTargetStatus("example")
+	if status.Status != StatusWarn {
+		t.Fatalf("expected WARN without rtt_down_threshold, got %s", status.Status)
+	}
+}
+
+func TestStoreFailureThresholds(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "example"}}, 100*time.Millisecond, Thresholds{WarnNG: 2, DownNG: 5})
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 10 * time.Millisecond})
+
+	want := []Status{StatusOK, StatusWarn, StatusWarn, StatusWarn, StatusDown}
+	for i, expected := range want {
+		store.UpdateResult("example", ping.Result{Success: false, Error: errSentinel{}})
+		if status, _ := store.GetTargetStatus("example"); status.Status != expected {
+			t.Fatalf("failure %d: expected %s, got %s", i+1, expected, status.Status)
+		}
+	}
+
+	// A single failure after recovery is tolerated again.
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 10 * time.Millisecond})
+	store.UpdateResult("example", ping.Result{Success: false, Error: errSentinel{}})
+	if status, _ := store.GetTargetStatus("example"); status.Status != StatusOK {
+		t.Fatalf("expected OK after one tolerated failure, got %s", status.Status)
+	}
+
+	// A target that never answered stays UNKNOWN until warn_threshold_ng.
+	store.UpdateTargets([]config.TargetConfig{{Name: "example"}, {Name: "new"}})
+	store.UpdateResult("new", ping.Result{Success: false, Error: errSentinel{}})
+	if status, _ := store.GetTargetStatus("new"); status.Status != StatusUnknown {
+		t.Fatalf("expected UNKNOWN after one failure, got %s", status.Status)
+	}
+}
+
+func TestStoreUpdateThresholds(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "example"}}, 100*time.Millisecond, Thresholds{})
+
+	// timeoutの25%（25ms）を超えるためWARN
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 40 * time.Millisecond})
+	status, _ := store.GetTargetStatus("example")
+	if status.Status != StatusWarn {
+		t.Fatalf("expected WARN with relative thresholds, got %s", status.Status)
+	}
+
+	store.UpdateThresholds(Thresholds{OK: 50 * time.Millisecond})
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 40 * time.Millisecond})
+	status, _ = store.GetTargetStatus("example")
+	if status.Status != StatusOK {
+		t.Fatalf("expected OK after raising ok_threshold, got %s", status.Status)
+	}
+}
+
+func TestStoreExportHistoryCSV(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "example"}}, 100*time.Millisecond, Thresholds{})
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 12500 * time.Microsecond})
+	store.UpdateResult("example", ping.Result{Success: false, Error: errSentinel{}})
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 3 * time.Millisecond})
+
+	var buf bytes.Buffer
+	if err := store.ExportHistoryCSV("example", &buf); err != nil {
+		t.Fatalf("ExportHistoryCSV error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d lines: %q", len(lines), buf.String())
+	}
+	if lines[0] != "timestamp,rtt_ms" {
+		t.Fatalf("unexpected header: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], ",12.500") || !strings.HasSuffix(lines[2], ",3.000") {
+		t.Fatalf("unexpected rows: %q", lines[1:])
+	}
+	if _, err := time.Parse(time.RFC3339Nano, strings.Split(lines[1], ",")[0]); err != nil {
+		t.Fatalf("expected RFC3339 timestamp, got %q: %v", lines[1], err)
+	}
+}
+
+func TestStoreExportHistoryCSVEmptyHistory(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "example"}}, 100*time.Millisecond, Thresholds{})
+
+	var buf bytes.Buffer
+	if err := store.ExportHistoryCSV("example", &buf); err != nil {
+		t.Fatalf("ExportHistoryCSV error: %v", err)
+	}
+	if buf.String() != "timestamp,rtt_ms\n" {
+		t.Fatalf("expected header only, got %q", buf.String())
+	}
+}
+
+func TestStoreExportHistoryCSVUnknownTarget(t *testing.T) {
+	store := NewStore(nil, 100*time.Millisecond, Thresholds{})
+
+	var buf bytes.Buffer
+	if err := store.ExportHistoryCSV("missing", &buf); err == nil {
+		t.Fatalf("expected error for unknown target")
+	}
+}
+
+func TestStoreRecordsStatusTransitions(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "example"}}, 100*time.Millisecond, Thresholds{})
+
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 10 * time.Millisecond})
+	store.UpdateResult("example", ping.Result{Success: true, RTT: 10 * time.Millisecond})
+	store.UpdateResult("example", ping.Result{Success: false, Error: errSentinel{}})
+	store.UpdateResult("example", ping.Result{Success: false, Error: errSentinel{}})
+	store.UpdateResult("example", ping.Result{Success: false, Error: errSentinel{}})
+
+	events := store.RecentEvents(0)
+	expected := []struct{ from, to Status }{
+		{StatusWarn, StatusDown},
+		{StatusOK, StatusWarn},
+		{StatusUnknown, StatusOK},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %+v", len(expected), len(events), events)
+	}
+	for i, want := range expected {
+		if events[i].Target != "example" || events[i].From != want.from || events[i].To != want.to {
+			t.Fatalf("event %d: expected %s -> %s, got %+v", i, want.from, want.to, events[i])
+		}
+		if events[i].Time.IsZero() {
+			t.Fatalf("event %d: expected timestamp", i)
+		}
+	}
+
+	if limited := store.RecentEvents(1); len(limited) != 1 || limited[0].To != StatusDown {
+		t.Fatalf("expected newest event only, got %+v", limited)
+	}
+}
+
+func TestStoreEventBufferIsBounded(t *testing.T) {
+	store := NewStore([]config.TargetConfig{{Name: "example"}}, 100*time.Millisecond, Thresholds{})
+	store.eventSize = 3
+
+	for i := 0; i < 5; i++ {
+		store.UpdateResult("example", ping.Result{Success: true, RTT: 10 * time.Millisecond})
+		store.UpdateResult("example", ping.Result{Success: false, Error: errSentinel{}})
+	}
+
+	events := store.RecentEvents(0)
+	if len(events) != 3 {
+		t.Fatalf("expected buffer capped at 3 events, got %d", len(events))
+	}
+	if events[0].From != StatusOK || events[0].To != StatusWarn {
+		t.Fatalf("expected newest event OK -> WARN, got %+v", events[0])
+	}
+}
+
+func TestStoreUpdateResultsAppliesBatchInOrder(t *testing.T) {
+	targets := []config.TargetConfig{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	store := NewStore(targets, 100*time.Millisecond, Thresholds{})
+
+	start := time.Now().Add(-time.Minute)
+	var updates []ResultUpdate
+	for i := 0; i < 3; i++ {
+		at := start.Add(time.Duration(i) * time.Second)
+		updates = append(updates,
+			ResultUpdate{Name: "a", Result: ping.Result{Success: false, Error: errSentinel{}}, At: at},
+			ResultUpdate{Name: "b", Result: ping.Result{Success: true, RTT: 10 * time.Millisecond}, At: at},
+		)
+	}
+	store.UpdateResults(updates)
+
+	a, _ := store.GetTargetStatus("a")
+	if a.Status != StatusDown || a.ConsecutiveNG != 3 || !a.LastFailureAt.Equal(start.Add(2*time.Second)) {
+		t.Fatalf("expected a DOWN after 3 failures at the probe times, got %+v", a)
+	}
+	b, _ := store.GetTargetStatus("b")
+	if b.Status != StatusOK || len(b.History) != 3 || !b.History[0].Time.Equal(start) {
+		t.Fatalf("expected b OK with history at the probe times, got %+v", b)
+	}
+	if c, _ := store.GetTargetStatus("c"); c.Status != StatusUnknown {
+		t.Fatalf("expected c untouched, got %s", c.Status)
+	}
+
+	// Events from different shards are merged in time order, newest first.
+	events := store.RecentEvents(0)
+	for i := 1; i < len(events); i++ {
+		if events[i].Time.After(events[i-1].Time) {
+			t.Fatalf("expected events newest first, got %+v", events)
+		}
+	}
+	if len(events) != 3 || events[0].Target != "a" || events[0].To != StatusDown {
+		t.Fatalf("expected a -> DOWN as the newest of 3 events, got %+v", events)
+	}
+}
+
+func TestStoreEventBufferKeepsTimeOrder(t *testing.T) {
+	store := NewStore(nil, 100*time.Millisecond, Thresholds{})
+	store.eventSize = 2
+	now := time.Now()
+
+	store.appendEvents([]Event{{Time: now, Target: "b"}, {Time: now.Add(-time.Second), Target: "a"}})
+	store.appendEvents([]Event{{Time: now.Add(time.Second), Target: "c"}})
+	store.appendEvents([]Event{{Time: now.Add(-time.Hour), Target: "old"}})
+
+	events := store.RecentEvents(0)
+	if len(events) != 2 || events[0].Target != "c" || events[1].Target != "b" {
+		t.Fatalf("expected c, b, got %+v", events)
+	}
+}
+
+func TestStoreConcurrentAccess(t *testing.T) {
+	var targets []config.TargetConfig
+	for i := 0; i < 50; i++ {
+		targets = append(targets, config.TargetConfig{Name: fmt.Sprintf("t%d", i)})
+	}
+	store := NewStore(targets, 100*time.Millisecond, Thresholds{})
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				name := targets[(w*13+i)%len(targets)].Name
+				if i%2 == 0 {
+					store.UpdateResult(name, ping.Result{Success: true, RTT: time.Millisecond})
+				} else {
+					store.UpdateResults([]ResultUpdate{{Name: name, Result: ping.Result{Success: false}}})
+				}
+			}
+		}(w)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			store.GetSnapshot()
+			store.RecentEvents(10)
+			store.UpdateTargets(targets)
+			store.UpdateThresholds(Thresholds{})
+		}
+	}()
+	wg.Wait()
+
+	total := 0
+	for _, target := range store.GetSnapshot() {
+		total += target.TotalSuccess + target.TotalFailure
+	}
+	if total != 800 {
+		t.Fatalf("expected 800 recorded results, got %d", total)
+	}
+}
+
+func TestStoreReset(t *testing.T) {
+	store := NewStore([]config.TargetConfig{
+		{Name: "a", Address: "192.0.2.1", Group: "g", Label: "A"},
+		{Name: "b", Address: "192.0.2.2", Muted: true},
+	}, 100*time.Millisecond, Thresholds{})
+	store.UpdateTiming(time.Second, 100*time.Millisecond)
+	for _, name := range []string{"a", "b"} {
+		store.UpdateResult(name, ping.Result{Success: true, RTT: 10 * time.Millisecond})
+		store.UpdateResult(name, ping.Result{Success: false, Error: errSentinel{}})
+	}
+	eventsBefore := len(store.RecentEvents(0))
+
+	store.Reset("a")
+	store.Reset("b")
+	store.Reset("missing")
+
+	a, _ := store.GetTargetStatus("a")
+	if a.Status != StatusUnknown || a.TotalSuccess != 0 || a.TotalFailure != 0 ||
+		a.ConsecutiveNG != 0 || len(a.History) != 0 || len(a.Recent) != 0 || a.LastRTT != 0 {
+		t.Fatalf("expected a cleared, got %+v", a)
+	}
+	if a.Address != "192.0.2.1" || a.Group != "g" || a.Label != "A" || a.Interval != time.Second || a.Timeout != 100*time.Millisecond {
+		t.Fatalf("expected a's configuration kept, got %+v", a)
+	}
+	if b, _ := store.GetTargetStatus("b"); b.Status != StatusMuted || !b.Muted || b.TotalFailure != 0 {
+		t.Fatalf("expected b cleared but still muted, got %+v", b)
+	}
+	if len(store.RecentEvents(0)) != eventsBefore {
+		t.Fatalf("expected reset not to record events")
+	}
+
+	// After a reset the target is classified from scratch.
+	store.UpdateResult("a", ping.Result{Success: true, RTT: 10 * time.Millisecond})
+	if a, _ := store.GetTargetStatus("a"); a.Status != StatusOK || a.TotalSuccess != 1 {
+		t.Fatalf("expected a OK after its first result, got %+v", a)
+	}
+}
+
 type errSentinel struct{}
 
 func (errSentinel) Error() string {
@@ -3,12 +3,15 @@ package ui
 import (
 	"context"
 	"fmt"
+	"io"
 	"math"
+	"os"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/doridoridoriand/surveiller/internal/config"
+	"github.com/doridoridoriand/surveiller/internal/ping"
 	"github.com/doridoridoriand/surveiller/internal/state"
 	"github.com/gdamore/tcell/v2"
 )
@@ -16,18 +19,95 @@ import (
 const (
 	uiRefreshInterval = 500 * time.Millisecond
 	minBoxHeight      = 4
+	minWidth          = 20 // below this only the "too small" notice is drawn
+	minHeight         = 5
+	compactWidth      = 60 // below this targets are listed without boxes
+	compactHeight     = 8
+	minEventsHeight   = 5
+	flashDuration     = 3 * time.Second
+	downTrendSize     = 60 // refresh cycles kept for the DOWN count sparkline
 )
 
+// intervalSteps are the probe intervals the +/- keys step through.
+var intervalSteps = []time.Duration{
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+}
+
 // UI renders a TUI view of target status.
 type UI struct {
 	cfg      config.GlobalOptions
 	state    state.Store
 	reloadCh chan<- struct{}
+	timing   TimingSetter
+
+	selected   string // name of the highlighted target
+	detail     bool   // whether the detail view of the selected target is open
+	message    string // feedback line shown in the detail view
+	showEvents bool   // whether the status transition pane is visible
+	hideAddr   bool   // whether the address column is hidden to widen the RTT bar
+	downTrend  []int  // DOWN target counts of recent refresh cycles, oldest first
+	title      string // terminal title last set for ui.set_title
+
+	reloadResults  <-chan ReloadResult
+	configLoadedAt time.Time // when the running config was loaded, shown on the settings row
+	flash          string    // transient header message, e.g. the last reload outcome
+	flashErr       bool      // whether flash reports a failure
+	flashUntil     time.Time // when flash stops being shown
+}
+
+// ReloadResult reports the outcome of a configuration reload back to the UI.
+type ReloadResult struct {
+	Targets  int
+	Skipped  int           // invalid target lines skipped with --lenient
+	LoadedAt time.Time     // when the reloaded config was applied
+	Interval time.Duration // probe interval in effect after the reload
+	Timeout  time.Duration // probe timeout in effect after the reload
+	Err      error
+}
+
+// historyExporter is implemented by stores that can dump RTT history as CSV.
+type historyExporter interface {
+	ExportHistoryCSV(name string, w io.Writer) error
+}
+
+// TimingSetter changes the live probe interval and timeout, e.g. the scheduler.
+type TimingSetter interface {
+	SetTiming(interval, timeout time.Duration)
+}
+
+// muter is implemented by stores that can mute targets at runtime.
+type muter interface {
+	SetMuted(name string, muted bool) bool
 }
 
 // New returns a UI instance.
 func New(cfg config.GlobalOptions, store state.Store, reloadCh chan<- struct{}) *UI {
-	return &UI{cfg: cfg, state: store, reloadCh: reloadCh}
+	return &UI{cfg: cfg, state: store, reloadCh: reloadCh, hideAddr: cfg.UIHideAddress}
+}
+
+// SetReloadResults sets the channel on which reload outcomes are delivered so
+// they can be flashed in the header.
+func (u *UI) SetReloadResults(ch <-chan ReloadResult) {
+	u.reloadResults = ch
+}
+
+// SetConfigLoadedAt sets the load time of the running config shown on the
+// settings row. Successful reloads update it from ReloadResult.LoadedAt.
+func (u *UI) SetConfigLoadedAt(t time.Time) {
+	u.configLoadedAt = t
+}
+
+// SetTimingControl enables the +/- keys, which step the probe interval of t.
+func (u *UI) SetTimingControl(t TimingSetter) {
+	u.timing = t
 }
 
 // Run blocks until the context is cancelled or the user quits.
@@ -62,7 +142,7 @@ func (u *UI) Run(ctx context.Context) error {
 	ticker := time.NewTicker(uiRefreshInterval)
 	defer ticker.Stop()
 
-	u.render(screen, u.state.GetSnapshot())
+	u.refresh(screen)
 	for {
 		select {
 		case <-ctx.Done():
@@ -73,43 +153,141 @@ func (u *UI) Run(ctx context.Context) error {
 				if ev.Key() == tcell.KeyCtrlC || ev.Rune() == 'q' {
 					return context.Canceled
 				}
-				if ev.Rune() == 'r' || ev.Rune() == 'R' {
-					u.requestReload()
-				}
+				u.handleKey(ev, u.state.GetSnapshot())
+				u.render(screen, u.state.GetSnapshot())
 			case *tcell.EventResize:
 				screen.Sync()
 			}
-		case <-ticker.C:
+		case result := <-u.reloadResults:
+			u.showReloadResult(result, time.Now())
 			u.render(screen, u.state.GetSnapshot())
+		case <-ticker.C:
+			u.refresh(screen)
+		}
+	}
+}
+
+// refresh samples the DOWN count for the trend sparkline and redraws. Key
+// presses and reload results redraw without sampling so the trend advances
+// once per refresh cycle.
+func (u *UI) refresh(screen tcell.Screen) {
+	snapshot := u.state.GetSnapshot()
+	u.recordDownCount(snapshot)
+	u.updateTitle(screen)
+	u.render(screen, snapshot)
+}
+
+// updateTitle puts the current DOWN count into the terminal title for
+// ui.set_title. The title is only written when it changes; tcell sends it
+// only to terminals whose terminfo describes a title sequence.
+func (u *UI) updateTitle(screen tcell.Screen) {
+	if !u.cfg.UISetTitle || len(u.downTrend) == 0 {
+		return
+	}
+	title := terminalTitle(u.downTrend[len(u.downTrend)-1])
+	if title == u.title {
+		return
+	}
+	screen.SetTitle(title)
+	u.title = title
+}
+
+func terminalTitle(down int) string {
+	if down == 0 {
+		return "surveiller: OK"
+	}
+	return fmt.Sprintf("surveiller: %d DOWN", down)
+}
+
+func (u *UI) recordDownCount(snapshot []state.TargetStatus) {
+	down := 0
+	for _, target := range snapshot {
+		if target.Status == state.StatusDown {
+			down++
 		}
 	}
+	if len(u.downTrend) == downTrendSize {
+		copy(u.downTrend, u.downTrend[1:])
+		u.downTrend = u.downTrend[:downTrendSize-1]
+	}
+	u.downTrend = append(u.downTrend, down)
 }
 
 func (u *UI) render(screen tcell.Screen, snapshot []state.TargetStatus) {
 	screen.Clear()
 	width, height := screen.Size()
-	if width < 20 || height < 5 {
+	if width < minWidth || height < minHeight {
+		drawCentered(screen, width, height, fmt.Sprintf("terminal too small (need %dx%d)", minWidth, minHeight))
 		screen.Show()
 		return
 	}
 
-	now := time.Now().Format("2006-01-02 15:04:05")
-	header := fmt.Sprintf(" surveiller  %s  (q to quit, r to reload)", now)
-	drawText(screen, 0, 0, width, header, tcell.StyleDefault.Bold(true))
+	if u.detail {
+		if target, ok := findTarget(snapshot, u.selected); ok {
+			u.renderDetail(screen, width, height, target)
+			screen.Show()
+			return
+		}
+		u.detail = false
+	}
+
+	now := time.Now()
+	if width < compactWidth || height < compactHeight {
+		u.renderCompact(screen, width, height, snapshot, now)
+		screen.Show()
+		return
+	}
+
+	if flash, style, ok := u.activeFlash(now); ok {
+		header := fmt.Sprintf(" surveiller  %s  ", now.Format("2006-01-02 15:04:05"))
+		drawText(screen, 0, 0, width, header, tcell.StyleDefault.Bold(true))
+		drawText(screen, len(header), 0, width-len(header), flash, style)
+	} else {
+		header := fmt.Sprintf(" surveiller  %s  (q to quit, r to reload, enter for details, v for events, a for address, m to mute)", now.Format("2006-01-02 15:04:05"))
+		drawText(screen, 0, 0, width, header, tcell.StyleDefault.Bold(true))
+	}
 
 	// 設定情報を2行目に表示
 	configInfo := formatConfigInfo(u.cfg)
-	drawText(screen, 0, 1, width, configInfo, tcell.StyleDefault.Foreground(tcell.ColorGray))
+	if !u.configLoadedAt.IsZero() {
+		configInfo += "  loaded=" + u.configLoadedAt.Format("15:04:05")
+	}
+	drawText(screen, 0, 1, width, configInfo, u.style(tcell.StyleDefault.Foreground(tcell.ColorGray)))
+	// DOWN数の推移を設定情報の右側に表示（幅が足りなければ省略）
+	if trend := u.formatDownTrend(); trend != "" {
+		trendWidth := len([]rune(trend))
+		if x := width - trendWidth; x > len(configInfo)+1 {
+			drawText(screen, x, 1, trendWidth, trend, u.style(tcell.StyleDefault.Foreground(tcell.ColorRed)))
+		}
+	}
 
-	groups := groupTargets(snapshot)
+	// 最終行はサマリーフッター用に常に確保する
+	bottom := height - 1
+	drawText(screen, 0, bottom, width, formatFooter(snapshot), u.style(tcell.StyleDefault.Foreground(tcell.ColorGray)))
+	if u.showEvents {
+		eventsHeight := maxInt(minEventsHeight, bottom/3)
+		if eventsHeight > bottom-2 {
+			eventsHeight = bottom - 2
+		}
+		bottom -= eventsHeight
+		u.drawEventsBox(screen, 0, bottom, width, eventsHeight, u.state.RecentEvents(eventsHeight-2))
+	}
+
+	if len(snapshot) == 0 {
+		drawCentered(screen, width, height, noTargetsMessage)
+		screen.Show()
+		return
+	}
+
+	groups := groupTargets(snapshot, u.cfg.DefaultGroup)
 	y := 2
 	for _, group := range groups {
-		if height-y < minBoxHeight {
+		if bottom-y < minBoxHeight {
 			break
 		}
 		boxHeight := len(group.Targets) + 3
-		if boxHeight > height-y {
-			boxHeight = height - y
+		if boxHeight > bottom-y {
+			boxHeight = bottom - y
 		}
 		u.drawGroupBox(screen, 0, y, width, boxHeight, group)
 		y += boxHeight
@@ -118,6 +296,382 @@ func (u *UI) render(screen tcell.Screen, snapshot []state.TargetStatus) {
 	screen.Show()
 }
 
+// renderCompact lists one target per line without boxes, for terminals too
+// cramped for the regular layout.
+func (u *UI) renderCompact(screen tcell.Screen, width, height int, snapshot []state.TargetStatus, now time.Time) {
+	header := fmt.Sprintf(" surveiller  %s  ", now.Format("15:04:05"))
+	drawText(screen, 0, 0, width, header, tcell.StyleDefault.Bold(true))
+	if flash, style, ok := u.activeFlash(now); ok {
+		drawText(screen, len(header), 0, width-len(header), flash, style)
+	}
+
+	bottom := height - 1
+	drawText(screen, 0, bottom, width, formatFooter(snapshot), u.style(tcell.StyleDefault.Foreground(tcell.ColorGray)))
+	if len(snapshot) == 0 {
+		drawCentered(screen, width, height, noTargetsMessage)
+		return
+	}
+
+	y := 1
+	listed := make(map[string]bool, len(snapshot))
+	for _, group := range groupTargets(snapshot, u.cfg.DefaultGroup) {
+		for _, target := range group.Targets {
+			if y >= bottom {
+				return
+			}
+			if listed[target.Name] {
+				continue
+			}
+			listed[target.Name] = true
+			line := flattenStyledText([]styledText{
+				{text: " " + padOrTrim(statusLabel(target.Status), 6) + " ", style: u.style(statusStyle(target.Status))},
+				{text: padOrTrim(displayName(target), maxInt(1, width-20)) + " ", style: tcell.StyleDefault},
+				{text: formatRTTUnit(target.LastRTT, u.cfg.UIRTTUnit), style: tcell.StyleDefault},
+			}, width)
+			if target.Name == u.selected {
+				line = highlight(line)
+			}
+			drawStyledText(screen, 0, y, width, line)
+			y++
+		}
+	}
+}
+
+// noTargetsMessage replaces the group boxes while the config has no targets,
+// so that an empty config is not mistaken for a broken one.
+const noTargetsMessage = "no targets configured (add some and press r to reload)"
+
+// drawCentered draws text in the middle of the screen, trimmed to its width.
+func drawCentered(screen tcell.Screen, width, height int, text string) {
+	runes := []rune(text)
+	if len(runes) > width {
+		runes = runes[:width]
+	}
+	drawText(screen, (width-len(runes))/2, height/2, len(runes), string(runes), tcell.StyleDefault.Bold(true))
+}
+
+// showReloadResult turns a reload outcome into a transient header message.
+func (u *UI) showReloadResult(result ReloadResult, now time.Time) {
+	if result.Err != nil {
+		u.flash = fmt.Sprintf("reload failed: %v", result.Err)
+		u.flashErr = true
+	} else {
+		if !result.LoadedAt.IsZero() {
+			u.configLoadedAt = result.LoadedAt
+		}
+		// A reload replaces an interval stepped with +/-; keep the settings
+		// row and the next step in line with what the scheduler now uses.
+		if result.Interval > 0 {
+			u.cfg.Interval, u.cfg.Timeout = result.Interval, result.Timeout
+		}
+		u.flash = fmt.Sprintf("config reloaded (%d targets)", result.Targets)
+		if result.Skipped > 0 {
+			u.flash = fmt.Sprintf("config reloaded (%d targets, %d invalid lines skipped)", result.Targets, result.Skipped)
+		}
+		u.flashErr = false
+	}
+	u.flashUntil = now.Add(flashDuration)
+}
+
+func (u *UI) activeFlash(now time.Time) (string, tcell.Style, bool) {
+	if u.flash == "" || !now.Before(u.flashUntil) {
+		return "", tcell.StyleDefault, false
+	}
+	if u.flashErr {
+		return u.flash, u.style(tcell.StyleDefault.Foreground(tcell.ColorRed).Bold(true)), true
+	}
+	return u.flash, u.style(tcell.StyleDefault.Foreground(tcell.ColorGreen).Bold(true)), true
+}
+
+// handleKey applies a key press to the view state. Quit keys are handled by Run.
+func (u *UI) handleKey(ev *tcell.EventKey, snapshot []state.TargetStatus) {
+	if u.detail {
+		switch {
+		case ev.Key() == tcell.KeyEscape, ev.Key() == tcell.KeyBackspace, ev.Key() == tcell.KeyBackspace2:
+			u.detail = false
+			u.message = ""
+		case ev.Rune() == 'e':
+			u.exportHistory()
+		case ev.Rune() == 'm':
+			u.toggleMute(snapshot)
+		case ev.Rune() == 'c':
+			u.state.Reset(u.selected)
+			u.message = "reset counters and history"
+		case ev.Rune() == 'r' || ev.Rune() == 'R':
+			u.requestReload()
+		}
+		return
+	}
+
+	switch {
+	case ev.Key() == tcell.KeyUp || ev.Rune() == 'k':
+		u.moveSelection(snapshot, -1)
+	case ev.Key() == tcell.KeyDown || ev.Rune() == 'j':
+		u.moveSelection(snapshot, 1)
+	case ev.Key() == tcell.KeyEnter:
+		if _, ok := findTarget(snapshot, u.selected); ok {
+			u.detail = true
+			u.message = ""
+		}
+	case ev.Rune() == 'v':
+		u.showEvents = !u.showEvents
+	case ev.Rune() == 'a':
+		u.hideAddr = !u.hideAddr
+	case ev.Rune() == 'm':
+		u.toggleMute(snapshot)
+	case ev.Rune() == '+' || ev.Rune() == '=':
+		u.stepInterval(1, time.Now())
+	case ev.Rune() == '-':
+		u.stepInterval(-1, time.Now())
+	case ev.Rune() == 'r' || ev.Rune() == 'R':
+		u.requestReload()
+	}
+}
+
+// stepInterval moves the live probe interval to the next longer (delta > 0)
+// or shorter (delta < 0) entry of intervalSteps. The timeout is kept.
+func (u *UI) stepInterval(delta int, now time.Time) {
+	if u.timing == nil {
+		return
+	}
+	next := nextInterval(u.cfg.Interval, delta)
+	if next == u.cfg.Interval {
+		return
+	}
+	u.cfg.Interval = next
+	u.timing.SetTiming(next, u.cfg.Timeout)
+	u.flash = fmt.Sprintf("interval set to %s", formatDuration(next))
+	u.flashErr = false
+	u.flashUntil = now.Add(flashDuration)
+}
+
+// nextInterval returns the step after (delta > 0) or before (delta < 0)
+// current. Intervals between steps snap to the neighbouring step, and the
+// ends of the ladder are clamped.
+func nextInterval(current time.Duration, delta int) time.Duration {
+	if delta > 0 {
+		for _, step := range intervalSteps {
+			if step > current {
+				return step
+			}
+		}
+		return max(current, intervalSteps[len(intervalSteps)-1])
+	}
+	for i := len(intervalSteps) - 1; i >= 0; i-- {
+		if intervalSteps[i] < current {
+			return intervalSteps[i]
+		}
+	}
+	return min(current, intervalSteps[0])
+}
+
+// moveSelection moves the highlight by delta rows in display order.
+func (u *UI) moveSelection(snapshot []state.TargetStatus, delta int) {
+	// A target in several groups is listed once, at its first appearance.
+	names := make([]string, 0, len(snapshot))
+	listed := make(map[string]bool, len(snapshot))
+	for _, group := range groupTargets(snapshot, u.cfg.DefaultGroup) {
+		for _, target := range group.Targets {
+			if !listed[target.Name] {
+				listed[target.Name] = true
+				names = append(names, target.Name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		u.selected = ""
+		return
+	}
+
+	index := -1
+	for i, name := range names {
+		if name == u.selected {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		u.selected = names[0]
+		return
+	}
+	index += delta
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(names) {
+		index = len(names) - 1
+	}
+	u.selected = names[index]
+}
+
+// toggleMute mutes or unmutes the selected target.
+func (u *UI) toggleMute(snapshot []state.TargetStatus) {
+	target, ok := findTarget(snapshot, u.selected)
+	if !ok {
+		return
+	}
+	m, ok := u.state.(muter)
+	if !ok {
+		u.message = "mute not supported"
+		return
+	}
+	m.SetMuted(target.Name, !target.Muted)
+}
+
+// exportHistory writes the selected target's RTT history to <name>.csv.
+func (u *UI) exportHistory() {
+	exporter, ok := u.state.(historyExporter)
+	if !ok {
+		u.message = "export not supported"
+		return
+	}
+	path := exportFileName(u.selected)
+	file, err := os.Create(path)
+	if err != nil {
+		u.message = fmt.Sprintf("export failed: %v", err)
+		return
+	}
+	err = exporter.ExportHistoryCSV(u.selected, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		u.message = fmt.Sprintf("export failed: %v", err)
+		return
+	}
+	u.message = fmt.Sprintf("exported history to %s", path)
+}
+
+// exportFileName derives a filesystem-safe CSV file name from a target name.
+func exportFileName(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if safe == "" || strings.Trim(safe, ".") == "" {
+		safe = "target"
+	}
+	return safe + ".csv"
+}
+
+func (u *UI) renderDetail(screen tcell.Screen, width, height int, target state.TargetStatus) {
+	header := fmt.Sprintf(" surveiller  %s  (esc to go back, e to export CSV, m to mute, c to reset)", target.Name)
+	drawText(screen, 0, 0, width, header, tcell.StyleDefault.Bold(true))
+
+	y := 2
+	for _, line := range detailLines(target, u.cfg.UIRTTUnit) {
+		if y >= height-1 {
+			break
+		}
+		drawText(screen, 0, y, width, line, tcell.StyleDefault)
+		y++
+	}
+	if u.message != "" {
+		drawText(screen, 0, height-1, width, " "+u.message, u.style(tcell.StyleDefault.Foreground(tcell.ColorGray)))
+	}
+}
+
+// detailLines formats the per-target fields shown in the detail view.
+func detailLines(target state.TargetStatus, unit config.RTTUnit) []string {
+	header, values := rttStatsTable(target, unit)
+	lines := []string{
+		fmt.Sprintf(" Name:          %s", target.Name),
+		fmt.Sprintf(" Label:         %s", orDash(target.Label)),
+		fmt.Sprintf(" Address:       %s", target.Address),
+		fmt.Sprintf(" Resolved IP:   %s", orDash(target.ResolvedIP)),
+		fmt.Sprintf(" Group:         %s", strings.Join(target.GroupNames(), ", ")),
+		fmt.Sprintf(" Status:        %s", target.Status),
+		fmt.Sprintf(" RTT:          %s", header),
+		fmt.Sprintf("               %s", values),
+		fmt.Sprintf(" TTL:           %s", formatTTL(target.LastTTL)),
+		fmt.Sprintf(" Pinger:        %s", orDash(target.Backend)),
+	}
+	if target.Backend == ping.BackendSynthetic {
+		phases := target.LastPhases
+		lines = append(lines, fmt.Sprintf(" Phases:        dns=%s connect=%s tls=%s",
+			formatRTTUnit(phases.DNS, unit), formatRTTUnit(phases.Connect, unit), formatRTTUnit(phases.TLS, unit)))
+	}
+	return append(lines,
+		fmt.Sprintf(" Lifetime loss: %.1f%%", target.LossPercent()),
+		fmt.Sprintf(" Consecutive:   ok=%d ng=%d", target.ConsecutiveOK, target.ConsecutiveNG),
+		fmt.Sprintf(" Totals:        success=%d failure=%d", target.TotalSuccess, target.TotalFailure),
+		fmt.Sprintf(" Last success:  %s", formatTimestamp(target.LastSuccessAt)),
+		fmt.Sprintf(" Last failure:  %s", formatTimestamp(target.LastFailureAt)),
+		fmt.Sprintf(" History:       %d points", len(target.History)),
+	)
+}
+
+// rttStatsTable formats the detail view's RTT statistics over the history
+// window as a header row and a value row with aligned columns. Loss covers
+// the last loss_window probes.
+func rttStatsTable(target state.TargetStatus, unit config.RTTUnit) (string, string) {
+	minRTT, maxRTT := calculateMinMaxRTT(target)
+	columns := []struct {
+		name  string
+		value string
+	}{
+		{"cur", formatRTTUnit(target.LastRTT, unit)},
+		{"min", formatRTTUnit(minRTT, unit)},
+		{"avg", formatRTTUnit(calculateAvgRTT(target), unit)},
+		{"p50", formatRTTUnit(state.Percentile(target.History, 50), unit)},
+		{"p95", formatRTTUnit(state.Percentile(target.History, 95), unit)},
+		{"max", formatRTTUnit(maxRTT, unit)},
+		{"jitter", formatRTTUnit(state.Jitter(target.History), unit)},
+		{fmt.Sprintf("loss/%d", len(target.Recent)), fmt.Sprintf("%.1f%%", calculateLossPercent(target))},
+	}
+	var header, values strings.Builder
+	for _, column := range columns {
+		fmt.Fprintf(&header, "%9s", column.name)
+		fmt.Fprintf(&values, "%9s", column.value)
+	}
+	return header.String(), values.String()
+}
+
+// displayName returns the label shown for a target, falling back to its name.
+func displayName(target state.TargetStatus) string {
+	if target.Label != "" {
+		return target.Label
+	}
+	return target.Name
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func formatTTL(ttl int) string {
+	if ttl <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", ttl)
+}
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func findTarget(snapshot []state.TargetStatus, name string) (state.TargetStatus, bool) {
+	if name == "" {
+		return state.TargetStatus{}, false
+	}
+	for _, target := range snapshot {
+		if target.Name == name {
+			return target, true
+		}
+	}
+	return state.TargetStatus{}, false
+}
+
 func (u *UI) requestReload() {
 	if u.reloadCh == nil {
 		return
@@ -133,28 +687,30 @@ type targetGroup struct {
 	Targets []state.TargetStatus
 }
 
-func groupTargets(snapshot []state.TargetStatus) []targetGroup {
+func groupTargets(snapshot []state.TargetStatus, defaultGroup string) []targetGroup {
 	if len(snapshot) == 0 {
 		return nil
 	}
 	groups := make(map[string][]state.TargetStatus)
 	for _, target := range snapshot {
-		name := strings.TrimSpace(target.Group)
-		if name == "" {
-			name = "default"
+		for _, name := range target.GroupNames() {
+			groups[name] = append(groups[name], target)
 		}
-		groups[name] = append(groups[name], target)
 	}
 	names := make([]string, 0, len(groups))
 	for name := range groups {
 		names = append(names, name)
 	}
-	sort.Slice(names, func(i, j int) bool {
-		if names[i] == "default" {
-			return true
+	// The ungrouped bucket comes first, whatever default_group names it.
+	rank := func(name string) int {
+		if name == state.DefaultGroupName || name == defaultGroup {
+			return 0
 		}
-		if names[j] == "default" {
-			return false
+		return 1
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if rank(names[i]) != rank(names[j]) {
+			return rank(names[i]) < rank(names[j])
 		}
 		return names[i] < names[j]
 	})
@@ -185,20 +741,44 @@ func (u *UI) drawGroupBox(screen tcell.Screen, x, y, width, height int, group ta
 	for i := 0; i < len(group.Targets) && i < maxRows; i++ {
 		target := group.Targets[i]
 		line := u.formatTargetLine(width-2, target)
+		if target.Name == u.selected {
+			line = highlight(line)
+		}
 		drawStyledText(screen, x+1, rowY+i, width-2, line)
 	}
 }
 
+func (u *UI) drawEventsBox(screen tcell.Screen, x, y, width, height int, events []state.Event) {
+	drawBox(screen, x, y, width, height)
+	drawText(screen, x+2, y, width-4, " events ", tcell.StyleDefault.Bold(true))
+
+	if len(events) == 0 {
+		drawText(screen, x+1, y+1, width-2, " no status changes yet", u.style(tcell.StyleDefault.Foreground(tcell.ColorGray)))
+		return
+	}
+	for i := 0; i < len(events) && i < height-2; i++ {
+		event := events[i]
+		line := fmt.Sprintf(" %s  %s  ", event.Time.Format("2006-01-02 15:04:05"), event.Target)
+		parts := []styledText{
+			{text: line, style: tcell.StyleDefault},
+			{text: string(event.From), style: u.style(statusStyle(event.From))},
+			{text: " -> ", style: tcell.StyleDefault},
+			{text: string(event.To), style: u.style(statusStyle(event.To))},
+		}
+		drawStyledText(screen, x+1, y+1+i, width-2, flattenStyledText(parts, width-2))
+	}
+}
+
 func (u *UI) formatTargetLine(width int, target state.TargetStatus) []styledRune {
-	statusStyle := statusStyle(target.Status)
-	name := padOrTrim(target.Name, minInt(14, width))
+	statusStyle := u.style(statusStyle(target.Status))
+	name := padOrTrim(displayName(target), minInt(14, width))
 	addr := padOrTrim(target.Address, minInt(18, width))
-	status := padOrTrim(string(target.Status), 6)
+	status := padOrTrim(statusLabel(target.Status), 6)
 
-	rtt := padOrTrim(fmt.Sprintf("RTT:%s", formatRTT(target.LastRTT)), 12)
+	rtt := padOrTrim(fmt.Sprintf("RTT:%s", formatRTTUnit(target.LastRTT, u.cfg.UIRTTUnit)), 12)
 
 	avgRTT := calculateAvgRTT(target)
-	avg := padOrTrim(fmt.Sprintf("AVG:%s", formatRTT(avgRTT)), 12)
+	avg := padOrTrim(fmt.Sprintf("AVG:%s", formatRTTUnit(avgRTT, u.cfg.UIRTTUnit)), 12)
 
 	// LOSS率を計算して表示
 	lossPercent := calculateLossPercent(target)
@@ -207,8 +787,14 @@ func (u *UI) formatTargetLine(width int, target state.TargetStatus) []styledRune
 	parts := []styledText{
 		{text: name, style: tcell.StyleDefault},
 		{text: " ", style: tcell.StyleDefault},
-		{text: addr, style: tcell.StyleDefault},
-		{text: " ", style: tcell.StyleDefault},
+	}
+	if !u.hideAddr {
+		parts = append(parts,
+			styledText{text: addr, style: tcell.StyleDefault},
+			styledText{text: " ", style: tcell.StyleDefault},
+		)
+	}
+	parts = append(parts, []styledText{
 		{text: status, style: statusStyle},
 		{text: " ", style: tcell.StyleDefault},
 		{text: rtt, style: tcell.StyleDefault},
@@ -217,21 +803,34 @@ func (u *UI) formatTargetLine(width int, target state.TargetStatus) []styledRune
 		{text: " ", style: tcell.StyleDefault},
 		{text: loss, style: statusStyle},
 		{text: " ", style: tcell.StyleDefault},
-	}
+	}...)
 
 	used := 0
 	for _, p := range parts {
 		used += len([]rune(p.text))
 	}
 	barWidth := width - used
-	if barWidth > 0 {
-		bar := buildBar(target, u.cfg.UIScale, barWidth)
+	if barWidth > 0 && !u.cfg.UIHideBar {
+		var bar string
+		if u.cfg.UITheme == config.UIThemeASCII {
+			bar = buildBar(target, u.cfg.UIScale, barWidth)
+		} else {
+			bar = buildSmoothBar(target, u.cfg.UIScale, barWidth)
+		}
 		parts = append(parts, styledText{text: bar, style: statusStyle})
 	}
 
 	return flattenStyledText(parts, width)
 }
 
+func highlight(parts []styledRune) []styledRune {
+	result := make([]styledRune, len(parts))
+	for i, part := range parts {
+		result[i] = styledRune{r: part.r, style: part.style.Reverse(true)}
+	}
+	return result
+}
+
 func buildBar(target state.TargetStatus, scale int, width int) string {
 	if width <= 0 {
 		return ""
@@ -253,6 +852,83 @@ func buildBar(target state.TargetStatus, scale int, width int) string {
 	return strings.Repeat("#", units) + strings.Repeat(" ", width-units)
 }
 
+// partialBlocks holds the 1/8 .. 7/8 block glyphs used for the bar remainder.
+var partialBlocks = []rune{'▏', '▎', '▍', '▌', '▋', '▊', '▉'}
+
+var sparkLevels = []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
+var asciiSparkLevels = []rune{'_', '.', '-', '~', '=', '+', '*', '#'}
+
+// sparkline draws one character per value, scaled so that max reaches the top
+// level. Zero values use the lowest level so the line keeps its width.
+func sparkline(values []int, max int, ascii bool) string {
+	levels := sparkLevels
+	if ascii {
+		levels = asciiSparkLevels
+	}
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if v > 0 && max > 0 {
+			// 切り上げて、1以上は必ず最下段より上に描く
+			level = (v*(len(levels)-1) + max - 1) / max
+			if level >= len(levels) {
+				level = len(levels) - 1
+			}
+		}
+		b.WriteRune(levels[level])
+	}
+	return b.String()
+}
+
+// formatDownTrend renders the recent DOWN counts as " DOWN <sparkline> n ",
+// or "" before the first sample.
+func (u *UI) formatDownTrend() string {
+	if len(u.downTrend) == 0 {
+		return ""
+	}
+	max := 0
+	for _, v := range u.downTrend {
+		if v > max {
+			max = v
+		}
+	}
+	line := sparkline(u.downTrend, max, u.cfg.UITheme == config.UIThemeASCII)
+	return fmt.Sprintf(" DOWN %s %d ", line, u.downTrend[len(u.downTrend)-1])
+}
+
+// buildSmoothBar is the Unicode variant of buildBar: whole units are drawn as
+// full blocks and the fractional remainder as a partial block, in eighths.
+// The result is always width runes wide and capped at width full blocks.
+func buildSmoothBar(target state.TargetStatus, scale int, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if scale <= 0 {
+		scale = 10
+	}
+	ms := float64(target.LastRTT) / float64(time.Millisecond)
+	if ms <= 0 {
+		return strings.Repeat(" ", width)
+	}
+	units := ms / float64(scale)
+	if units >= float64(width) {
+		return strings.Repeat("█", width)
+	}
+	full := int(units)
+	eighths := int((units - float64(full)) * 8)
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat("█", full))
+	used := full
+	if eighths > 0 {
+		b.WriteRune(partialBlocks[eighths-1])
+		used++
+	}
+	b.WriteString(strings.Repeat(" ", width-used))
+	return b.String()
+}
+
 func drawBox(screen tcell.Screen, x, y, width, height int) {
 	if width < 2 || height < 2 {
 		return
@@ -357,6 +1033,24 @@ func formatRTT(rtt time.Duration) string {
 	return fmt.Sprintf("%.1fs", rtt.Seconds())
 }
 
+// formatRTTUnit formats rtt in a fixed unit so columns keep their width;
+// RTTUnitAuto (or unset) falls back to formatRTT.
+func formatRTTUnit(rtt time.Duration, unit config.RTTUnit) string {
+	if rtt <= 0 {
+		return "-"
+	}
+	switch unit {
+	case config.RTTUnitMicroseconds:
+		return fmt.Sprintf("%dus", rtt.Microseconds())
+	case config.RTTUnitMilliseconds:
+		return fmt.Sprintf("%.1fms", float64(rtt)/float64(time.Millisecond))
+	case config.RTTUnitSeconds:
+		return fmt.Sprintf("%.3fs", rtt.Seconds())
+	default:
+		return formatRTT(rtt)
+	}
+}
+
 func calculateAvgRTT(target state.TargetStatus) time.Duration {
 	if len(target.History) == 0 {
 		return target.LastRTT
@@ -368,12 +1062,48 @@ func calculateAvgRTT(target state.TargetStatus) time.Duration {
 	return sum / time.Duration(len(target.History))
 }
 
+// calculateMinMaxRTT returns the best and worst RTT in the history window,
+// falling back to the last RTT when there is no history.
+func calculateMinMaxRTT(target state.TargetStatus) (time.Duration, time.Duration) {
+	if len(target.History) == 0 {
+		return target.LastRTT, target.LastRTT
+	}
+	minRTT, maxRTT := target.History[0].RTT, target.History[0].RTT
+	for _, point := range target.History[1:] {
+		if point.RTT < minRTT {
+			minRTT = point.RTT
+		}
+		if point.RTT > maxRTT {
+			maxRTT = point.RTT
+		}
+	}
+	return minRTT, maxRTT
+}
+
+// calculateLossPercent returns the loss over the recent probe window, falling
+// back to lifetime totals when no recent outcomes are recorded.
 func calculateLossPercent(target state.TargetStatus) float64 {
-	total := target.TotalSuccess + target.TotalFailure
-	if total == 0 {
-		return 0.0
+	if len(target.Recent) > 0 {
+		return target.RecentLossPercent()
+	}
+	return target.LossPercent()
+}
+
+// statusLabel returns the status as shown in the six-character list column.
+func statusLabel(status state.Status) string {
+	if status == state.StatusFlapping {
+		return "FLAP"
+	}
+	return string(status)
+}
+
+// style drops the colors from s when colors are disabled (NO_COLOR or
+// --no-color), keeping attributes such as bold.
+func (u *UI) style(s tcell.Style) tcell.Style {
+	if u.cfg.UINoColor {
+		return s.Foreground(tcell.ColorDefault).Background(tcell.ColorDefault)
 	}
-	return float64(target.TotalFailure) / float64(total) * 100.0
+	return s
 }
 
 func statusStyle(status state.Status) tcell.Style {
@@ -384,6 +1114,10 @@ func statusStyle(status state.Status) tcell.Style {
 		return tcell.StyleDefault.Foreground(tcell.ColorYellow)
 	case state.StatusDown:
 		return tcell.StyleDefault.Foreground(tcell.ColorRed)
+	case state.StatusFlapping:
+		return tcell.StyleDefault.Foreground(tcell.ColorFuchsia)
+	case state.StatusMuted:
+		return tcell.StyleDefault.Foreground(tcell.ColorBlue)
 	default:
 		return tcell.StyleDefault.Foreground(tcell.ColorGray)
 	}
@@ -403,6 +1137,33 @@ func maxInt(a, b int) int {
 	return b
 }
 
+// formatFooter summarizes status counts across the whole snapshot.
+func formatFooter(snapshot []state.TargetStatus) string {
+	var ok, warn, down, flapping, muted int
+	for _, target := range snapshot {
+		switch target.Status {
+		case state.StatusOK:
+			ok++
+		case state.StatusWarn:
+			warn++
+		case state.StatusDown:
+			down++
+		case state.StatusFlapping:
+			flapping++
+		case state.StatusMuted:
+			muted++
+		}
+	}
+	counts := fmt.Sprintf(" Total: %d  OK: %d  WARN: %d  DOWN: %d", len(snapshot), ok, warn, down)
+	if flapping > 0 {
+		counts += fmt.Sprintf("  FLAP: %d", flapping)
+	}
+	if muted > 0 {
+		counts += fmt.Sprintf("  MUTED: %d", muted)
+	}
+	return fmt.Sprintf("%s  | refresh %s | q quit", counts, formatDuration(uiRefreshInterval))
+}
+
 func formatConfigInfo(cfg config.GlobalOptions) string {
 	intervalStr := formatDuration(cfg.Interval)
 	timeoutStr := formatDuration(cfg.Timeout)
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/doridoridoriand/surveiller/internal/config"
 	"github.com/doridoridoriand/surveiller/internal/state"
@@ -38,7 +39,7 @@ func TestPropertyTUIGroupDisplay(t *testing.T) {
 				}
 			}
 
-			groups := groupTargets(snapshot)
+			groups := groupTargets(snapshot, "")
 
 			// Verify correct number of groups
 			if len(groups) != groupCount {
@@ -90,7 +91,7 @@ func TestPropertyTUIGroupDisplay(t *testing.T) {
 				}
 			}
 
-			groups := groupTargets(snapshot)
+			groups := groupTargets(snapshot, "")
 
 			// Should have exactly one group named "default"
 			if len(groups) != 1 {
@@ -135,7 +136,7 @@ func TestPropertyTUIGroupDisplay(t *testing.T) {
 				})
 			}
 
-			groups := groupTargets(snapshot)
+			groups := groupTargets(snapshot, "")
 
 			// Default group should be first
 			if len(groups) < 1 {
@@ -172,7 +173,7 @@ func TestPropertyTUIGroupDisplay(t *testing.T) {
 				}
 			}
 
-			groups := groupTargets(snapshot)
+			groups := groupTargets(snapshot, "")
 
 			if len(groups) != 1 {
 				return false
@@ -291,6 +292,37 @@ func TestPropertyTUIRTTBarGraphDisplay(t *testing.T) {
 		}),
 	))
 
+	props.Property("smooth bar keeps width and whole-unit block count", prop.ForAll(
+		func(rttMs int, scale int, width int) bool {
+			target := state.TargetStatus{
+				LastRTT: time.Duration(rttMs) * time.Millisecond,
+			}
+
+			bar := buildSmoothBar(target, scale, width)
+			if utf8.RuneCountInString(bar) != width {
+				return false
+			}
+
+			expectedFull := rttMs / scale
+			if expectedFull > width {
+				expectedFull = width
+			}
+			return strings.Count(bar, "█") == expectedFull
+		},
+		gopter.Gen(func(genParams *gopter.GenParameters) *gopter.GenResult {
+			value := genParams.Rng.Intn(2000) + 1
+			return gopter.NewGenResult(value, gopter.NoShrinker)
+		}),
+		gopter.Gen(func(genParams *gopter.GenParameters) *gopter.GenResult {
+			value := genParams.Rng.Intn(100) + 1
+			return gopter.NewGenResult(value, gopter.NoShrinker)
+		}),
+		gopter.Gen(func(genParams *gopter.GenParameters) *gopter.GenResult {
+			value := genParams.Rng.Intn(100) + 1
+			return gopter.NewGenResult(value, gopter.NoShrinker)
+		}),
+	))
+
 	props.Property("bar is capped at width when RTT exceeds scale", prop.ForAll(
 		func(rttMs int, scale int, width int) bool {
 			if rttMs < 1 || scale < 1 || width < 1 || rttMs <= scale*width {
@@ -1,11 +1,15 @@
 package ui
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/doridoridoriand/surveiller/internal/config"
+	"github.com/doridoridoriand/surveiller/internal/ping"
 	"github.com/doridoridoriand/surveiller/internal/state"
 	"github.com/gdamore/tcell/v2"
 )
@@ -94,6 +98,30 @@ func TestFormatRTT_VariousDurations(t *testing.T) {
 	}
 }
 
+func TestFormatRTTUnit_PinsUnit(t *testing.T) {
+	tests := []struct {
+		unit     config.RTTUnit
+		duration time.Duration
+		expected string
+	}{
+		{config.RTTUnitAuto, 500 * time.Microsecond, "500us"},
+		{config.RTTUnitMilliseconds, 500 * time.Microsecond, "0.5ms"},
+		{config.RTTUnitMilliseconds, 2500 * time.Millisecond, "2500.0ms"},
+		{config.RTTUnitMicroseconds, 25 * time.Millisecond, "25000us"},
+		{config.RTTUnitSeconds, 25 * time.Millisecond, "0.025s"},
+		{config.RTTUnitMilliseconds, 0, "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.unit), func(t *testing.T) {
+			result := formatRTTUnit(tt.duration, tt.unit)
+			if result != tt.expected {
+				t.Errorf("formatRTTUnit(%v, %q) = %q, want %q", tt.duration, tt.unit, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestStatusStyle_ReturnsCorrectColors(t *testing.T) {
 	tests := []struct {
 		status        state.Status
@@ -116,6 +144,25 @@ func TestStatusStyle_ReturnsCorrectColors(t *testing.T) {
 	}
 }
 
+func TestStyle_NoColor(t *testing.T) {
+	u := &UI{cfg: config.GlobalOptions{UINoColor: true}}
+	for _, status := range []state.Status{state.StatusOK, state.StatusWarn, state.StatusDown, state.StatusUnknown} {
+		if got := u.style(statusStyle(status)); got != tcell.StyleDefault {
+			t.Errorf("status %s: expected default style without colors, got %v", status, got)
+		}
+	}
+	if _, _, attrs := u.style(tcell.StyleDefault.Foreground(tcell.ColorRed).Bold(true)).Decompose(); attrs&tcell.AttrBold == 0 {
+		t.Errorf("expected attributes to survive when colors are disabled")
+	}
+
+	line := (&UI{cfg: config.GlobalOptions{UIScale: 10, UINoColor: true}}).formatTargetLine(80, state.TargetStatus{Name: "a", Status: state.StatusDown})
+	for _, r := range line {
+		if fg, _, _ := r.style.Decompose(); fg != tcell.ColorDefault {
+			t.Fatalf("expected no colored cells, got %v", fg)
+		}
+	}
+}
+
 func TestFormatConfigInfo_DisplaysAllSettings(t *testing.T) {
 	cfg := config.GlobalOptions{
 		Interval:       2 * time.Second,
@@ -169,6 +216,31 @@ func TestCalculateAvgRTT_NoHistory(t *testing.T) {
 	}
 }
 
+func TestCalculateMinMaxRTT_WithHistory(t *testing.T) {
+	target := state.TargetStatus{
+		LastRTT: 50 * time.Millisecond,
+		History: []state.RTTPoint{
+			{RTT: 20 * time.Millisecond},
+			{RTT: 10 * time.Millisecond},
+			{RTT: 30 * time.Millisecond},
+		},
+	}
+
+	minRTT, maxRTT := calculateMinMaxRTT(target)
+	if minRTT != 10*time.Millisecond || maxRTT != 30*time.Millisecond {
+		t.Errorf("calculateMinMaxRTT() = %v, %v, want 10ms, 30ms", minRTT, maxRTT)
+	}
+}
+
+func TestCalculateMinMaxRTT_NoHistory(t *testing.T) {
+	target := state.TargetStatus{LastRTT: 50 * time.Millisecond}
+
+	minRTT, maxRTT := calculateMinMaxRTT(target)
+	if minRTT != target.LastRTT || maxRTT != target.LastRTT {
+		t.Errorf("calculateMinMaxRTT() = %v, %v, want %v for both", minRTT, maxRTT, target.LastRTT)
+	}
+}
+
 func TestCalculateLossPercent(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -197,6 +269,17 @@ func TestCalculateLossPercent(t *testing.T) {
 	}
 }
 
+func TestCalculateLossPercent_PrefersRecentWindow(t *testing.T) {
+	target := state.TargetStatus{
+		TotalSuccess: 90,
+		TotalFailure: 10,
+		Recent:       []bool{true, false, false, true},
+	}
+	if got := calculateLossPercent(target); got != 50 {
+		t.Errorf("calculateLossPercent() = %.1f, want 50.0 from the recent window", got)
+	}
+}
+
 func TestPadOrTrim(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -224,7 +307,7 @@ func TestPadOrTrim(t *testing.T) {
 // 5.2 グループ表示機能の単体テスト
 
 func TestGroupTargets_EmptySnapshot(t *testing.T) {
-	result := groupTargets([]state.TargetStatus{})
+	result := groupTargets([]state.TargetStatus{}, "")
 	if result != nil {
 		t.Errorf("groupTargets([]) = %v, want nil", result)
 	}
@@ -236,7 +319,7 @@ func TestGroupTargets_SingleGroup(t *testing.T) {
 		{Name: "host2", Group: "web"},
 	}
 
-	groups := groupTargets(snapshot)
+	groups := groupTargets(snapshot, "")
 	if len(groups) != 1 {
 		t.Fatalf("expected 1 group, got %d", len(groups))
 	}
@@ -255,7 +338,7 @@ func TestGroupTargets_MultipleGroups(t *testing.T) {
 		{Name: "web2", Group: "web"},
 	}
 
-	groups := groupTargets(snapshot)
+	groups := groupTargets(snapshot, "")
 	if len(groups) != 2 {
 		t.Fatalf("expected 2 groups, got %d", len(groups))
 	}
@@ -288,7 +371,7 @@ func TestGroupTargets_DefaultGroup(t *testing.T) {
 		{Name: "host2", Group: "  "},
 	}
 
-	groups := groupTargets(snapshot)
+	groups := groupTargets(snapshot, "")
 	if len(groups) != 1 {
 		t.Fatalf("expected 1 group, got %d", len(groups))
 	}
@@ -303,7 +386,7 @@ func TestGroupTargets_DefaultGroupFirst(t *testing.T) {
 		{Name: "host2", Group: ""},
 	}
 
-	groups := groupTargets(snapshot)
+	groups := groupTargets(snapshot, "")
 	if len(groups) != 2 {
 		t.Fatalf("expected 2 groups, got %d", len(groups))
 	}
@@ -313,6 +396,22 @@ func TestGroupTargets_DefaultGroupFirst(t *testing.T) {
 	}
 }
 
+func TestGroupTargets_NamedDefaultGroupFirst(t *testing.T) {
+	snapshot := []state.TargetStatus{
+		{Name: "host1", Group: "web"},
+		{Name: "host2", Group: "prod"},
+		{Name: "host3", Group: "db"},
+	}
+
+	groups := groupTargets(snapshot, "prod")
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+	if groups[0].Name != "prod" || groups[1].Name != "db" || groups[2].Name != "web" {
+		t.Errorf("expected prod, db, web, got %q, %q, %q", groups[0].Name, groups[1].Name, groups[2].Name)
+	}
+}
+
 func TestGroupTargets_TargetsSortedWithinGroup(t *testing.T) {
 	snapshot := []state.TargetStatus{
 		{Name: "zebra", Group: "web"},
@@ -320,7 +419,7 @@ func TestGroupTargets_TargetsSortedWithinGroup(t *testing.T) {
 		{Name: "beta", Group: "web"},
 	}
 
-	groups := groupTargets(snapshot)
+	groups := groupTargets(snapshot, "")
 	if len(groups) != 1 {
 		t.Fatalf("expected 1 group, got %d", len(groups))
 	}
@@ -448,6 +547,137 @@ func TestBuildBar_NegativeScale(t *testing.T) {
 	}
 }
 
+func TestBuildSmoothBar(t *testing.T) {
+	tests := []struct {
+		name  string
+		rtt   time.Duration
+		scale int
+		width int
+		want  string
+	}{
+		{name: "fractional remainder", rtt: 14 * time.Millisecond, scale: 10, width: 5, want: "█▍   "},
+		{name: "exact units", rtt: 20 * time.Millisecond, scale: 10, width: 5, want: "██   "},
+		{name: "less than one eighth", rtt: 1 * time.Millisecond, scale: 10, width: 3, want: "   "},
+		{name: "capped", rtt: 500 * time.Millisecond, scale: 10, width: 4, want: "████"},
+		{name: "zero rtt", rtt: 0, scale: 10, width: 3, want: "   "},
+		{name: "default scale", rtt: 25 * time.Millisecond, scale: 0, width: 4, want: "██▌ "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildSmoothBar(state.TargetStatus{LastRTT: tt.rtt}, tt.scale, tt.width)
+			if got != tt.want {
+				t.Errorf("buildSmoothBar() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]int{0, 1, 2, 4, 7}, 7, false); got != "▁▂▃▅█" {
+		t.Errorf("sparkline() = %q, want %q", got, "▁▂▃▅█")
+	}
+	if got := sparkline([]int{0, 1, 100}, 100, false); got != "▁▂█" {
+		t.Errorf("sparkline() with small values = %q, want %q", got, "▁▂█")
+	}
+	if got := sparkline([]int{0, 0}, 0, true); got != "__" {
+		t.Errorf("sparkline() all zero = %q, want %q", got, "__")
+	}
+	if got := sparkline([]int{0, 7}, 7, true); got != "_#" {
+		t.Errorf("ASCII sparkline() = %q, want %q", got, "_#")
+	}
+}
+
+func TestRecordDownCount(t *testing.T) {
+	u := &UI{}
+	if u.formatDownTrend() != "" {
+		t.Fatalf("expected no trend before the first sample")
+	}
+
+	snapshot := []state.TargetStatus{
+		{Name: "a", Status: state.StatusDown},
+		{Name: "b", Status: state.StatusOK},
+		{Name: "c", Status: state.StatusDown},
+	}
+	for i := 0; i < downTrendSize+5; i++ {
+		u.recordDownCount(snapshot[:i%2+1])
+	}
+	if len(u.downTrend) != downTrendSize {
+		t.Fatalf("expected trend capped at %d samples, got %d", downTrendSize, len(u.downTrend))
+	}
+
+	u.recordDownCount(snapshot)
+	trend := u.formatDownTrend()
+	if !strings.HasPrefix(trend, " DOWN ") || !strings.HasSuffix(trend, "█ 2 ") {
+		t.Fatalf("unexpected trend %q", trend)
+	}
+}
+
+func TestFormatTargetLine_ASCIITheme(t *testing.T) {
+	target := state.TargetStatus{Name: "a", Address: "192.0.2.1", Status: state.StatusOK, LastRTT: 30 * time.Millisecond}
+
+	ascii := &UI{cfg: config.GlobalOptions{UIScale: 10, UITheme: config.UIThemeASCII}}
+	line := styledRunesToString(ascii.formatTargetLine(120, target))
+	if !strings.Contains(line, "###") || strings.Contains(line, "█") {
+		t.Fatalf("expected ASCII bar, got %q", line)
+	}
+
+	unicode := &UI{cfg: config.GlobalOptions{UIScale: 10, UITheme: config.UIThemeUnicode}}
+	line = styledRunesToString(unicode.formatTargetLine(120, target))
+	if !strings.Contains(line, "███") || strings.Contains(line, "#") {
+		t.Fatalf("expected Unicode bar, got %q", line)
+	}
+}
+
+func TestFormatTargetLine_HideBar(t *testing.T) {
+	target := state.TargetStatus{Name: "a", Address: "192.0.2.1", Status: state.StatusOK, LastRTT: 30 * time.Millisecond}
+
+	u := &UI{cfg: config.GlobalOptions{UIScale: 10, UITheme: config.UIThemeASCII, UIHideBar: true}}
+	line := styledRunesToString(u.formatTargetLine(120, target))
+	if strings.Contains(line, "#") {
+		t.Fatalf("expected no bar with ui.show_bar=false, got %q", line)
+	}
+	if !strings.Contains(line, "LOSS:0.0%") {
+		t.Fatalf("expected the remaining columns to be kept, got %q", line)
+	}
+}
+
+func TestFormatTargetLine_UsesLabel(t *testing.T) {
+	u := &UI{cfg: config.GlobalOptions{UIScale: 10}}
+	target := state.TargetStatus{Name: "192.0.2.1", Label: "router", Address: "192.0.2.1", Status: state.StatusOK}
+
+	line := styledRunesToString(u.formatTargetLine(100, target))
+	if !strings.HasPrefix(line, "router ") {
+		t.Fatalf("expected label in name column, got %q", line)
+	}
+
+	target.Label = ""
+	line = styledRunesToString(u.formatTargetLine(100, target))
+	if !strings.HasPrefix(line, "192.0.2.1 ") {
+		t.Fatalf("expected name without a label, got %q", line)
+	}
+}
+
+func TestFormatTargetLine_HideAddress(t *testing.T) {
+	target := state.TargetStatus{Name: "web", Address: "192.0.2.123", Status: state.StatusOK, LastRTT: 50 * time.Millisecond}
+
+	u := New(config.GlobalOptions{UIScale: 10}, nil, nil)
+	shown := styledRunesToString(u.formatTargetLine(100, target))
+	if !strings.Contains(shown, "192.0.2.123") {
+		t.Fatalf("expected address by default, got %q", shown)
+	}
+
+	u.handleKey(tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone), nil)
+	hidden := styledRunesToString(u.formatTargetLine(100, target))
+	if strings.Contains(hidden, "192.0.2.123") {
+		t.Fatalf("expected address to be hidden after toggle, got %q", hidden)
+	}
+
+	u = New(config.GlobalOptions{UIScale: 10, UIHideAddress: true}, nil, nil)
+	if line := styledRunesToString(u.formatTargetLine(100, target)); strings.Contains(line, "192.0.2.123") {
+		t.Fatalf("expected ui.show_address=false to hide the address, got %q", line)
+	}
+}
+
 func TestFormatDuration(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -469,3 +699,502 @@ func TestFormatDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestMoveSelection_FollowsDisplayOrder(t *testing.T) {
+	u := &UI{}
+	snapshot := []state.TargetStatus{
+		{Name: "b", Group: "web"},
+		{Name: "a", Group: "web"},
+		{Name: "z", Group: ""},
+	}
+
+	u.moveSelection(snapshot, 1)
+	if u.selected != "z" {
+		t.Fatalf("expected first target of default group selected, got %q", u.selected)
+	}
+	u.moveSelection(snapshot, 1)
+	if u.selected != "a" {
+		t.Fatalf("expected a selected, got %q", u.selected)
+	}
+	u.moveSelection(snapshot, 5)
+	if u.selected != "b" {
+		t.Fatalf("expected selection clamped to last target, got %q", u.selected)
+	}
+	u.moveSelection(snapshot, -5)
+	if u.selected != "z" {
+		t.Fatalf("expected selection clamped to first target, got %q", u.selected)
+	}
+}
+
+func TestMoveSelection_MultipleGroups(t *testing.T) {
+	u := &UI{}
+	snapshot := []state.TargetStatus{
+		{Name: "a", Group: "east", Groups: []string{"east", "web"}},
+		{Name: "b", Group: "web"},
+	}
+
+	groups := groupTargets(snapshot, "")
+	if len(groups) != 2 || len(groups[0].Targets) != 1 || len(groups[1].Targets) != 2 {
+		t.Fatalf("expected a in both east and web, got %+v", groups)
+	}
+
+	// a is listed in east and web but visited once.
+	for _, want := range []string{"a", "b", "b"} {
+		u.moveSelection(snapshot, 1)
+		if u.selected != want {
+			t.Fatalf("expected %s selected, got %q", want, u.selected)
+		}
+	}
+	u.moveSelection(snapshot, -1)
+	if u.selected != "a" {
+		t.Fatalf("expected a selected after moving up, got %q", u.selected)
+	}
+}
+
+func TestHandleKey_OpensAndClosesDetail(t *testing.T) {
+	u := &UI{}
+	snapshot := []state.TargetStatus{{Name: "example"}}
+
+	u.handleKey(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), snapshot)
+	if u.detail {
+		t.Fatalf("expected detail to stay closed without a selection")
+	}
+
+	u.handleKey(tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone), snapshot)
+	u.handleKey(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), snapshot)
+	if !u.detail {
+		t.Fatalf("expected detail view to open for selected target")
+	}
+
+	u.handleKey(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone), snapshot)
+	if u.detail {
+		t.Fatalf("expected escape to close detail view")
+	}
+}
+
+func TestExportHistory_WritesCSVFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	store := state.NewStore([]config.TargetConfig{{Name: "db/primary"}}, 100*time.Millisecond, state.Thresholds{})
+	store.UpdateResult("db/primary", ping.Result{Success: true, RTT: 5 * time.Millisecond})
+	u := &UI{state: store, selected: "db/primary", detail: true}
+
+	u.handleKey(tcell.NewEventKey(tcell.KeyRune, 'e', tcell.ModNone), store.GetSnapshot())
+
+	data, err := os.ReadFile("db_primary.csv")
+	if err != nil {
+		t.Fatalf("expected export file: %v (message %q)", err, u.message)
+	}
+	if !strings.HasPrefix(string(data), "timestamp,rtt_ms\n") {
+		t.Fatalf("unexpected CSV content: %q", string(data))
+	}
+	if !strings.Contains(u.message, "db_primary.csv") {
+		t.Fatalf("expected export confirmation message, got %q", u.message)
+	}
+}
+
+func TestExportFileName(t *testing.T) {
+	tests := map[string]string{
+		"web1":       "web1.csv",
+		"db/primary": "db_primary.csv",
+		"..":         "target.csv",
+		"":           "target.csv",
+	}
+	for name, expected := range tests {
+		if got := exportFileName(name); got != expected {
+			t.Errorf("exportFileName(%q) = %q, want %q", name, got, expected)
+		}
+	}
+}
+
+func TestDetailLines_IncludesTargetFields(t *testing.T) {
+	target := state.TargetStatus{
+		Name:         "example",
+		Address:      "192.0.2.10",
+		Group:        "web",
+		Status:       state.StatusWarn,
+		LastRTT:      30 * time.Millisecond,
+		LastTTL:      57,
+		Backend:      "external",
+		TotalSuccess: 3,
+		TotalFailure: 1,
+		History:      []state.RTTPoint{{RTT: 12 * time.Millisecond}, {RTT: 48 * time.Millisecond}},
+	}
+
+	text := strings.Join(detailLines(target, config.RTTUnitAuto), "\n")
+	for _, want := range []string{"example", "192.0.2.10", "web", "WARN", "30ms", "57", "25.0%", "p50", "p95", "jitter", "48ms", "external"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected detail view to contain %q, got:\n%s", want, text)
+		}
+	}
+}
+
+func TestDetailLines_ShowsSyntheticPhases(t *testing.T) {
+	target := state.TargetStatus{Name: "web", Backend: "icmp"}
+	if text := strings.Join(detailLines(target, config.RTTUnitAuto), "\n"); strings.Contains(text, "Phases:") {
+		t.Fatalf("expected no phases for ICMP targets, got:\n%s", text)
+	}
+
+	target.Backend = ping.BackendSynthetic
+	target.LastPhases = ping.Phases{DNS: 2 * time.Millisecond, Connect: 15 * time.Millisecond}
+	text := strings.Join(detailLines(target, config.RTTUnitMilliseconds), "\n")
+	if !strings.Contains(text, "Phases:        dns=2.0ms connect=15.0ms tls=-") {
+		t.Fatalf("expected phase breakdown, got:\n%s", text)
+	}
+}
+
+func TestRTTStatsTable_AlignsColumns(t *testing.T) {
+	target := state.TargetStatus{
+		LastRTT: 20 * time.Millisecond,
+		History: []state.RTTPoint{
+			{RTT: 10 * time.Millisecond}, {RTT: 20 * time.Millisecond}, {RTT: 30 * time.Millisecond},
+			{RTT: 40 * time.Millisecond}, {RTT: 200 * time.Millisecond},
+		},
+		Recent: []bool{true, true, true, false},
+	}
+
+	header, values := rttStatsTable(target, config.RTTUnitAuto)
+	if len(header) != len(values) {
+		t.Fatalf("expected header and values of equal width, got %q / %q", header, values)
+	}
+	wantHeader := "      cur      min      avg      p50      p95      max   jitter   loss/4"
+	wantValues := "     20ms     10ms     60ms     30ms    200ms    200ms     70ms    25.0%"
+	if header != wantHeader || values != wantValues {
+		t.Fatalf("unexpected table:\n%q\n%q", header, values)
+	}
+}
+
+func newTestScreen(t *testing.T, width, height int) tcell.SimulationScreen {
+	t.Helper()
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("init simulation screen: %v", err)
+	}
+	t.Cleanup(screen.Fini)
+	screen.SetSize(width, height)
+	return screen
+}
+
+func screenText(screen tcell.Screen) string {
+	width, height := screen.Size()
+	var b strings.Builder
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, _, _, _ := screen.GetContent(x, y)
+			b.WriteRune(r)
+		}
+		b.WriteRune('\n')
+	}
+	return b.String()
+}
+
+func TestRender_EventsPaneShowsTransitions(t *testing.T) {
+	store := state.NewStore([]config.TargetConfig{{Name: "web1", Address: "192.0.2.1"}}, 100*time.Millisecond, state.Thresholds{})
+	store.UpdateResult("web1", ping.Result{Success: true, RTT: 5 * time.Millisecond})
+	store.UpdateResult("web1", ping.Result{Success: false})
+
+	u := &UI{cfg: config.GlobalOptions{UIScale: 10}, state: store}
+	u.handleKey(tcell.NewEventKey(tcell.KeyRune, 'v', tcell.ModNone), store.GetSnapshot())
+	if !u.showEvents {
+		t.Fatalf("expected v to toggle the events pane")
+	}
+
+	screen := newTestScreen(t, 100, 20)
+	u.render(screen, store.GetSnapshot())
+	text := screenText(screen)
+	if !strings.Contains(text, "web1  OK -> WARN") {
+		t.Fatalf("expected OK -> WARN transition in events pane, got:\n%s", text)
+	}
+	if !strings.Contains(text, "web1  UNKNOWN -> OK") {
+		t.Fatalf("expected UNKNOWN -> OK transition in events pane, got:\n%s", text)
+	}
+}
+
+func TestRender_FlashesReloadResult(t *testing.T) {
+	store := state.NewStore([]config.TargetConfig{{Name: "web1", Address: "192.0.2.1"}}, 100*time.Millisecond, state.Thresholds{})
+	u := &UI{cfg: config.GlobalOptions{UIScale: 10}, state: store}
+	screen := newTestScreen(t, 120, 20)
+
+	u.showReloadResult(ReloadResult{Targets: 12}, time.Now())
+	u.render(screen, store.GetSnapshot())
+	if text := screenText(screen); !strings.Contains(text, "config reloaded (12 targets)") {
+		t.Fatalf("expected reload confirmation in header, got:\n%s", text)
+	}
+
+	u.showReloadResult(ReloadResult{Targets: 12, Skipped: 2}, time.Now())
+	u.render(screen, store.GetSnapshot())
+	if text := screenText(screen); !strings.Contains(text, "config reloaded (12 targets, 2 invalid lines skipped)") {
+		t.Fatalf("expected skipped line count in header, got:\n%s", text)
+	}
+
+	loadedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	u.showReloadResult(ReloadResult{Targets: 12, LoadedAt: loadedAt}, time.Now())
+	u.render(screen, store.GetSnapshot())
+	if text := screenText(screen); !strings.Contains(text, "loaded=03:04:05") {
+		t.Fatalf("expected reload time on the settings row, got:\n%s", text)
+	}
+
+	u.showReloadResult(ReloadResult{Err: errors.New("bad directive")}, time.Now())
+	u.render(screen, store.GetSnapshot())
+	if text := screenText(screen); !strings.Contains(text, "reload failed: bad directive") {
+		t.Fatalf("expected reload failure in header, got:\n%s", text)
+	}
+
+	u.showReloadResult(ReloadResult{Targets: 1}, time.Now().Add(-2*flashDuration))
+	u.render(screen, store.GetSnapshot())
+	text := screenText(screen)
+	if strings.Contains(text, "config reloaded") {
+		t.Fatalf("expected flash to expire, got:\n%s", text)
+	}
+	if !strings.Contains(text, "r to reload") {
+		t.Fatalf("expected key hints after flash expires, got:\n%s", text)
+	}
+}
+
+func TestFormatFooter(t *testing.T) {
+	snapshot := []state.TargetStatus{
+		{Status: state.StatusOK},
+		{Status: state.StatusOK},
+		{Status: state.StatusWarn},
+		{Status: state.StatusDown},
+		{Status: state.StatusUnknown},
+	}
+	want := " Total: 5  OK: 2  WARN: 1  DOWN: 1  | refresh 500ms | q quit"
+	if got := formatFooter(snapshot); got != want {
+		t.Fatalf("formatFooter() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatFooter_Flapping(t *testing.T) {
+	snapshot := []state.TargetStatus{{Status: state.StatusOK}, {Status: state.StatusFlapping}}
+	want := " Total: 2  OK: 1  WARN: 0  DOWN: 0  FLAP: 1  | refresh 500ms | q quit"
+	if got := formatFooter(snapshot); got != want {
+		t.Fatalf("formatFooter() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatFooter_Muted(t *testing.T) {
+	snapshot := []state.TargetStatus{{Status: state.StatusDown}, {Status: state.StatusMuted, Muted: true}}
+	want := " Total: 2  OK: 0  WARN: 0  DOWN: 1  MUTED: 1  | refresh 500ms | q quit"
+	if got := formatFooter(snapshot); got != want {
+		t.Fatalf("formatFooter() = %q, want %q", got, want)
+	}
+}
+
+func TestHandleKeyTogglesMute(t *testing.T) {
+	store := state.NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}, time.Second, state.Thresholds{})
+	u := New(config.GlobalOptions{}, store, nil)
+	u.selected = "a"
+
+	u.handleKey(tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModNone), store.GetSnapshot())
+	if status, _ := store.GetTargetStatus("a"); status.Status != state.StatusMuted {
+		t.Fatalf("expected m to mute the selected target, got %s", status.Status)
+	}
+	u.handleKey(tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModNone), store.GetSnapshot())
+	if status, _ := store.GetTargetStatus("a"); status.Muted {
+		t.Fatalf("expected a second m to unmute")
+	}
+}
+
+func TestHandleKeyResetsTargetInDetail(t *testing.T) {
+	store := state.NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}, time.Second, state.Thresholds{})
+	store.UpdateResult("a", ping.Result{Success: false})
+	u := New(config.GlobalOptions{}, store, nil)
+	u.selected = "a"
+
+	u.handleKey(tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone), store.GetSnapshot())
+	if status, _ := store.GetTargetStatus("a"); status.TotalFailure != 1 {
+		t.Fatalf("expected c to do nothing outside the detail view, got %+v", status)
+	}
+
+	u.detail = true
+	u.handleKey(tcell.NewEventKey(tcell.KeyRune, 'c', tcell.ModNone), store.GetSnapshot())
+	if status, _ := store.GetTargetStatus("a"); status.TotalFailure != 0 || status.Status != state.StatusUnknown {
+		t.Fatalf("expected c to reset the target, got %+v", status)
+	}
+	if u.message == "" {
+		t.Fatalf("expected a confirmation message")
+	}
+}
+
+type timingRecorder struct {
+	interval, timeout time.Duration
+}
+
+func (r *timingRecorder) SetTiming(interval, timeout time.Duration) {
+	r.interval, r.timeout = interval, timeout
+}
+
+func TestHandleKeyStepsInterval(t *testing.T) {
+	recorder := &timingRecorder{}
+	u := New(config.GlobalOptions{Interval: time.Second, Timeout: 800 * time.Millisecond}, state.NewStore(nil, time.Second, state.Thresholds{}), nil)
+	u.SetTimingControl(recorder)
+
+	u.handleKey(tcell.NewEventKey(tcell.KeyRune, '+', tcell.ModNone), nil)
+	if recorder.interval != 2*time.Second || recorder.timeout != 800*time.Millisecond {
+		t.Fatalf("expected + to set interval=2s timeout=800ms, got %s %s", recorder.interval, recorder.timeout)
+	}
+	if u.cfg.Interval != 2*time.Second || u.flash != "interval set to 2.0s" {
+		t.Fatalf("expected the settings row and flash to follow, got %s %q", u.cfg.Interval, u.flash)
+	}
+
+	u.handleKey(tcell.NewEventKey(tcell.KeyRune, '-', tcell.ModNone), nil)
+	u.handleKey(tcell.NewEventKey(tcell.KeyRune, '-', tcell.ModNone), nil)
+	if recorder.interval != 500*time.Millisecond {
+		t.Fatalf("expected two - presses to set 500ms, got %s", recorder.interval)
+	}
+}
+
+func TestStepIntervalFollowsReloadedTiming(t *testing.T) {
+	recorder := &timingRecorder{}
+	u := New(config.GlobalOptions{Interval: time.Second, Timeout: 800 * time.Millisecond}, state.NewStore(nil, time.Second, state.Thresholds{}), nil)
+	u.SetTimingControl(recorder)
+
+	u.handleKey(tcell.NewEventKey(tcell.KeyRune, '+', tcell.ModNone), nil)
+	u.showReloadResult(ReloadResult{Targets: 1, Interval: time.Second, Timeout: 3 * time.Second}, time.Now())
+	if u.cfg.Interval != time.Second || u.cfg.Timeout != 3*time.Second {
+		t.Fatalf("expected the reloaded timing on the settings row, got interval=%s timeout=%s", u.cfg.Interval, u.cfg.Timeout)
+	}
+
+	u.handleKey(tcell.NewEventKey(tcell.KeyRune, '+', tcell.ModNone), nil)
+	if recorder.interval != 2*time.Second || recorder.timeout != 3*time.Second {
+		t.Fatalf("expected + to keep the reloaded timeout, got interval=%s timeout=%s", recorder.interval, recorder.timeout)
+	}
+
+	u.showReloadResult(ReloadResult{Err: errors.New("bad directive")}, time.Now())
+	if u.cfg.Interval != 2*time.Second {
+		t.Fatalf("expected a failed reload to keep the stepped interval, got %s", u.cfg.Interval)
+	}
+}
+
+func TestNextInterval(t *testing.T) {
+	tests := []struct {
+		current time.Duration
+		delta   int
+		want    time.Duration
+	}{
+		{time.Second, 1, 2 * time.Second},
+		{time.Second, -1, 500 * time.Millisecond},
+		{1500 * time.Millisecond, 1, 2 * time.Second},
+		{1500 * time.Millisecond, -1, time.Second},
+		{100 * time.Millisecond, -1, 100 * time.Millisecond},
+		{50 * time.Millisecond, -1, 50 * time.Millisecond},
+		{time.Minute, 1, time.Minute},
+		{5 * time.Minute, 1, 5 * time.Minute},
+		{5 * time.Minute, -1, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := nextInterval(tt.current, tt.delta); got != tt.want {
+			t.Errorf("nextInterval(%s, %d) = %s, want %s", tt.current, tt.delta, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTargetLine_Flapping(t *testing.T) {
+	u := &UI{cfg: config.GlobalOptions{UIScale: 10}}
+	line := u.formatTargetLine(100, state.TargetStatus{Name: "a", Address: "192.0.2.1", Status: state.StatusFlapping})
+	if text := styledRunesToString(line); !strings.Contains(text, " FLAP ") {
+		t.Fatalf("expected FLAP status label, got %q", text)
+	}
+	if fg, _, _ := statusStyle(state.StatusFlapping).Decompose(); fg != tcell.ColorFuchsia {
+		t.Fatalf("expected FLAPPING to have its own color, got %v", fg)
+	}
+}
+
+func TestRender_TooSmallShowsNotice(t *testing.T) {
+	store := state.NewStore([]config.TargetConfig{{Name: "web1", Address: "192.0.2.1"}}, 100*time.Millisecond, state.Thresholds{})
+	u := &UI{cfg: config.GlobalOptions{UIScale: 10}, state: store}
+
+	screen := newTestScreen(t, 40, 4)
+	u.render(screen, store.GetSnapshot())
+	lines := strings.Split(screenText(screen), "\n")
+	if !strings.Contains(lines[2], "terminal too small (need 20x5)") {
+		t.Fatalf("expected centered size notice, got:\n%s", screenText(screen))
+	}
+	if strings.Contains(screenText(screen), "web1") {
+		t.Fatalf("expected no targets on a too small terminal")
+	}
+}
+
+func TestRender_NoTargetsShowsPlaceholder(t *testing.T) {
+	store := state.NewStore(nil, 100*time.Millisecond, state.Thresholds{})
+	u := &UI{cfg: config.GlobalOptions{UIScale: 10}, state: store}
+
+	for _, size := range [][2]int{{100, 12}, {40, 6}} {
+		screen := newTestScreen(t, size[0], size[1])
+		u.render(screen, store.GetSnapshot())
+		text := screenText(screen)
+		if !strings.Contains(text, "no targets configured") {
+			t.Fatalf("expected empty-state placeholder at %dx%d, got:\n%s", size[0], size[1], text)
+		}
+		if !strings.HasPrefix(text, " surveiller") {
+			t.Fatalf("expected header above the placeholder, got:\n%s", text)
+		}
+	}
+}
+
+func TestRefresh_SetsTerminalTitle(t *testing.T) {
+	store := state.NewStore([]config.TargetConfig{{Name: "web1", Address: "192.0.2.1"}}, 100*time.Millisecond, state.Thresholds{})
+	u := &UI{cfg: config.GlobalOptions{UIScale: 10}, state: store}
+	screen := newTestScreen(t, 100, 12)
+
+	u.refresh(screen)
+	if title := screen.GetTitle(); title != "" {
+		t.Fatalf("expected no title without ui.set_title, got %q", title)
+	}
+
+	u.cfg.UISetTitle = true
+	u.refresh(screen)
+	if title := screen.GetTitle(); title != "surveiller: OK" {
+		t.Fatalf("expected OK title, got %q", title)
+	}
+
+	for i := 0; i < 3; i++ {
+		store.UpdateResult("web1", ping.Result{Success: false})
+	}
+	u.refresh(screen)
+	if title := screen.GetTitle(); title != "surveiller: 1 DOWN" {
+		t.Fatalf("expected DOWN count in title, got %q", title)
+	}
+}
+
+func TestRender_CompactViewOnCrampedTerminal(t *testing.T) {
+	store := state.NewStore([]config.TargetConfig{
+		{Name: "web1", Address: "192.0.2.1"},
+		{Name: "web2", Address: "192.0.2.2"},
+	}, 100*time.Millisecond, state.Thresholds{})
+	store.UpdateResult("web1", ping.Result{Success: true, RTT: 5 * time.Millisecond})
+	u := &UI{cfg: config.GlobalOptions{UIScale: 10}, state: store}
+
+	screen := newTestScreen(t, 40, 6)
+	u.render(screen, store.GetSnapshot())
+	lines := strings.Split(screenText(screen), "\n")
+	if !strings.HasPrefix(lines[1], " OK     web1") || !strings.Contains(lines[1], "5ms") {
+		t.Fatalf("expected compact line for web1, got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], " UNKNOW web2") {
+		t.Fatalf("expected compact line for web2, got %q", lines[2])
+	}
+	if !strings.HasPrefix(lines[5], " Total: 2") {
+		t.Fatalf("expected footer on last row, got %q", lines[5])
+	}
+	if strings.ContainsRune(screenText(screen), '+') {
+		t.Fatalf("expected no group boxes in the compact view")
+	}
+}
+
+func TestRender_FooterOnLastRow(t *testing.T) {
+	targets := make([]config.TargetConfig, 0, 30)
+	for i := 0; i < 30; i++ {
+		targets = append(targets, config.TargetConfig{Name: fmt.Sprintf("t%02d", i), Address: "192.0.2.1"})
+	}
+	store := state.NewStore(targets, 100*time.Millisecond, state.Thresholds{})
+	u := &UI{cfg: config.GlobalOptions{UIScale: 10}, state: store}
+
+	screen := newTestScreen(t, 100, 12)
+	u.render(screen, store.GetSnapshot())
+	lines := strings.Split(screenText(screen), "\n")
+	if !strings.HasPrefix(lines[11], " Total: 30  OK: 0") {
+		t.Fatalf("expected footer on last row, got %q", lines[11])
+	}
+}
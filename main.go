@@ -1,12 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"os/signal"
+	"runtime"
+	"runtime/debug"
 	"sort"
 	"strings"
 	"sync"
@@ -26,20 +31,47 @@ import (
 var version = "0.0.2"
 
 func main() {
+	os.Exit(run())
+}
+
+// run is the body of main. It returns the process exit code instead of calling
+// os.Exit so deferred cleanup, such as closing syslog, always runs.
+func run() int {
+	if len(os.Args) > 1 && os.Args[1] == "init" {
+		return runInit(os.Args[2:], os.Stdout, os.Stderr)
+	}
+
 	var (
-		flagInterval       cli.OptionalDuration
+		flagInterval       cli.OptionalInterval
 		flagTimeout        cli.OptionalDuration
 		flagMaxConcurrency cli.OptionalInt
 		flagMetricsMode    cli.OptionalMetricsMode
 		flagMetricsListen  cli.OptionalString
 		flagNoUI           cli.OptionalBool
 		flagLogFile        cli.OptionalString
+		flagConfigDir      string
 		flagVersion        bool
 		flagVersionShort   bool
+		flagListTargets    bool
+		flagForce          bool
+		flagQuiet          bool
+		flagLogSyslog      bool
+		flagSyslogFacility string
+		flagSyslogTag      string
+		flagLogStdio       bool
+		flagNoColor        bool
+		flagFailAfter      time.Duration
+		flagStartupDelay   time.Duration
+		flagMaxRuntime     time.Duration
+		flagPinger         string
+		flagLogTimeFormat  string
+		flagLogLevel       string
+		flagLenient        bool
+		flagDiagnose       string
 	)
 
-	flag.Var(&flagInterval, "interval", "ping interval per target (override config)")
-	flag.Var(&flagInterval, "i", "ping interval per target (override config)")
+	flag.Var(&flagInterval, "interval", "ping interval per target, e.g. 2s or 30/min (override config)")
+	flag.Var(&flagInterval, "i", "ping interval per target, e.g. 2s or 30/min (override config)")
 	flag.Var(&flagTimeout, "timeout", "ping timeout (override config)")
 	flag.Var(&flagTimeout, "t", "ping timeout (override config)")
 	flag.Var(&flagMaxConcurrency, "max-concurrency", "max concurrent pings (override config)")
@@ -47,11 +79,30 @@ func main() {
 	flag.Var(&flagMetricsListen, "metrics-listen", "metrics listen address (e.g. :9100)")
 	flag.Var(&flagNoUI, "no-ui", "disable TUI (log only)")
 	flag.Var(&flagLogFile, "log-file", "log file path (default: logging disabled)")
+	flag.StringVar(&flagConfigDir, "config-dir", "", "load every *.conf file in this directory, in name order, instead of a config file")
+	flag.DurationVar(&flagFailAfter, "fail-after", 0, "with --no-ui, exit non-zero once a target has been DOWN this long")
+	flag.DurationVar(&flagStartupDelay, "startup-delay", 0, "print a startup message and wait this long before the TUI takes over the terminal")
+	flag.DurationVar(&flagMaxRuntime, "max-runtime", 0, "shut down gracefully after running this long, e.g. 10m (default: run until stopped)")
+	flag.StringVar(&flagPinger, "pinger", pingerFallback, "pinger implementation: fallback|icmp|external")
+	flag.BoolVar(&flagLogSyslog, "log-syslog", false, "send logs to the local syslog daemon instead of a file")
+	flag.StringVar(&flagSyslogFacility, "log-syslog-facility", "daemon", "syslog facility for --log-syslog (e.g. daemon, user, local0)")
+	flag.StringVar(&flagSyslogTag, "log-syslog-tag", "surveiller", "syslog tag for --log-syslog")
+	flag.BoolVar(&flagLogStdio, "log-stdio", false, "with --no-ui, write ERROR logs to stderr and all other logs to stdout")
+	flag.StringVar(&flagLogLevel, "log-level", "", "log level: debug|info|warn|error (overrides log.level and SURVEILLER_LOG_LEVEL)")
+	flag.StringVar(&flagLogTimeFormat, "log-time-format", "", "log timestamp format: Go time layout, epoch or epochms (default RFC3339)")
+	flag.StringVar(&flagDiagnose, "diagnose", "", "resolve and probe the named target once with verbose output, then exit")
+	flag.BoolVar(&flagNoColor, "no-color", false, "render the TUI without colors (also enabled by the NO_COLOR environment variable)")
+	flag.BoolVar(&flagQuiet, "quiet", false, "with --no-ui, print only status changes instead of every second")
+	flag.BoolVar(&flagLenient, "lenient", false, "skip and log invalid target lines instead of refusing the whole config")
+	flag.BoolVar(&flagForce, "force", false, "start even when the target count exceeds target_hard_limit")
+	flag.BoolVar(&flagListTargets, "list-targets", false, "print the parsed targets and exit")
 	flag.BoolVar(&flagVersion, "version", false, "show version")
 	flag.BoolVar(&flagVersionShort, "v", false, "show version")
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "usage: %s [options] <config-file>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "usage: %s [options] <config-file or - for stdin>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s [options] --config-dir <dir>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s init [-o file] [host ...]\n\n", os.Args[0])
 		fmt.Fprintln(os.Stderr, "Options:")
 		flag.PrintDefaults()
 	}
@@ -59,8 +110,9 @@ func main() {
 	flag.Parse()
 
 	if flagVersion || flagVersionShort {
-		fmt.Fprintf(os.Stdout, "surveiller version %s\n", version)
-		return
+		info, _ := debug.ReadBuildInfo()
+		fmt.Fprint(os.Stdout, versionText(version, info))
+		return 0
 	}
 
 	args := flag.Args()
@@ -80,7 +132,7 @@ func main() {
 			flagLogFile.Set(strings.TrimPrefix(arg, "--log-file="))
 		} else if strings.HasPrefix(arg, "-log-file=") {
 			flagLogFile.Set(strings.TrimPrefix(arg, "-log-file="))
-		} else if !strings.HasPrefix(arg, "-") {
+		} else if arg == config.StdinPath || !strings.HasPrefix(arg, "-") {
 			// This is a non-flag argument (config file)
 			if configPath == "" {
 				configPath = arg
@@ -88,64 +140,186 @@ func main() {
 		}
 	}
 
+	parser := config.SurveillerParser{Lenient: flagLenient}
+	loadConfig := parser.LoadConfig
+	if flagConfigDir != "" {
+		if configPath != "" {
+			fmt.Fprintln(os.Stderr, "use either a config file or --config-dir, not both")
+			return 1
+		}
+		configPath = flagConfigDir
+		loadConfig = parser.LoadConfigDir
+	}
 	if configPath == "" {
 		flag.Usage()
-		os.Exit(1)
+		return 1
 	}
 
-	// Initialize logger (default to INFO level, can be overridden by environment variable)
+	// Initialize logger at INFO until the config is loaded; --log-level and
+	// SURVEILLER_LOG_LEVEL take precedence over log.level.
+	levelOverride, err := logLevelOverride(flagLogLevel, os.Getenv("SURVEILLER_LOG_LEVEL"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
 	logLevel := log.LevelInfo
-	if levelStr := os.Getenv("SURVEILLER_LOG_LEVEL"); levelStr != "" {
-		logLevel = log.ParseLevel(levelStr)
+	if levelOverride != nil {
+		logLevel = log.ParseLevel(*levelOverride)
 	}
 	logger := log.NewLogger(logLevel)
+	logger.SetTimeFormat(flagLogTimeFormat)
 
 	// Set log output to file if --log-file flag is specified
-	if logFilePath, ok := flagLogFile.Value(); ok && logFilePath != "" {
+	logFilePath, hasLogFile := flagLogFile.Value()
+	if countTrue(flagLogSyslog, flagLogStdio, hasLogFile && logFilePath != "") > 1 {
+		fmt.Fprintln(os.Stderr, "only one of --log-file, --log-syslog and --log-stdio can be used")
+		return 1
+	}
+	if flagLogStdio {
+		logger.SetOutputs(os.Stdout, os.Stderr)
+	}
+	if flagLogSyslog {
+		syslogWriter, err := log.NewSyslogWriter(flagSyslogFacility, flagSyslogTag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to set up syslog: %v\n", err)
+			return 1
+		}
+		defer syslogWriter.Close()
+		logger.SetOutput(syslogWriter)
+	}
+	if hasLogFile && logFilePath != "" {
 		logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "failed to open log file %s: %v\n", logFilePath, err)
-			os.Exit(1)
+			return 1
 		}
 		logger.SetOutput(logFile)
 	}
 
 	overrides := buildOverrides(flagInterval, flagTimeout, flagMaxConcurrency, flagMetricsMode, flagMetricsListen, flagNoUI)
+	overrides.LogLevel = levelOverride
+	if noColorRequested(flagNoColor, os.Getenv) {
+		noColor := true
+		overrides.UINoColor = &noColor
+	}
 
-	parser := config.SurveillerParser{}
-	cfg, err := parser.LoadConfig(configPath, overrides)
+	cfg, err := loadConfig(configPath, overrides)
 	if err != nil {
 		logger.LogConfigLoad(false, configPath, err)
-		os.Exit(1)
+		return 1
 	}
 	logger.LogConfigLoad(true, configPath, nil)
+	logger.SetLevel(log.ParseLevel(cfg.Global.LogLevel))
+	logSkippedLines(logger, configPath, cfg.Skipped)
+	cfg.LoadedAt = time.Now()
+
+	if flagListTargets {
+		printTargets(os.Stdout, cfg.Targets)
+		return 0
+	}
+
+	if flagLogStdio && !cfg.Global.UIDisable {
+		fmt.Fprintln(os.Stderr, "--log-stdio requires --no-ui (or ui.disable=true); logs would corrupt the TUI")
+		return 1
+	}
+	if flagFailAfter > 0 && !cfg.Global.UIDisable {
+		fmt.Fprintln(os.Stderr, "--fail-after requires --no-ui (or ui.disable=true)")
+		return 1
+	}
+
+	if err := checkTargetLimits(cfg, flagForce, logger); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		logger.LogError("config", err, nil)
+		return 1
+	}
 
 	icmpPinger, err := ping.NewICMPPinger()
 	if err != nil {
 		logger.LogError("pinger", err, nil)
-		os.Exit(1)
+		return 1
+	}
+	if err := icmpPinger.SetPayloadSize(cfg.Global.PayloadSize); err != nil {
+		logger.LogError("pinger", err, nil)
+		return 1
+	}
+	externalPinger := ping.NewExternalPinger()
+	externalPinger.SetCommand(strings.Fields(cfg.Global.ExternalPingCommand))
+	ping.SetResolveCacheTTL(cfg.Global.ResolveCacheTTL)
+	pinger, err := selectPinger(context.Background(), flagPinger, icmpPinger, externalPinger, logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
-	pinger := ping.NewFallbackPinger(icmpPinger, ping.NewExternalPinger())
 
-	store := state.NewStore(cfg.Targets, cfg.Global.Timeout)
+	if flagDiagnose != "" {
+		return runDiagnose(context.Background(), os.Stdout, cfg, flagDiagnose, pinger)
+	}
+
+	store := state.NewStore(cfg.Targets, cfg.Global.Timeout, state.ThresholdsFromOptions(cfg.Global))
+	store.UpdateTiming(cfg.Global.Interval, cfg.Global.Timeout)
 	sched := scheduler.NewScheduler(cfg.Global, cfg.Targets, pinger, store, logger)
 
 	ctx, cancel := signalContext()
 	defer cancel()
+	if flagMaxRuntime > 0 {
+		go stopAfter(ctx, flagMaxRuntime, cancel, logger)
+	}
+
+	current := &currentConfig{}
+	current.Store(*cfg)
 
 	reloadCh := make(chan struct{}, 1)
+	reloadResults := make(chan ui.ReloadResult, 1)
 	reload := func() error {
-		newCfg, err := parser.LoadConfig(configPath, overrides)
+		if flagConfigDir == "" && configPath == config.StdinPath {
+			err := errors.New("reload is not supported when the config is read from stdin")
+			logger.Warn("Ignoring reload request", map[string]interface{}{"error": err.Error()})
+			return err
+		}
+		newCfg, err := loadConfig(configPath, overrides)
 		if err != nil {
 			logger.LogConfigLoad(false, configPath, err)
 			return err
 		}
 		logger.LogConfigLoad(true, configPath, nil)
+		logger.SetLevel(log.ParseLevel(newCfg.Global.LogLevel))
+		logSkippedLines(logger, configPath, newCfg.Skipped)
+		if err := checkTargetLimits(newCfg, flagForce, logger); err != nil {
+			logger.LogError("config", err, nil)
+			return err
+		}
+		if err := icmpPinger.SetPayloadSize(newCfg.Global.PayloadSize); err != nil {
+			logger.LogError("pinger", err, nil)
+			return err
+		}
+		externalPinger.SetCommand(strings.Fields(newCfg.Global.ExternalPingCommand))
+		ping.SetResolveCacheTTL(newCfg.Global.ResolveCacheTTL)
 		sched.UpdateConfig(newCfg.Global, newCfg.Targets)
 		store.UpdateTargets(newCfg.Targets)
-		store.UpdateTimeout(newCfg.Global.Timeout)
+		store.UpdateTiming(newCfg.Global.Interval, newCfg.Global.Timeout)
+		store.UpdateThresholds(state.ThresholdsFromOptions(newCfg.Global))
+		newCfg.LoadedAt = time.Now()
+		current.Store(*newCfg)
 		return nil
 	}
+	// runReload serializes reloads from SIGHUP, the UI and POST /-/reload.
+	// Errors are logged in reload; the outcome is also reported to the UI.
+	var reloadMu sync.Mutex
+	runReload := func() error {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
+		err := reload()
+		loaded := current.Load()
+		notifyReload(reloadResults, ui.ReloadResult{
+			Targets:  len(loaded.Targets),
+			Skipped:  len(loaded.Skipped),
+			LoadedAt: loaded.LoadedAt,
+			Interval: loaded.Global.Interval,
+			Timeout:  loaded.Global.Timeout,
+			Err:      err,
+		})
+		return err
+	}
 
 	var reloadWg sync.WaitGroup
 	reloadWg.Add(1)
@@ -156,9 +330,7 @@ func main() {
 			case <-ctx.Done():
 				return
 			case <-reloadCh:
-				if err := reload(); err != nil {
-					// Error already logged in reload function
-				}
+				_ = runReload()
 			}
 		}
 	}()
@@ -177,13 +349,26 @@ func main() {
 		}
 	}()
 
+	// The TUI owns the terminal, so it gets the dump through the logger instead.
+	dump := func() { writeSnapshot(os.Stderr, store.GetSnapshot(), time.Now()) }
+	if !cfg.Global.UIDisable {
+		dump = func() { logSnapshot(logger, store.GetSnapshot()) }
+	}
+	go dumpOnSignal(ctx, dump)
+
+	exitCode := 0
 	var wg sync.WaitGroup
 	if cfg.Global.MetricsListen != "" {
+		metricsServer := metrics.NewServer(cfg.Global.MetricsMode, store, version)
+		metricsServer.SetConfigSource(current.Load)
+		metricsServer.SetLogger(logger)
+		metricsServer.SetReloader(runReload)
+		go metricsServer.RunSnapshotCache(ctx, cfg.Global.MetricsMaxStaleness)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if err := metrics.Serve(ctx, cfg.Global.MetricsListen, cfg.Global.MetricsMode, store); err != nil && !errors.Is(err, context.Canceled) {
-				logger.LogError("metrics", err, nil)
+			// Serve logs its own failures through metricsServer's logger.
+			if err := metrics.Serve(ctx, cfg.Global.MetricsListen, cfg.Global.MetricsPath, metricsServer); err != nil && !errors.Is(err, context.Canceled) {
 				cancel()
 			}
 		}()
@@ -201,11 +386,31 @@ func main() {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			runTextReporter(ctx, store)
+			runTextReporter(ctx, store, flagQuiet)
 		}()
+		if flagFailAfter > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if target, ok := watchDownTargets(ctx, store, flagFailAfter); ok {
+					logger.Error("Target down longer than fail-after", map[string]interface{}{
+						"target":     target.Name,
+						"down_since": target.StatusSince.Format(time.RFC3339),
+						"fail_after": flagFailAfter.String(),
+					})
+					fmt.Fprintf(os.Stderr, "target %s has been DOWN since %s (fail-after %s)\n", target.Name, target.StatusSince.Format(time.RFC3339), flagFailAfter)
+					exitCode = 2
+					cancel()
+				}
+			}()
+		}
 		<-ctx.Done()
 	} else {
 		ui := ui.New(cfg.Global, store, reloadCh)
+		ui.SetReloadResults(reloadResults)
+		ui.SetConfigLoadedAt(cfg.LoadedAt)
+		ui.SetTimingControl(timingControl{sched: sched, store: store})
+		startupPause(ctx, os.Stdout, flagStartupDelay, len(cfg.Targets))
 		if err := ui.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
 			logger.LogError("ui", err, nil)
 			cancel()
@@ -214,10 +419,11 @@ func main() {
 
 	wg.Wait()
 	reloadWg.Wait()
+	return exitCode
 }
 
 func buildOverrides(
-	interval cli.OptionalDuration,
+	interval cli.OptionalInterval,
 	timeout cli.OptionalDuration,
 	maxConcurrency cli.OptionalInt,
 	metricsMode cli.OptionalMetricsMode,
@@ -243,7 +449,7 @@ func buildOverrides(
 		overrides.MetricsMode = &value
 	}
 	if v, ok := metricsListen.Value(); ok && v != "" {
-		value := v
+		value := config.NormalizeListenAddress(v)
 		overrides.MetricsListen = &value
 	}
 	if v, ok := noUI.Value(); ok {
@@ -254,6 +460,347 @@ func buildOverrides(
 	return overrides
 }
 
+// currentConfig holds the most recently applied configuration for readers
+// outside the reload goroutine.
+type currentConfig struct {
+	mu  sync.RWMutex
+	cfg config.Config
+}
+
+func (c *currentConfig) Store(cfg config.Config) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cfg = cfg
+}
+
+func (c *currentConfig) Load() config.Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cfg
+}
+
+// timingControl applies interval changes from the TUI to the scheduler and to
+// the store, which reports the effective timing in the per-target metrics.
+type timingControl struct {
+	sched *scheduler.Impl
+	store *state.StoreImpl
+}
+
+func (t timingControl) SetTiming(interval, timeout time.Duration) {
+	t.sched.SetTiming(interval, timeout)
+	t.store.UpdateTiming(interval, timeout)
+}
+
+const (
+	pingerFallback = "fallback"
+	pingerICMP     = ping.BackendICMP
+	pingerExternal = ping.BackendExternal
+)
+
+// noColorRequested reports whether colors are disabled by --no-color or by a
+// non-empty NO_COLOR environment variable (https://no-color.org).
+func noColorRequested(flagNoColor bool, getenv func(string) string) bool {
+	return flagNoColor || getenv("NO_COLOR") != ""
+}
+
+// countTrue returns how many of values are true.
+func countTrue(values ...bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
+
+// versionText formats --version output: the version line, then the Go
+// version, platform and, when the binary was built from a VCS checkout, the
+// revision and commit time. info may be nil.
+func versionText(version string, info *debug.BuildInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "surveiller version %s\n", version)
+	fmt.Fprintf(&b, "  go:       %s\n", runtime.Version())
+	fmt.Fprintf(&b, "  platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	if info == nil {
+		return b.String()
+	}
+	var revision, commitTime string
+	modified := false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.time":
+			commitTime = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision != "" {
+		if modified {
+			revision += " (modified)"
+		}
+		fmt.Fprintf(&b, "  revision: %s\n", revision)
+	}
+	if commitTime != "" {
+		fmt.Fprintf(&b, "  commit:   %s\n", commitTime)
+	}
+	return b.String()
+}
+
+// logSkippedLines logs each target line a lenient parse skipped, then their count.
+func logSkippedLines(logger *log.Logger, path string, skipped []config.SkippedLine) {
+	if len(skipped) == 0 {
+		return
+	}
+	for _, line := range skipped {
+		fields := map[string]interface{}{
+			"path":  path,
+			"line":  line.Line,
+			"error": line.Err.Error(),
+		}
+		if line.File != "" {
+			fields["file"] = line.File
+		}
+		logger.Warn("Skipped invalid target line", fields)
+	}
+	logger.Warn("Config loaded with invalid target lines skipped", map[string]interface{}{
+		"path":    path,
+		"skipped": len(skipped),
+	})
+}
+
+// logLevelOverride returns the log level that overrides log.level: the
+// --log-level flag, else a valid SURVEILLER_LOG_LEVEL, else nil.
+func logLevelOverride(flagValue, envValue string) (*string, error) {
+	if flagValue != "" {
+		if _, ok := log.LookupLevel(flagValue); !ok {
+			return nil, fmt.Errorf("invalid --log-level %q: use debug, info, warn or error", flagValue)
+		}
+		return &flagValue, nil
+	}
+	if _, ok := log.LookupLevel(envValue); ok {
+		return &envValue, nil
+	}
+	return nil, nil
+}
+
+// checkTargetLimits guards against configs with more targets than the process
+// can reasonably probe. Exceeding target_soft_limit only logs a warning;
+// exceeding target_hard_limit is an error unless force is set. A limit of 0
+// disables that check.
+func checkTargetLimits(cfg *config.Config, force bool, logger *log.Logger) error {
+	count := len(cfg.Targets)
+	soft, hard := cfg.Global.TargetSoftLimit, cfg.Global.TargetHardLimit
+	if hard > 0 && count > hard {
+		if !force {
+			return fmt.Errorf("%d targets exceeds target_hard_limit %d (use --force to start anyway)", count, hard)
+		}
+		logger.Warn("Target count exceeds hard limit, continuing because of --force", map[string]interface{}{
+			"targets": count,
+			"limit":   hard,
+		})
+		return nil
+	}
+	if soft > 0 && count > soft {
+		logger.Warn("Target count exceeds soft limit", map[string]interface{}{
+			"targets": count,
+			"limit":   soft,
+		})
+	}
+	return nil
+}
+
+// selectPinger picks the pinger implementation for the session. "icmp" never
+// falls back, so permission errors surface directly; "external" never opens an
+// ICMP socket. In "fallback" mode a single loopback probe decides whether raw
+// ICMP works; if it does not, the external pinger is used directly instead of
+// failing over on every probe.
+func selectPinger(ctx context.Context, mode string, icmp, external ping.Pinger, logger *log.Logger) (ping.Pinger, error) {
+	switch mode {
+	case pingerICMP:
+		return icmp, nil
+	case pingerExternal:
+		return external, nil
+	case pingerFallback, "":
+		if ping.ICMPAvailable(ctx, icmp) {
+			return ping.NewFallbackPinger(icmp, external), nil
+		}
+		logger.Info("ICMP unavailable, using external ping for this session", nil)
+		return external, nil
+	default:
+		return nil, fmt.Errorf("invalid --pinger %q (expected fallback, icmp or external)", mode)
+	}
+}
+
+// runDiagnose probes a single named target once and prints every step. pinger
+// is used for ICMP targets; synthetic targets get their own pinger, as in the
+// scheduler. It returns the process exit code: 0 when the probe succeeded, 1
+// otherwise.
+func runDiagnose(ctx context.Context, w io.Writer, cfg *config.Config, name string, pinger ping.Pinger) int {
+	var target config.TargetConfig
+	found := false
+	for _, candidate := range cfg.Targets {
+		if candidate.Name == name {
+			target = candidate
+			found = true
+			break
+		}
+	}
+	if !found {
+		fmt.Fprintf(w, "target %q not found in config\n", name)
+		return 1
+	}
+
+	group := target.Group
+	if group == "" {
+		group = state.DefaultGroupName
+	}
+	if len(target.Groups) > 0 {
+		group = strings.Join(target.Groups, ",")
+	}
+	fmt.Fprintf(w, "target:   %s\n", target.Name)
+	fmt.Fprintf(w, "address:  %s\n", target.Address)
+	fmt.Fprintf(w, "group:    %s\n", group)
+
+	host := target.Address
+	if target.Probe == config.ProbeSynthetic {
+		pinger = ping.NewSyntheticPinger(target.TLS)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		fmt.Fprintf(w, "resolved: %s (literal)\n", ip)
+	} else {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			fmt.Fprintf(w, "resolved: error: %v\n", err)
+		} else {
+			ips := make([]string, 0, len(addrs))
+			for _, addr := range addrs {
+				ips = append(ips, addr.String())
+			}
+			fmt.Fprintf(w, "resolved: %s\n", strings.Join(ips, ", "))
+		}
+	}
+
+	fmt.Fprintf(w, "pinger:   %s\n", describePinger(pinger))
+	fmt.Fprintf(w, "timeout:  %s\n", cfg.Global.Timeout)
+
+	probeCtx, cancel := context.WithTimeout(ctx, cfg.Global.Timeout)
+	defer cancel()
+	start := time.Now()
+	result := pinger.Ping(probeCtx, target.Address, cfg.Global.Timeout)
+	elapsed := time.Since(start)
+
+	fmt.Fprintf(w, "success:  %t\n", result.Success)
+	fmt.Fprintf(w, "rtt:      %s\n", result.RTT)
+	if result.TTL > 0 {
+		fmt.Fprintf(w, "ttl:      %d\n", result.TTL)
+	}
+	if target.Probe == config.ProbeSynthetic {
+		fmt.Fprintf(w, "phases:   dns=%s connect=%s tls=%s\n", result.Phases.DNS, result.Phases.Connect, result.Phases.TLS)
+	}
+	fmt.Fprintf(w, "elapsed:  %s\n", elapsed)
+	if result.Error != nil {
+		fmt.Fprintf(w, "error:    %v\n", result.Error)
+		fmt.Fprintf(w, "error type: %T\n", result.Error)
+	}
+	if !result.Success {
+		return 1
+	}
+	return 0
+}
+
+// runInit implements "surveiller init": it writes a starter config for hosts to
+// stdout, or to the file given with -o, which must not exist yet.
+func runInit(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", "", "write the config to this file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: surveiller init [-o file] [host ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var buf bytes.Buffer
+	if err := config.WriteStarter(&buf, fs.Args()); err != nil {
+		fmt.Fprintf(stderr, "init: %v\n", err)
+		return 1
+	}
+
+	if *output == "" {
+		if _, err := stdout.Write(buf.Bytes()); err != nil {
+			fmt.Fprintf(stderr, "init: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	file, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		fmt.Fprintf(stderr, "init: %v\n", err)
+		return 1
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		fmt.Fprintf(stderr, "init: %v\n", err)
+		return 1
+	}
+	if err := file.Close(); err != nil {
+		fmt.Fprintf(stderr, "init: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func describePinger(p ping.Pinger) string {
+	switch p.(type) {
+	case *ping.FallbackPinger:
+		return "fallback (ICMP, external ping on permission errors)"
+	case *ping.ICMPPinger:
+		return "icmp"
+	case *ping.ExternalPinger:
+		return "external (system ping command)"
+	case *ping.SyntheticPinger:
+		return "synthetic (DNS, TCP connect and optional TLS handshake)"
+	default:
+		return fmt.Sprintf("%T", p)
+	}
+}
+
+// printTargets writes one tab-separated line per target: name, address, group, options.
+func printTargets(w io.Writer, targets []config.TargetConfig) {
+	for _, target := range targets {
+		group := target.Group
+		if group == "" {
+			group = "-"
+		}
+		if len(target.Groups) > 0 {
+			group = strings.Join(target.Groups, ",")
+		}
+		keys := make([]string, 0, len(target.Options))
+		for key := range target.Options {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		options := make([]string, 0, len(keys))
+		for _, key := range keys {
+			options = append(options, key+"="+target.Options[key])
+		}
+		optionText := "-"
+		if len(options) > 0 {
+			optionText = strings.Join(options, " ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", target.Name, target.Address, group, optionText)
+	}
+}
+
 func signalContext() (context.Context, context.CancelFunc) {
 	ctx, cancel := context.WithCancel(context.Background())
 	ch := make(chan os.Signal, 1)
@@ -265,6 +812,24 @@ func signalContext() (context.Context, context.CancelFunc) {
 	return ctx, cancel
 }
 
+// stopAfter cancels the run once d has passed, for --max-runtime. It cancels
+// rather than using a context deadline so that shutdown takes the same path as
+// SIGINT and components do not report context.DeadlineExceeded as a failure.
+func stopAfter(ctx context.Context, d time.Duration, cancel context.CancelFunc, logger *log.Logger) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+		if logger != nil {
+			logger.Info("Max runtime reached, shutting down", map[string]interface{}{
+				"max_runtime": d.String(),
+			})
+		}
+		cancel()
+	}
+}
+
 func requestReload(ch chan<- struct{}) {
 	select {
 	case ch <- struct{}{}:
@@ -272,10 +837,94 @@ func requestReload(ch chan<- struct{}) {
 	}
 }
 
-func runTextReporter(ctx context.Context, store state.Store) {
+// startupPause announces the TUI and waits for delay before it clears the
+// terminal, so scripted launches keep their preceding output visible for a
+// moment. Monitoring has already started; ctx cancellation ends the wait.
+func startupPause(ctx context.Context, w io.Writer, delay time.Duration, targets int) {
+	if delay <= 0 {
+		return
+	}
+	fmt.Fprintf(w, "surveiller: starting, %d targets, press q to quit\n", targets)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// watchDownTargets polls the store until a target has been DOWN for at least
+// failAfter, returning that target. It returns false when ctx is cancelled first.
+func watchDownTargets(ctx context.Context, store state.Store, failAfter time.Duration) (state.TargetStatus, bool) {
+	ticker := time.NewTicker(watchInterval(failAfter))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return state.TargetStatus{}, false
+		case <-ticker.C:
+			if target, ok := longestDown(store.GetSnapshot(), failAfter, time.Now()); ok {
+				return target, true
+			}
+		}
+	}
+}
+
+func watchInterval(failAfter time.Duration) time.Duration {
+	interval := failAfter / 10
+	if interval < 10*time.Millisecond {
+		return 10 * time.Millisecond
+	}
+	if interval > time.Second {
+		return time.Second
+	}
+	return interval
+}
+
+// longestDown returns the target that has been DOWN the longest, if that is at least failAfter.
+func longestDown(snapshot []state.TargetStatus, failAfter time.Duration, now time.Time) (state.TargetStatus, bool) {
+	var worst state.TargetStatus
+	found := false
+	for _, target := range snapshot {
+		if target.Status != state.StatusDown || target.StatusSince.IsZero() {
+			continue
+		}
+		if now.Sub(target.StatusSince) < failAfter {
+			continue
+		}
+		if !found || target.StatusSince.Before(worst.StatusSince) {
+			worst = target
+			found = true
+		}
+	}
+	return worst, found
+}
+
+// notifyReload delivers a reload outcome without blocking when nobody is listening.
+func notifyReload(ch chan ui.ReloadResult, result ui.ReloadResult) {
+	select {
+	case ch <- result:
+	default:
+		// Drop the stale result so the latest outcome wins.
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- result:
+		default:
+		}
+	}
+}
+
+// runTextReporter prints the snapshot every second. In quiet mode only status
+// transitions are printed.
+func runTextReporter(ctx context.Context, store state.Store, quiet bool) {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
+	previous := make(map[string]state.Status)
 	for {
 		select {
 		case <-ctx.Done():
@@ -285,22 +934,104 @@ func runTextReporter(ctx context.Context, store state.Store) {
 			if len(snapshot) == 0 {
 				continue
 			}
-			sort.Slice(snapshot, func(i, j int) bool {
-				return snapshot[i].Name < snapshot[j].Name
-			})
-			fmt.Fprintf(os.Stdout, "[%s] targets=%d\n", time.Now().Format(time.RFC3339), len(snapshot))
-			for _, target := range snapshot {
-				fmt.Fprintf(
-					os.Stdout,
-					"- %s (%s) status=%s rtt=%s ok=%d ng=%d\n",
-					target.Name,
-					target.Address,
-					target.Status,
-					target.LastRTT,
-					target.ConsecutiveOK,
-					target.ConsecutiveNG,
-				)
+			if quiet {
+				writeTransitions(os.Stdout, previous, snapshot, time.Now())
+				continue
 			}
+			writeSnapshot(os.Stdout, snapshot, time.Now())
+		}
+	}
+}
+
+// writeTransitions prints one line per target whose status differs from
+// previous, then records the new statuses in previous. Targets not seen before
+// are compared against UNKNOWN, so their first real status is reported once.
+func writeTransitions(w io.Writer, previous map[string]state.Status, snapshot []state.TargetStatus, now time.Time) {
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Name < snapshot[j].Name
+	})
+	seen := make(map[string]bool, len(snapshot))
+	for _, target := range snapshot {
+		seen[target.Name] = true
+		from, ok := previous[target.Name]
+		if !ok {
+			from = state.StatusUnknown
+		}
+		previous[target.Name] = target.Status
+		if from == target.Status {
+			continue
+		}
+		fmt.Fprintf(
+			w,
+			"[%s] %s (%s) %s -> %s rtt=%s\n",
+			now.Format(time.RFC3339),
+			target.Name,
+			target.Address,
+			from,
+			target.Status,
+			target.LastRTT,
+		)
+	}
+	// Forget targets removed by a reload so that re-adding them reports again.
+	for name := range previous {
+		if !seen[name] {
+			delete(previous, name)
+		}
+	}
+}
+
+// writeSnapshot prints snapshot in the text reporter format, sorted by name.
+func writeSnapshot(w io.Writer, snapshot []state.TargetStatus, now time.Time) {
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Name < snapshot[j].Name
+	})
+	fmt.Fprintf(w, "[%s] targets=%d\n", now.Format(time.RFC3339), len(snapshot))
+	for _, target := range snapshot {
+		fmt.Fprintf(
+			w,
+			"- %s (%s) status=%s rtt=%s ok=%d ng=%d\n",
+			target.Name,
+			target.Address,
+			target.Status,
+			target.LastRTT,
+			target.ConsecutiveOK,
+			target.ConsecutiveNG,
+		)
+	}
+}
+
+// logSnapshot logs one line per target of snapshot, sorted by name.
+func logSnapshot(logger *log.Logger, snapshot []state.TargetStatus) {
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Name < snapshot[j].Name
+	})
+	for _, target := range snapshot {
+		logger.Info("Target status", map[string]interface{}{
+			"target":  target.Name,
+			"address": target.Address,
+			"status":  string(target.Status),
+			"rtt":     target.LastRTT.String(),
+			"ok":      target.ConsecutiveOK,
+			"ng":      target.ConsecutiveNG,
+		})
+	}
+}
+
+// dumpOnSignal calls dump each time one of dumpSignals is received. It does
+// nothing on platforms without a dump signal.
+func dumpOnSignal(ctx context.Context, dump func()) {
+	if len(dumpSignals) == 0 {
+		return
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, dumpSignals...)
+	defer signal.Stop(ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			dump()
 		}
 	}
 }
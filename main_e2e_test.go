@@ -175,7 +175,7 @@ target2 192.0.2.2
 	mockPinger.SetDefaultResult(true, 10*time.Millisecond)
 
 	// 4. スケジューラー起動
-	store := state.NewStore(cfg.Targets, cfg.Global.Timeout)
+	store := state.NewStore(cfg.Targets, cfg.Global.Timeout, state.Thresholds{})
 	logger := log.NewLogger(log.LevelInfo)
 	sched := scheduler.NewScheduler(cfg.Global, cfg.Targets, mockPinger, store, logger)
 
@@ -249,7 +249,7 @@ func TestE2E_TUIAndBackend(t *testing.T) {
 		{Name: "target2", Address: "192.0.2.2", Group: "group1"},
 	}
 	timeout := 100 * time.Millisecond
-	store := state.NewStore(targets, timeout)
+	store := state.NewStore(targets, timeout, state.Thresholds{})
 
 	// 2. TUI初期化（実際のスクリーンは使用しない）
 	cfg := config.GlobalOptions{
@@ -351,7 +351,7 @@ target2 192.0.2.2
 	mockPinger := NewMockPinger()
 	mockPinger.SetDefaultResult(true, 10*time.Millisecond)
 
-	store := state.NewStore(cfg.Targets, cfg.Global.Timeout)
+	store := state.NewStore(cfg.Targets, cfg.Global.Timeout, state.Thresholds{})
 	logger := log.NewLogger(log.LevelInfo)
 	sched := scheduler.NewScheduler(cfg.Global, cfg.Targets, mockPinger, store, logger)
 
@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
 	"strings"
 	"testing"
 	"time"
@@ -16,6 +20,7 @@ import (
 	"github.com/doridoridoriand/surveiller/internal/log"
 	"github.com/doridoridoriand/surveiller/internal/ping"
 	"github.com/doridoridoriand/surveiller/internal/state"
+	"github.com/doridoridoriand/surveiller/internal/ui"
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/prop"
 )
@@ -24,7 +29,7 @@ import (
 func TestBuildOverrides(t *testing.T) {
 	tests := []struct {
 		name             string
-		setupInterval    func() cli.OptionalDuration
+		setupInterval    func() cli.OptionalInterval
 		setupTimeout     func() cli.OptionalDuration
 		setupMaxConc     func() cli.OptionalInt
 		setupMetrics     func() cli.OptionalMetricsMode
@@ -45,8 +50,8 @@ func TestBuildOverrides(t *testing.T) {
 	}{
 		{
 			name: "all overrides set",
-			setupInterval: func() cli.OptionalDuration {
-				var d cli.OptionalDuration
+			setupInterval: func() cli.OptionalInterval {
+				var d cli.OptionalInterval
 				d.Set("2s")
 				return d
 			},
@@ -90,8 +95,8 @@ func TestBuildOverrides(t *testing.T) {
 		},
 		{
 			name: "partial overrides",
-			setupInterval: func() cli.OptionalDuration {
-				var d cli.OptionalDuration
+			setupInterval: func() cli.OptionalInterval {
+				var d cli.OptionalInterval
 				d.Set("3s")
 				return d
 			},
@@ -111,7 +116,7 @@ func TestBuildOverrides(t *testing.T) {
 		},
 		{
 			name:          "no overrides",
-			setupInterval: func() cli.OptionalDuration { return cli.OptionalDuration{} },
+			setupInterval: func() cli.OptionalInterval { return cli.OptionalInterval{} },
 			setupTimeout:  func() cli.OptionalDuration { return cli.OptionalDuration{} },
 			setupMaxConc:  func() cli.OptionalInt { return cli.OptionalInt{} },
 			setupMetrics:  func() cli.OptionalMetricsMode { return cli.OptionalMetricsMode{} },
@@ -187,7 +192,7 @@ func TestBuildOverrides_EmptyValues(t *testing.T) {
 	var emptyListen cli.OptionalString
 	// Don't call Set, so it remains unset
 	overrides := buildOverrides(
-		cli.OptionalDuration{},
+		cli.OptionalInterval{},
 		cli.OptionalDuration{},
 		cli.OptionalInt{},
 		cli.OptionalMetricsMode{},
@@ -311,7 +316,511 @@ func TestConfigLoading_InvalidFile(t *testing.T) {
 	}
 }
 
+func TestBuildOverridesNormalizesListenPort(t *testing.T) {
+	var listen cli.OptionalString
+	listen.Set("9100")
+
+	overrides := buildOverrides(cli.OptionalInterval{}, cli.OptionalDuration{}, cli.OptionalInt{}, cli.OptionalMetricsMode{}, listen, cli.OptionalBool{})
+	if overrides.MetricsListen == nil || *overrides.MetricsListen != ":9100" {
+		t.Fatalf("expected --metrics-listen 9100 to become :9100, got %v", overrides.MetricsListen)
+	}
+}
+
+func TestLoggerTimeFormat(t *testing.T) {
+	cases := []struct {
+		format string
+		check  func(string) bool
+	}{
+		{format: "", check: func(ts string) bool { _, err := time.Parse(time.RFC3339, ts); return err == nil }},
+		{format: log.TimeFormatEpoch, check: func(ts string) bool { return len(ts) == 10 && strings.Trim(ts, "0123456789") == "" }},
+		{format: log.TimeFormatEpochMS, check: func(ts string) bool { return len(ts) == 13 && strings.Trim(ts, "0123456789") == "" }},
+		{format: "2006-01-02", check: func(ts string) bool { _, err := time.Parse("2006-01-02", ts); return err == nil }},
+	}
+
+	for _, tc := range cases {
+		var buf bytes.Buffer
+		logger := log.NewLogger(log.LevelInfo)
+		logger.SetOutput(&buf)
+		logger.SetTimeFormat(tc.format)
+		logger.Info("hello", nil)
+
+		var entry log.LogEntry
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("format %q: invalid JSON %q: %v", tc.format, buf.String(), err)
+		}
+		if !tc.check(entry.Timestamp) {
+			t.Errorf("format %q: unexpected timestamp %q", tc.format, entry.Timestamp)
+		}
+	}
+}
+
+func TestNoColorRequested(t *testing.T) {
+	env := func(value string) func(string) string {
+		return func(key string) string {
+			if key == "NO_COLOR" {
+				return value
+			}
+			return ""
+		}
+	}
+	if noColorRequested(false, env("")) {
+		t.Fatalf("expected colors by default")
+	}
+	if !noColorRequested(false, env("1")) {
+		t.Fatalf("expected NO_COLOR to disable colors")
+	}
+	if !noColorRequested(true, env("")) {
+		t.Fatalf("expected --no-color to disable colors")
+	}
+}
+
+func TestVersionText(t *testing.T) {
+	text := versionText("1.2.3", nil)
+	if !strings.HasPrefix(text, "surveiller version 1.2.3\n") || !strings.Contains(text, runtime.Version()) {
+		t.Fatalf("unexpected version text without build info:\n%s", text)
+	}
+	if strings.Contains(text, "revision:") {
+		t.Fatalf("expected no revision without build info:\n%s", text)
+	}
+
+	info := &debug.BuildInfo{Settings: []debug.BuildSetting{
+		{Key: "vcs.revision", Value: "0123abcd"},
+		{Key: "vcs.time", Value: "2026-01-02T03:04:05Z"},
+		{Key: "vcs.modified", Value: "true"},
+	}}
+	text = versionText("1.2.3", info)
+	for _, want := range []string{
+		"  platform: " + runtime.GOOS + "/" + runtime.GOARCH + "\n",
+		"  revision: 0123abcd (modified)\n",
+		"  commit:   2026-01-02T03:04:05Z\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in version text:\n%s", want, text)
+		}
+	}
+}
+
+func TestLogSnapshot(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewLogger(log.LevelInfo)
+	logger.SetOutput(&buf)
+
+	logSnapshot(logger, []state.TargetStatus{
+		{Name: "web", Address: "192.0.2.2", Status: state.StatusDown, ConsecutiveNG: 3},
+		{Name: "db", Address: "192.0.2.1", Status: state.StatusOK, LastRTT: 2 * time.Millisecond, ConsecutiveOK: 5},
+	})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one log line per target, got %q", buf.String())
+	}
+	for i, want := range [][]string{
+		{`"target":"db"`, `"status":"OK"`, `"rtt":"2ms"`, `"ok":5`},
+		{`"target":"web"`, `"status":"DOWN"`, `"ng":3`},
+	} {
+		for _, field := range want {
+			if !strings.Contains(lines[i], field) {
+				t.Errorf("expected line %d to contain %s, got %q", i, field, lines[i])
+			}
+		}
+	}
+}
+
+func TestLogSkippedLines(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewLogger(log.LevelInfo)
+	logger.SetOutput(&buf)
+
+	logSkippedLines(logger, "conf.d", []config.SkippedLine{
+		{File: "10-web.conf", Line: 3, Err: errors.New("invalid target line: \"broken\"")},
+		{Line: 7, Err: errors.New("duplicate target name")},
+	})
+	output := buf.String()
+	for _, want := range []string{`"file":"10-web.conf"`, `"line":3`, `"line":7`, `"skipped":2`, `duplicate target name`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected log to contain %s, got %q", want, output)
+		}
+	}
+
+	buf.Reset()
+	logSkippedLines(logger, "surveiller.conf", nil)
+	if buf.Len() != 0 {
+		t.Fatalf("expected no log entries without skipped lines, got %q", buf.String())
+	}
+}
+
+func TestLogLevelOverride(t *testing.T) {
+	if level, err := logLevelOverride("", ""); err != nil || level != nil {
+		t.Fatalf("expected no override by default, got %v %v", level, err)
+	}
+	if level, err := logLevelOverride("", "debug"); err != nil || level == nil || *level != "debug" {
+		t.Fatalf("expected SURVEILLER_LOG_LEVEL to override, got %v %v", level, err)
+	}
+	if level, err := logLevelOverride("", "verbose"); err != nil || level != nil {
+		t.Fatalf("expected an unknown SURVEILLER_LOG_LEVEL to be ignored, got %v %v", level, err)
+	}
+	if level, err := logLevelOverride("warn", "debug"); err != nil || level == nil || *level != "warn" {
+		t.Fatalf("expected --log-level to win over the environment, got %v %v", level, err)
+	}
+	if _, err := logLevelOverride("verbose", ""); err == nil {
+		t.Fatalf("expected an error for an unknown --log-level")
+	}
+}
+
+func TestCheckTargetLimits(t *testing.T) {
+	targets := func(n int) []config.TargetConfig {
+		out := make([]config.TargetConfig, n)
+		for i := range out {
+			out[i] = config.TargetConfig{Name: fmt.Sprintf("t%d", i), Address: "192.0.2.1"}
+		}
+		return out
+	}
+	cases := []struct {
+		name    string
+		count   int
+		soft    int
+		hard    int
+		force   bool
+		wantErr bool
+		wantLog string
+	}{
+		{name: "under limits", count: 2, soft: 3, hard: 5},
+		{name: "over soft", count: 4, soft: 3, hard: 5, wantLog: "soft limit"},
+		{name: "over hard", count: 6, soft: 3, hard: 5, wantErr: true},
+		{name: "over hard forced", count: 6, soft: 3, hard: 5, force: true, wantLog: "hard limit"},
+		{name: "limits disabled", count: 6},
+	}
+
+	for _, tc := range cases {
+		var buf bytes.Buffer
+		logger := log.NewLogger(log.LevelInfo)
+		logger.SetOutput(&buf)
+		cfg := &config.Config{
+			Global:  config.GlobalOptions{TargetSoftLimit: tc.soft, TargetHardLimit: tc.hard},
+			Targets: targets(tc.count),
+		}
+
+		err := checkTargetLimits(cfg, tc.force, logger)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.wantErr, err)
+		}
+		if tc.wantLog == "" && buf.Len() > 0 {
+			t.Errorf("%s: expected no log output, got %q", tc.name, buf.String())
+		}
+		if tc.wantLog != "" && !strings.Contains(buf.String(), tc.wantLog) {
+			t.Errorf("%s: expected log containing %q, got %q", tc.name, tc.wantLog, buf.String())
+		}
+	}
+}
+
+func TestWriteTransitions(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	previous := make(map[string]state.Status)
+	var buf bytes.Buffer
+
+	writeTransitions(&buf, previous, []state.TargetStatus{
+		{Name: "web", Address: "192.0.2.1", Status: state.StatusOK, LastRTT: 5 * time.Millisecond},
+		{Name: "db", Address: "192.0.2.2", Status: state.StatusUnknown},
+	}, now)
+	if got, want := buf.String(), "[2024-01-02T03:04:05Z] web (192.0.2.1) UNKNOWN -> OK rtt=5ms\n"; got != want {
+		t.Fatalf("first tick: got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	writeTransitions(&buf, previous, []state.TargetStatus{
+		{Name: "web", Address: "192.0.2.1", Status: state.StatusOK, LastRTT: 6 * time.Millisecond},
+		{Name: "db", Address: "192.0.2.2", Status: state.StatusUnknown},
+	}, now)
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output without transitions, got %q", buf.String())
+	}
+
+	writeTransitions(&buf, previous, []state.TargetStatus{
+		{Name: "web", Address: "192.0.2.1", Status: state.StatusDown},
+	}, now)
+	if got := buf.String(); !strings.Contains(got, "web (192.0.2.1) OK -> DOWN") || strings.Contains(got, "db") {
+		t.Fatalf("expected only the web transition, got %q", got)
+	}
+	if _, ok := previous["db"]; ok {
+		t.Fatalf("expected removed target to be forgotten")
+	}
+}
+
+func TestNotifyReloadKeepsLatestResult(t *testing.T) {
+	ch := make(chan ui.ReloadResult, 1)
+	notifyReload(ch, ui.ReloadResult{Targets: 1})
+	notifyReload(ch, ui.ReloadResult{Targets: 2})
+
+	select {
+	case got := <-ch:
+		if got.Targets != 2 {
+			t.Fatalf("expected latest reload result, got %+v", got)
+		}
+	default:
+		t.Fatalf("expected a reload result to be queued")
+	}
+}
+
+func TestRunDiagnose(t *testing.T) {
+	cfg := &config.Config{
+		Global: config.GlobalOptions{Timeout: time.Second},
+		Targets: []config.TargetConfig{
+			{Name: "web", Address: "192.0.2.10", Group: "frontend"},
+		},
+	}
+
+	var buf bytes.Buffer
+	ok := &stubPinger{result: ping.Result{Success: true, RTT: 12 * time.Millisecond, TTL: 57}}
+	if code := runDiagnose(context.Background(), &buf, cfg, "web", ok); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	out := buf.String()
+	for _, want := range []string{"target:   web", "resolved: 192.0.2.10 (literal)", "group:    frontend", "success:  true", "rtt:      12ms", "ttl:      57"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	failing := &stubPinger{result: ping.Result{Error: os.ErrPermission}}
+	if code := runDiagnose(context.Background(), &buf, cfg, "web", failing); code != 1 {
+		t.Fatalf("expected exit code 1 for failed probe, got %d", code)
+	}
+	if !strings.Contains(buf.String(), "error:    permission denied") {
+		t.Errorf("expected error details, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if code := runDiagnose(context.Background(), &buf, cfg, "missing", ok); code != 1 || ok.calls != 1 {
+		t.Fatalf("expected unknown target to fail without probing, code=%d calls=%d", code, ok.calls)
+	}
+}
+
+func TestRunDiagnoseSynthetic(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	cfg := &config.Config{
+		Global: config.GlobalOptions{Timeout: time.Second},
+		Targets: []config.TargetConfig{
+			{Name: "api", Address: ln.Addr().String(), Probe: config.ProbeSynthetic},
+		},
+	}
+
+	// The global pinger would fail; the synthetic target must not use it.
+	var buf bytes.Buffer
+	failing := &stubPinger{result: ping.Result{Error: os.ErrPermission}}
+	if code := runDiagnose(context.Background(), &buf, cfg, "api", failing); code != 0 {
+		t.Fatalf("expected exit code 0, got %d:\n%s", code, buf.String())
+	}
+	if failing.calls != 0 {
+		t.Fatalf("expected the global pinger to be unused, got %d calls", failing.calls)
+	}
+	for _, want := range []string{"resolved: 127.0.0.1 (literal)", "pinger:   synthetic", "success:  true", "phases:   dns=0s connect="} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestPrintTargets(t *testing.T) {
+	targets := []config.TargetConfig{
+		{Name: "google", Address: "8.8.8.8", Options: map[string]string{}},
+		{Name: "relay1", Address: "192.0.2.10", Group: "DNS", Options: map[string]string{"user": "foo", "relay": "jump1"}},
+	}
+
+	var buf bytes.Buffer
+	printTargets(&buf, targets)
+
+	expected := "google\t8.8.8.8\t-\t-\n" +
+		"relay1\t192.0.2.10\tDNS\trelay=jump1 user=foo\n"
+	if buf.String() != expected {
+		t.Errorf("unexpected target listing:\n%q\nwant:\n%q", buf.String(), expected)
+	}
+}
+
+func TestRunInit(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runInit([]string{"8.8.8.8", "example.com"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "\n8.8.8.8 8.8.8.8\nexample.com example.com\n") {
+		t.Fatalf("expected one line per host, got:\n%s", stdout.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "surveiller.conf")
+	stdout.Reset()
+	if code := runInit([]string{"-o", path, "8.8.8.8"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("expected nothing on stdout with -o, got %q", stdout.String())
+	}
+	cfg, err := (config.SurveillerParser{}).LoadConfig(path, config.CLIOverrides{})
+	if err != nil || len(cfg.Targets) != 1 {
+		t.Fatalf("expected written config to load with one target, got %v, %v", cfg, err)
+	}
+
+	stderr.Reset()
+	if code := runInit([]string{"-o", path, "8.8.4.4"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected refusal to overwrite, got exit code %d", code)
+	}
+	stderr.Reset()
+	if code := runInit([]string{"bad host"}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "invalid hostname") {
+		t.Fatalf("expected invalid host to fail, got %d: %s", code, stderr.String())
+	}
+}
+
 // 6.2 シグナルハンドリングの単体テスト
+type stubPinger struct {
+	result ping.Result
+	calls  int
+}
+
+func (p *stubPinger) Ping(ctx context.Context, addr string, timeout time.Duration) ping.Result {
+	p.calls++
+	return p.result
+}
+
+func TestSelectPinger(t *testing.T) {
+	logger := log.NewLogger(log.LevelInfo)
+	denied := &stubPinger{result: ping.Result{Error: os.ErrPermission}}
+	working := &stubPinger{result: ping.Result{Success: true}}
+	external := &stubPinger{result: ping.Result{Success: true}}
+
+	p, err := selectPinger(context.Background(), pingerFallback, denied, external, logger)
+	if err != nil || p != ping.Pinger(external) {
+		t.Fatalf("expected external pinger when ICMP is denied, got %T (err=%v)", p, err)
+	}
+
+	p, err = selectPinger(context.Background(), pingerFallback, working, external, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := p.(*ping.FallbackPinger); !ok {
+		t.Fatalf("expected fallback pinger when ICMP works, got %T", p)
+	}
+
+	denied.calls = 0
+	p, _ = selectPinger(context.Background(), pingerICMP, denied, external, logger)
+	if p != ping.Pinger(denied) || denied.calls != 0 {
+		t.Fatalf("expected forced ICMP pinger without probing")
+	}
+	working.calls = 0
+	p, _ = selectPinger(context.Background(), pingerExternal, working, external, logger)
+	if p != ping.Pinger(external) || working.calls != 0 {
+		t.Fatalf("expected forced external pinger without touching ICMP")
+	}
+
+	if _, err := selectPinger(context.Background(), "raw", working, external, logger); err == nil {
+		t.Fatalf("expected error for invalid mode")
+	}
+}
+
+func TestLongestDown(t *testing.T) {
+	now := time.Now()
+	snapshot := []state.TargetStatus{
+		{Name: "ok", Status: state.StatusOK, StatusSince: now.Add(-time.Hour)},
+		{Name: "recent", Status: state.StatusDown, StatusSince: now.Add(-10 * time.Second)},
+		{Name: "old", Status: state.StatusDown, StatusSince: now.Add(-2 * time.Minute)},
+		{Name: "older", Status: state.StatusDown, StatusSince: now.Add(-5 * time.Minute)},
+	}
+
+	target, ok := longestDown(snapshot, time.Minute, now)
+	if !ok || target.Name != "older" {
+		t.Fatalf("expected longest DOWN target 'older', got %q (ok=%v)", target.Name, ok)
+	}
+	if _, ok := longestDown(snapshot, time.Hour, now); ok {
+		t.Fatalf("expected no target DOWN for an hour")
+	}
+}
+
+func TestStartupPause(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Now()
+	startupPause(context.Background(), &buf, 20*time.Millisecond, 3)
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("expected to wait the startup delay, returned after %s", elapsed)
+	}
+	if buf.String() != "surveiller: starting, 3 targets, press q to quit\n" {
+		t.Fatalf("unexpected startup message: %q", buf.String())
+	}
+
+	buf.Reset()
+	startupPause(context.Background(), &buf, 0, 3)
+	if buf.Len() != 0 {
+		t.Fatalf("expected no message without a delay, got %q", buf.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start = time.Now()
+	startupPause(ctx, &buf, time.Hour, 3)
+	if time.Since(start) > time.Second {
+		t.Fatalf("expected cancellation to end the wait")
+	}
+}
+
+func TestStopAfter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var buf bytes.Buffer
+	logger := log.NewLogger(log.LevelInfo)
+	logger.SetOutput(&buf)
+
+	start := time.Now()
+	stopAfter(ctx, 20*time.Millisecond, cancel, logger)
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("expected to wait the max runtime, returned after %s", elapsed)
+	}
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Fatalf("expected the run to be canceled, got %v", ctx.Err())
+	}
+	if !strings.Contains(buf.String(), "Max runtime reached") {
+		t.Fatalf("expected a shutdown log entry, got %q", buf.String())
+	}
+
+	// A run stopped earlier, e.g. by a signal, ends the wait without logging.
+	buf.Reset()
+	done, stop := context.WithCancel(context.Background())
+	stop()
+	stopAfter(done, time.Hour, func() { t.Fatalf("expected no cancel after the run ended") }, logger)
+	if buf.Len() != 0 {
+		t.Fatalf("expected no log entry, got %q", buf.String())
+	}
+}
+
+func TestWatchDownTargets(t *testing.T) {
+	store := state.NewStore([]config.TargetConfig{{Name: "a", Address: "192.0.2.1"}}, time.Second, state.Thresholds{})
+	for i := 0; i < 3; i++ {
+		store.UpdateResult("a", ping.Result{Success: false, Error: fmt.Errorf("timeout")})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	target, ok := watchDownTargets(ctx, store, 20*time.Millisecond)
+	if !ok || target.Name != "a" {
+		t.Fatalf("expected target 'a' to trip fail-after, got %q (ok=%v)", target.Name, ok)
+	}
+
+	cancelled, stop := context.WithCancel(context.Background())
+	stop()
+	if _, ok := watchDownTargets(cancelled, store, time.Hour); ok {
+		t.Fatalf("expected no result after cancellation")
+	}
+}
+
 func TestSignalContext_Cancellation(t *testing.T) {
 	ctx, cancel := signalContext()
 	defer cancel()
@@ -395,7 +904,7 @@ func TestPropertyConfigReloadErrorHandling(t *testing.T) {
 			}
 
 			// Initialize store
-			store := state.NewStore(cfg.Targets, cfg.Global.Timeout)
+			store := state.NewStore(cfg.Targets, cfg.Global.Timeout, state.Thresholds{})
 
 			// Add history to targets
 			for i := 0; i < targetCount; i++ {
@@ -491,7 +1000,7 @@ func TestPropertyConfigReloadErrorHandling(t *testing.T) {
 			}
 
 			// Initialize store
-			store := state.NewStore(cfg.Targets, cfg.Global.Timeout)
+			store := state.NewStore(cfg.Targets, cfg.Global.Timeout, state.Thresholds{})
 
 			// Add some state
 			for i := 0; i < targetCount; i++ {
@@ -572,7 +1081,7 @@ func TestPropertyConfigReloadErrorHandling(t *testing.T) {
 			}
 
 			// Initialize store
-			store := state.NewStore(cfg.Targets, cfg.Global.Timeout)
+			store := state.NewStore(cfg.Targets, cfg.Global.Timeout, state.Thresholds{})
 
 			// Attempt reload with invalid config
 			_, err = parser.LoadConfig(invalidConfigPath, config.CLIOverrides{})
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// dumpSignals trigger a one-off snapshot dump to stderr.
+var dumpSignals = []os.Signal{syscall.SIGUSR1}
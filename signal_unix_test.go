@@ -0,0 +1,68 @@
+//go:build !windows
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/doridoridoriand/surveiller/internal/config"
+	"github.com/doridoridoriand/surveiller/internal/state"
+)
+
+func TestDumpOnSignal(t *testing.T) {
+	store := state.NewStore([]config.TargetConfig{{Name: "web", Address: "192.0.2.1"}}, time.Second, state.Thresholds{})
+	var out lockedBuffer
+
+	// Keep SIGUSR1 from terminating the test binary before dumpOnSignal registers.
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGUSR1)
+	defer signal.Stop(guard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dumpOnSignal(ctx, func() { writeSnapshot(&out, store.GetSnapshot(), time.Now()) })
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(out.String(), "- web (192.0.2.1)") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected snapshot dump after SIGUSR1, got %q", out.String())
+		}
+		// Resend until dumpOnSignal has registered its handler.
+		time.Sleep(20 * time.Millisecond)
+		if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatalf("kill: %v", err)
+		}
+	}
+
+	cancel()
+	<-done
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}